@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/YanSystems/cms/pkg/backup"
+)
+
+func backupDir(flags *flag.FlagSet) *string {
+	return flags.String("dir", "", "snapshot directory, YAN_CMS_BACKUP_DIR by default")
+}
+
+// backupConfig reads the backup settings from the environment, with -dir
+// taking precedence over YAN_CMS_BACKUP_DIR.
+func backupConfig(dir string) (backup.Config, error) {
+	cfg, err := backup.ConfigFromEnv()
+	if err != nil {
+		return cfg, err
+	}
+	if dir != "" {
+		cfg.Dir = dir
+	}
+	if cfg.Dir == "" {
+		return cfg, usagef("-dir or YAN_CMS_BACKUP_DIR is required")
+	}
+	return cfg, nil
+}
+
+func runBackup(c *cli, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dir := backupDir(flags)
+	archive := flags.Bool("archive", false, "write a tar archive instead of a directory")
+	if _, err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := backupConfig(*dir)
+	if err != nil {
+		return err
+	}
+	cfg.Archive = cfg.Archive || *archive
+
+	// Taking a snapshot by hand applies the same retention rules as the
+	// scheduled ones.
+	manifest, err := backup.NewScheduler(cfg, c.local.server.DB).RunOnce(c.ctx)
+	if err != nil {
+		return err
+	}
+	return c.printSnapshots([]backup.Manifest{*manifest})
+}
+
+func runBackups(c *cli, args []string) error {
+	flags := flag.NewFlagSet("backups", flag.ContinueOnError)
+	dir := backupDir(flags)
+	if _, err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := backupConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	manifests, err := (&backup.Store{Dir: cfg.Dir}).List()
+	if err != nil {
+		return err
+	}
+	return c.printSnapshots(manifests)
+}
+
+func (c *cli) printSnapshots(manifests []backup.Manifest) error {
+	rows := make([][]string, len(manifests))
+	for i, m := range manifests {
+		var documents int64
+		for _, cm := range m.Collections {
+			documents += cm.Documents
+		}
+		rows[i] = []string{
+			m.Id,
+			formatTime(m.CreatedAt),
+			strconv.Itoa(len(m.Collections)),
+			strconv.FormatInt(documents, 10),
+			strconv.FormatBool(m.Consistent),
+			strconv.FormatBool(m.Archive),
+		}
+	}
+	return c.print(manifests, []string{"SNAPSHOT", "CREATED", "COLLECTIONS", "DOCUMENTS", "CONSISTENT", "ARCHIVE"}, rows)
+}
+
+func runRestore(c *cli, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dir := backupDir(flags)
+	coll := flags.String("collection", "", "only restore this collection")
+	id := flags.String("id", "", "only restore this item of -collection")
+	args, err := parse(flags, args, "snapshot")
+	if err != nil {
+		return err
+	}
+	if *id != "" && *coll == "" {
+		return usagef("-id needs -collection")
+	}
+
+	cfg, err := backupConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := (&backup.Store{Dir: cfg.Dir}).Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	restorer := backup.Restorer{DB: c.local.server.DB, SystemDB: c.local.server.SystemDB}
+	switch {
+	case *id != "":
+		if err := restorer.RestoreItem(c.ctx, snapshot, *coll, *id); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Restored item %s of %s from %s\n", *id, *coll, snapshot.Id)
+	case *coll != "":
+		documents, err := restorer.RestoreCollection(c.ctx, snapshot, *coll)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Restored %d documents of %s from %s\n", documents, *coll, snapshot.Id)
+	default:
+		if err := restorer.RestoreDatabase(c.ctx, snapshot); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Restored %d collections from %s\n", len(snapshot.Collections), snapshot.Id)
+	}
+	return nil
+}
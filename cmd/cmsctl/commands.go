@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/YanSystems/cms/pkg/client"
+	"github.com/YanSystems/cms/pkg/idempotency"
+	"github.com/YanSystems/cms/pkg/migrations"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/ratelimit"
+)
+
+// parse parses the flags of a command and checks it was given exactly the
+// named arguments.
+func parse(flags *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, usagef("%v", err)
+	}
+	if flags.NArg() != len(names) {
+		return nil, usagef("expected arguments: %v, got %d", names, flags.NArg())
+	}
+	return flags.Args(), nil
+}
+
+// readInput reads a file, or standard input when path is "-".
+func (c *cli) readInput(path string) ([]byte, error) {
+	if path == "" {
+		return nil, usagef("-f is required")
+	}
+	if path == "-" {
+		return io.ReadAll(c.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func runCollections(c *cli, args []string) error {
+	if _, err := parse(flag.NewFlagSet("collections", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	names, err := c.client.ListCollections(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		rows[i] = []string{name}
+	}
+	return c.print(names, []string{"COLLECTION"}, rows)
+}
+
+func runClasses(c *cli, args []string) error {
+	args, err := parse(flag.NewFlagSet("classes", flag.ContinueOnError), args, "collection")
+	if err != nil {
+		return err
+	}
+
+	classes, err := c.client.ListClasses(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(classes))
+	for i, class := range classes {
+		rows[i] = []string{class}
+	}
+	return c.print(classes, []string{"CLASS"}, rows)
+}
+
+func runList(c *cli, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	class := flags.String("class", "", "only list this class")
+	args, err := parse(flags, args, "collection")
+	if err != nil {
+		return err
+	}
+
+	var contents []models.Content
+	if *class != "" {
+		contents, err = c.client.GetClass(c.ctx, args[0], *class)
+	} else {
+		contents, err = c.client.GetCollection(c.ctx, args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(contents))
+	for i, content := range contents {
+		rows[i] = []string{
+			content.Id,
+			content.Class,
+			truncate(content.Title, 40),
+			strconv.FormatBool(content.IsPublic),
+			formatTime(content.UpdatedAt),
+		}
+	}
+	return c.print(contents, []string{"ID", "CLASS", "TITLE", "PUBLIC", "UPDATED"}, rows)
+}
+
+func runGet(c *cli, args []string) error {
+	args, err := parse(flag.NewFlagSet("get", flag.ContinueOnError), args, "collection", "id")
+	if err != nil {
+		return err
+	}
+
+	content, err := c.client.GetContent(c.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"id", content.Id},
+		{"class", content.Class},
+		{"title", content.Title},
+		{"description", truncate(content.Description, 80)},
+		{"body", truncate(content.Body, 80)},
+		{"is_public", strconv.FormatBool(content.IsPublic)},
+		{"views", strconv.Itoa(content.Views)},
+		{"creator_id", content.CreatorId},
+		{"created_at", formatTime(content.CreatedAt)},
+		{"updated_at", formatTime(content.UpdatedAt)},
+	}
+	return c.print(content, nil, rows)
+}
+
+func runCreate(c *cli, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	file := flags.String("f", "", "JSON file with one item or an array of items, or - for standard input")
+	args, err := parse(flags, args, "collection")
+	if err != nil {
+		return err
+	}
+
+	data, err := c.readInput(*file)
+	if err != nil {
+		return err
+	}
+
+	var contents []models.Content
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = decodeStrict(data, &contents)
+	} else {
+		contents = make([]models.Content, 1)
+		err = decodeStrict(data, &contents[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	ids := []string{}
+	for i := range contents {
+		id, err := c.client.CreateContent(c.ctx, args[0], &contents[i])
+		if err != nil {
+			// Report what was created before the failure, so it is not
+			// created twice when the command is run again.
+			c.printIds(ids)
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+		ids = append(ids, id)
+	}
+	return c.printIds(ids)
+}
+
+func (c *cli) printIds(ids []string) error {
+	rows := make([][]string, len(ids))
+	for i, id := range ids {
+		rows[i] = []string{id}
+	}
+	return c.print(ids, []string{"ID"}, rows)
+}
+
+func runUpdate(c *cli, args []string) error {
+	flags := flag.NewFlagSet("update", flag.ContinueOnError)
+	file := flags.String("f", "", "JSON file with the fields to change, or - for standard input")
+	args, err := parse(flags, args, "collection", "id")
+	if err != nil {
+		return err
+	}
+
+	data, err := c.readInput(*file)
+	if err != nil {
+		return err
+	}
+
+	var update models.UpdateContent
+	if err := decodeStrict(data, &update); err != nil {
+		return err
+	}
+
+	if err := c.client.UpdateContent(c.ctx, args[0], args[1], &update); err != nil {
+		return err
+	}
+	return c.printIds([]string{args[1]})
+}
+
+func runExport(c *cli, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	class := flags.String("class", "", "only export this class")
+	compress := flags.Bool("gzip", false, "compress the export with gzip")
+	file := flags.String("f", "-", "file to write, or - for standard output")
+	args, err := parse(flags, args, "collection")
+	if err != nil {
+		return err
+	}
+
+	body, err := c.client.ExportCollection(c.ctx, args[0], *class, *compress)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	out := c.stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	_, err = io.Copy(out, body)
+	return err
+}
+
+func runImport(c *cli, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("f", "", "NDJSON file to import, plain or gzipped, or - for standard input")
+	ids := flags.String("ids", "preserve", "preserve or regenerate")
+	onConflict := flags.String("on-conflict", "fail", "skip, overwrite or fail")
+	dryRun := flags.Bool("dry-run", false, "report what would happen without writing")
+	args, err := parse(flags, args, "collection")
+	if err != nil {
+		return err
+	}
+
+	data, err := c.readInput(*file)
+	if err != nil {
+		return err
+	}
+
+	report, err := c.client.ImportCollection(c.ctx, args[0], bytes.NewReader(data), client.ImportOptions{
+		Ids:        *ids,
+		OnConflict: *onConflict,
+		DryRun:     *dryRun,
+	})
+	if report != nil {
+		c.printReport(report)
+	}
+	return err
+}
+
+func (c *cli) printReport(report *models.ImportReport) error {
+	if c.json {
+		return c.print(report, nil, nil)
+	}
+
+	prefix := ""
+	if report.DryRun {
+		prefix = "Dry run: "
+	}
+	fmt.Fprintf(c.stdout, "%s%d lines, %d created, %d replaced, %d skipped, %d failed\n",
+		prefix, report.Lines, report.Created, report.Replaced, report.Skipped, report.Failed)
+	if len(report.Errors) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(c.stdout)
+	rows := make([][]string, len(report.Errors))
+	for i, e := range report.Errors {
+		rows[i] = []string{strconv.Itoa(e.Line), e.Id, e.Message}
+	}
+	return c.print(nil, []string{"LINE", "ID", "ERROR"}, rows)
+}
+
+func runReindex(c *cli, args []string) error {
+	if _, err := parse(flag.NewFlagSet("reindex", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	rateLimit, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	c.local.server.RateLimit = &rateLimit
+
+	idempotencyConfig, err := idempotency.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	c.local.server.Idempotency = &idempotencyConfig
+
+	if err := c.local.server.EnsureIndexes(); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, "Indexes are up to date")
+	return nil
+}
+
+func runMigrate(c *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	action := "status"
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		action = flags.Arg(0)
+	default:
+		return usagef("expected at most one argument, status or up")
+	}
+
+	runner := migrations.NewRunner(c.local.server.DB, c.local.server.SystemDB)
+	switch action {
+	case "status":
+		statuses, err := runner.Status(c.ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, len(statuses))
+		for i, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = formatTime(*status.AppliedAt)
+			}
+			rows[i] = []string{status.Id, applied, status.Description}
+		}
+		return c.print(statuses, []string{"ID", "APPLIED", "DESCRIPTION"}, rows)
+	case "up":
+		ran, err := runner.Up(c.ctx)
+		if len(ran) == 0 && err == nil && !c.json {
+			fmt.Fprintln(c.stdout, "No pending migrations")
+			return nil
+		}
+		c.printIds(ran)
+		return err
+	default:
+		return usagef("unknown migrate action %q, expected status or up", action)
+	}
+}
+
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid JSON: expected a single value")
+	}
+	return nil
+}
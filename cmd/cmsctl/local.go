@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/YanSystems/cms/pkg/client"
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/server"
+	"github.com/YanSystems/cms/pkg/services"
+	"github.com/YanSystems/cms/pkg/utils"
+	dispatch "github.com/YanSystems/cms/pkg/webhooks"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// local serves the API from inside cmsctl on a loopback port, so commands
+// behave the same with and without -server and go through the same
+// validation, change tracking, audit log and webhooks.
+type local struct {
+	server *server.Server
+	client *client.Client
+
+	mongo *mongo.Client
+	http  *http.Server
+}
+
+func connectLocal(ctx context.Context) (*local, error) {
+	mongoClient, err := utils.ConnectToDB()
+	if err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		mongoClient.Disconnect(ctx)
+		return nil, err
+	}
+
+	s := &server.Server{
+		DB:         mongoClient.Database("content"),
+		SystemDB:   mongoClient.Database("cms"),
+		AdminToken: hex.EncodeToString(token),
+		Events:     events.NewBus(),
+	}
+
+	// Changes are audited as the admin. Webhook deliveries are queued here
+	// and sent by the running server on its next poll.
+	auditService := services.AuditService{DB: s.SystemDB}
+	s.Events.Subscribe(auditService.Record)
+	s.Events.Subscribe(dispatch.NewDispatcher(s.SystemDB).Queue)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		mongoClient.Disconnect(ctx)
+		return nil, err
+	}
+
+	l := &local{
+		server: s,
+		client: client.New("http://"+listener.Addr().String(), client.WithToken(s.AdminToken), client.WithUserAgent("cmsctl")),
+		mongo:  mongoClient,
+		http:   &http.Server{Handler: s.NewRouter()},
+	}
+	go func() {
+		if err := l.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Local server encountered an error", "error", err)
+		}
+	}()
+	return l, nil
+}
+
+func (l *local) Close() {
+	l.http.Close()
+	l.mongo.Disconnect(context.Background())
+}
@@ -0,0 +1,165 @@
+// Command cmsctl runs administrative tasks against the CMS, either through a
+// running server or, without -server, directly against the database named by
+// YAN_CMS_DB_URI.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/YanSystems/cms/pkg/client"
+)
+
+const usage = `Usage: cmsctl [flags] <command> [command flags] [arguments]
+
+Commands:
+  collections                          List collections
+  classes <collection>                 List the classes of a collection
+  list [-class c] <collection>         List the items of a collection
+  get <collection> <id>                Show an item
+  create -f file <collection>          Create items from a JSON object or array
+  update -f file <collection> <id>     Update an item from a JSON object
+  export [-class c] [-gzip] [-f file] <collection>
+                                       Export a collection as NDJSON
+  import [-ids m] [-on-conflict m] [-dry-run] -f file <collection>
+                                       Import an NDJSON export
+  reindex                              Create the indexes of every system collection
+  migrate [status|up]                  Show or apply data migrations
+  backup [-dir d] [-archive]           Take a snapshot of the content database
+  backups [-dir d]                     List snapshots
+  restore [-dir d] [-collection c [-id i]] <snapshot>
+                                       Restore the database, a collection or an item
+
+reindex, migrate, backup, backups and restore need direct database access and
+cannot be used with -server. -dir defaults to YAN_CMS_BACKUP_DIR.
+
+Flags:
+`
+
+type command struct {
+	run func(c *cli, args []string) error
+	// local commands need the database and cannot go through a server.
+	local bool
+}
+
+var commands = map[string]command{
+	"collections": {run: runCollections},
+	"classes":     {run: runClasses},
+	"list":        {run: runList},
+	"get":         {run: runGet},
+	"create":      {run: runCreate},
+	"update":      {run: runUpdate},
+	"export":      {run: runExport},
+	"import":      {run: runImport},
+	"reindex":     {run: runReindex, local: true},
+	"migrate":     {run: runMigrate, local: true},
+	"backup":      {run: runBackup, local: true},
+	"backups":     {run: runBackups, local: true},
+	"restore":     {run: runRestore, local: true},
+}
+
+type cli struct {
+	ctx    context.Context
+	client *client.Client
+	local  *local
+	stdin  io.Reader
+	stdout io.Writer
+	json   bool
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cmsctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	server := flags.String("server", os.Getenv("YAN_CMS_URL"), "URL of a running server; without it cmsctl connects to YAN_CMS_DB_URI")
+	token := flags.String("token", os.Getenv("YAN_CMS_TOKEN"), "API key or admin token to use with -server")
+	output := flags.String("o", "table", "output format, table or json")
+	verbose := flags.Bool("v", false, "log what cmsctl is doing")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	level := slog.LevelInfo
+	if !*verbose {
+		level = slog.LevelError + 1
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level})))
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "cmsctl: unknown output format %q, expected table or json\n", *output)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for n := range commands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(stderr, "cmsctl: unknown command %q, expected one of %s\n", name, strings.Join(names, ", "))
+		return 2
+	}
+
+	c := &cli{ctx: ctx, stdin: stdin, stdout: stdout, json: *output == "json"}
+	if *server != "" {
+		if cmd.local {
+			fmt.Fprintf(stderr, "cmsctl: %s needs direct database access and cannot be used with -server\n", name)
+			return 2
+		}
+		c.client = client.New(*server, client.WithToken(*token), client.WithUserAgent("cmsctl"))
+	} else {
+		l, err := connectLocal(ctx)
+		if err != nil {
+			fmt.Fprintf(stderr, "cmsctl: %v\n", err)
+			return 1
+		}
+		defer l.Close()
+		c.local = l
+		c.client = l.client
+	}
+
+	if err := cmd.run(c, flags.Args()[1:]); err != nil {
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "cmsctl %s: %v\n", name, err)
+			return 2
+		}
+		fmt.Fprintf(stderr, "cmsctl %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// usageError reports a mistake in how a command was called, which exits with
+// status 2 rather than 1.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
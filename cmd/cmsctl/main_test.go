@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/client/clienttest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func cmsctl(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestCommands(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	item := `{"class":"lessons","title":"Intro","description":"D","body":"B","creator_id":"` + uuid.New().String() + `"}`
+
+	stdout, stderr, code := cmsctl(t, item, "-server", srv.URL, "-o", "json", "create", "-f", "-", "courses")
+	assert.Equal(t, 0, code, stderr)
+	var ids []string
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &ids))
+	assert.Len(t, ids, 1)
+	id := ids[0]
+
+	t.Run("Create Many", func(t *testing.T) {
+		stdout, stderr, code := cmsctl(t, "["+item+","+item+"]", "-server", srv.URL, "create", "-f", "-", "drafts")
+		assert.Equal(t, 0, code, stderr)
+		assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 3)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		stdout, stderr, code := cmsctl(t, "", "-server", srv.URL, "get", "courses", id)
+		assert.Equal(t, 0, code, stderr)
+		assert.Contains(t, stdout, "title        Intro")
+	})
+
+	t.Run("Update From File", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "update.json")
+		os.WriteFile(file, []byte(`{"title":"Introduction"}`), 0o600)
+
+		_, stderr, code := cmsctl(t, "", "-server", srv.URL, "update", "-f", file, "courses", id)
+		assert.Equal(t, 0, code, stderr)
+		assert.Equal(t, "Introduction", srv.Contents("courses")[0].Title)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		stdout, stderr, code := cmsctl(t, "", "-server", srv.URL, "list", "-class", "lessons", "courses")
+		assert.Equal(t, 0, code, stderr)
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		assert.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], "ID"))
+		assert.Contains(t, lines[1], "Introduction")
+	})
+
+	t.Run("Export And Import", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "courses.ndjson.gz")
+		_, stderr, code := cmsctl(t, "", "-server", srv.URL, "export", "-gzip", "-f", file, "courses")
+		assert.Equal(t, 0, code, stderr)
+
+		stdout, stderr, code := cmsctl(t, "", "-server", srv.URL, "import", "-f", file, "-ids", "regenerate", "copies")
+		assert.Equal(t, 0, code, stderr)
+		assert.Equal(t, "1 lines, 1 created, 0 replaced, 0 skipped, 0 failed\n", stdout)
+
+		stdout, _, code = cmsctl(t, "", "-server", srv.URL, "import", "-f", file, "courses")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout, "content with this ID already exists")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, stderr, code := cmsctl(t, "", "-server", srv.URL, "get", "courses", uuid.New().String())
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "content not found")
+
+		_, stderr, code = cmsctl(t, "", "-server", srv.URL, "get", "courses")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "expected arguments")
+
+		_, stderr, code = cmsctl(t, "", "-server", srv.URL, "reindex")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "needs direct database access")
+
+		_, _, code = cmsctl(t, "", "-server", srv.URL, "frobnicate")
+		assert.Equal(t, 2, code)
+	})
+}
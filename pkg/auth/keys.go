@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	KeyPrefix    = "yan_"
+	keyBytes     = 32
+	prefixLength = 12
+)
+
+func GenerateKey() (key string, prefix string, hash string, err error) {
+	secret := make([]byte, keyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:prefixLength], HashKey(key), nil
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, KeyPrefix)
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKey(t *testing.T) {
+	t.Run("Key Format", func(t *testing.T) {
+		key, prefix, hash, err := GenerateKey()
+		assert.NoError(t, err)
+		assert.True(t, IsAPIKey(key))
+		assert.True(t, strings.HasPrefix(key, prefix))
+		assert.Equal(t, HashKey(key), hash)
+		assert.NotContains(t, hash, key)
+	})
+
+	t.Run("Unique Keys", func(t *testing.T) {
+		first, _, _, err := GenerateKey()
+		assert.NoError(t, err)
+		second, _, _, err := GenerateKey()
+		assert.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	scoped := &Principal{
+		Kind:        KindAPIKey,
+		Collections: []string{"lessons"},
+		Operations:  []string{OpRead, OpWrite},
+	}
+	wildcard := &Principal{
+		Kind:        KindAPIKey,
+		Collections: []string{AllCollections},
+		Operations:  []string{OpRead},
+	}
+
+	assert.True(t, scoped.Allows("lessons", OpRead))
+	assert.True(t, scoped.Allows("lessons", OpWrite))
+	assert.False(t, scoped.Allows("lessons", OpDelete))
+	assert.False(t, scoped.Allows("quizzes", OpRead))
+	assert.True(t, wildcard.Allows("quizzes", OpRead))
+	assert.False(t, wildcard.Allows("quizzes", OpWrite))
+	assert.True(t, Anonymous.Allows("quizzes", OpDelete))
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type KeyStore interface {
+	GetKeyByHash(hash string) (*models.APIKey, error)
+	TouchKey(id string, at time.Time) error
+}
+
+// Authenticate resolves the caller from the Authorization header. Requests
+// without one, or carrying a bearer token that is neither the admin token nor
+// an API key, are left to user auth and continue as anonymous.
+func Authenticate(keys KeyStore, adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := Resolve(keys, adminToken, r.Header.Get("Authorization"))
+			if err != nil {
+				slog.Error("API key authentication failed", "error", err)
+				utils.Error(w, r, err, http.StatusUnauthorized)
+				return
+			}
+			if principal != Anonymous {
+				r = r.WithContext(WithPrincipal(r.Context(), principal))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Resolve returns the caller identified by an Authorization header value, or
+// Anonymous if it names neither the admin token nor an API key.
+func Resolve(keys KeyStore, adminToken string, header string) (*Principal, error) {
+	token := bearerToken(header)
+
+	switch {
+	case token == "":
+		return Anonymous, nil
+	case adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1:
+		slog.Debug("Admin token accepted")
+		return &Principal{Kind: KindAdmin}, nil
+	case IsAPIKey(token):
+		return authenticateKey(keys, token)
+	default:
+		return Anonymous, nil
+	}
+}
+
+func authenticateKey(keys KeyStore, token string) (*Principal, error) {
+	if keys == nil {
+		return nil, errors.New("invalid api key")
+	}
+
+	key, err := keys.GetKeyByHash(HashKey(token))
+	if err != nil {
+		return nil, errors.New("invalid api key")
+	}
+
+	now := time.Now().UTC()
+	if key.RevokedAt != nil {
+		return nil, errors.New("api key has been revoked")
+	}
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, errors.New("api key has expired")
+	}
+
+	if err := keys.TouchKey(key.Id, now); err != nil {
+		slog.Error("Failed to record api key usage", "id", key.Id, "error", err)
+	}
+
+	slog.Debug("API key accepted", "id", key.Id, "name", key.Name)
+	return &Principal{
+		Kind:        KindAPIKey,
+		Id:          key.Id,
+		Name:        key.Name,
+		Collections: key.Collections,
+		Operations:  key.Operations,
+	}, nil
+}
+
+func bearerToken(header string) string {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found {
+		return ""
+	}
+	if !strings.EqualFold(scheme, "Bearer") && !strings.EqualFold(scheme, "ApiKey") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func Operation(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return OpRead
+	case http.MethodDelete:
+		return OpDelete
+	default:
+		return OpWrite
+	}
+}
+
+// Authorize checks the caller's scopes against the {collection} route
+// parameter, so it has to run inside the routed handler chain.
+func Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coll := chi.URLParam(r, "collection")
+		op := Operation(r.Method)
+		principal := FromContext(r.Context())
+
+		if !principal.Allows(coll, op) {
+			err := fmt.Errorf("api key is not permitted to %s collection %s", op, coll)
+			slog.Error("Authorization failed", "principal", principal.String(), "error", err)
+			utils.Error(w, r, err, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := FromContext(r.Context())
+		if principal.Kind == KindAnonymous {
+			err := errors.New("authentication required")
+			slog.Error("Admin authorization failed", "error", err)
+			utils.Error(w, r, err, http.StatusUnauthorized)
+			return
+		}
+		if principal.Kind != KindAdmin {
+			err := errors.New("admin access required")
+			slog.Error("Admin authorization failed", "principal", principal.String(), "error", err)
+			utils.Error(w, r, err, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeKeyStore struct {
+	keys    map[string]*models.APIKey
+	touched map[string]time.Time
+}
+
+func (f *fakeKeyStore) GetKeyByHash(hash string) (*models.APIKey, error) {
+	if key, ok := f.keys[hash]; ok {
+		return key, nil
+	}
+	return nil, errors.New("api key not found")
+}
+
+func (f *fakeKeyStore) TouchKey(id string, at time.Time) error {
+	f.touched[id] = at
+	return nil
+}
+
+func newTestRouter(store KeyStore) http.Handler {
+	router := chi.NewRouter()
+	router.Use(Authenticate(store, "admin-secret"))
+	router.Group(func(router chi.Router) {
+		router.Use(Authorize)
+		router.Get("/contents/{collection}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(FromContext(r.Context()).Kind))
+		})
+		router.Delete("/contents/{collection}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(FromContext(r.Context()).Kind))
+		})
+	})
+	router.With(RequireAdmin).Get("/admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin"))
+	})
+	return router
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, prefix, hash, _ := GenerateKey()
+	expiredKey, _, expiredHash, _ := GenerateKey()
+	revokedKey, _, revokedHash, _ := GenerateKey()
+
+	past := time.Now().Add(-time.Hour)
+	store := &fakeKeyStore{
+		keys: map[string]*models.APIKey{
+			hash:        {Id: "valid", Prefix: prefix, Collections: []string{"lessons"}, Operations: []string{OpRead}},
+			expiredHash: {Id: "expired", Collections: []string{"*"}, Operations: Operations, ExpiresAt: &past},
+			revokedHash: {Id: "revoked", Collections: []string{"*"}, Operations: Operations, RevokedAt: &past},
+		},
+		touched: map[string]time.Time{},
+	}
+	router := newTestRouter(store)
+
+	tests := []struct {
+		Case           string
+		Method         string
+		Path           string
+		Authorization  string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{"Anonymous Read", "GET", "/contents/lessons", "", http.StatusOK, KindAnonymous},
+		{"User Bearer Token Passes Through", "GET", "/contents/lessons", "Bearer some.jwt.token", http.StatusOK, KindAnonymous},
+		{"Valid Key In Scope", "GET", "/contents/lessons", "Bearer " + key, http.StatusOK, KindAPIKey},
+		{"ApiKey Scheme", "GET", "/contents/lessons", "ApiKey " + key, http.StatusOK, KindAPIKey},
+		{"Valid Key Wrong Collection", "GET", "/contents/quizzes", "Bearer " + key, http.StatusForbidden, ""},
+		{"Valid Key Wrong Operation", "DELETE", "/contents/lessons", "Bearer " + key, http.StatusForbidden, ""},
+		{"Unknown Key", "GET", "/contents/lessons", "Bearer yan_unknown", http.StatusUnauthorized, ""},
+		{"Expired Key", "GET", "/contents/lessons", "Bearer " + expiredKey, http.StatusUnauthorized, ""},
+		{"Revoked Key", "GET", "/contents/lessons", "Bearer " + revokedKey, http.StatusUnauthorized, ""},
+		{"Admin Token", "GET", "/admin", "Bearer admin-secret", http.StatusOK, "admin"},
+		{"Admin Route Anonymous", "GET", "/admin", "", http.StatusUnauthorized, ""},
+		{"Admin Route With Key", "GET", "/admin", "Bearer " + key, http.StatusForbidden, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Case, func(t *testing.T) {
+			req := httptest.NewRequest(tc.Method, tc.Path, nil)
+			if tc.Authorization != "" {
+				req.Header.Set("Authorization", tc.Authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedStatus, rr.Code, "HTTP status code mismatch")
+			if tc.ExpectedBody != "" {
+				assert.Equal(t, tc.ExpectedBody, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Records Last Used", func(t *testing.T) {
+		assert.Contains(t, store.touched, "valid")
+		assert.NotContains(t, store.touched, "expired")
+		assert.NotContains(t, store.touched, "revoked")
+	})
+}
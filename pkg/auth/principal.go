@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+const (
+	OpRead   = "read"
+	OpWrite  = "write"
+	OpDelete = "delete"
+)
+
+const (
+	KindAnonymous = "anonymous"
+	KindAPIKey    = "api_key"
+	KindAdmin     = "admin"
+)
+
+const AllCollections = "*"
+
+var Operations = []string{OpRead, OpWrite, OpDelete}
+
+type Principal struct {
+	Kind        string
+	Id          string
+	Name        string
+	Collections []string
+	Operations  []string
+}
+
+var Anonymous = &Principal{Kind: KindAnonymous}
+
+func (p *Principal) String() string {
+	if p.Id == "" {
+		return p.Kind
+	}
+	return p.Kind + ":" + p.Id
+}
+
+func (p *Principal) Allows(coll string, op string) bool {
+	if p.Kind != KindAPIKey {
+		return true
+	}
+	if !slices.Contains(p.Operations, op) {
+		return false
+	}
+	return slices.Contains(p.Collections, AllCollections) || slices.Contains(p.Collections, coll)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return p
+	}
+	return Anonymous
+}
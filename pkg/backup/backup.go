@@ -0,0 +1,304 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Backup writes a snapshot of every collection in db to the store, as a tar
+// archive when archive is true. Collections are read at a single point in
+// time when the server supports snapshot reads. The snapshot only appears in
+// the store once it is complete.
+func (s *Store) Backup(ctx context.Context, db *mongo.Database, archive bool) (*Manifest, error) {
+	slog.Debug("Backup called", "dir", s.Dir, "database", db.Name(), "archive", archive)
+	now := time.Now().UTC()
+	manifest := Manifest{
+		Id:         now.Format(idLayout),
+		Database:   db.Name(),
+		CreatedAt:  now,
+		Consistent: true,
+		Archive:    archive,
+	}
+
+	if _, err := s.Open(manifest.Id); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", manifest.Id)
+	}
+
+	staging := filepath.Join(s.Dir, "."+manifest.Id+".tmp")
+	if err := os.MkdirAll(staging, 0o700); err != nil {
+		slog.Error("Failed to create snapshot directory", "error", err)
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	names, err := collectionNames(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Collections, err = dump(ctx, db, names, staging, true)
+	if err != nil && snapshotsUnsupported(err) {
+		slog.Warn("Snapshot reads are not supported, backing up collections one at a time", "error", err)
+		manifest.Consistent = false
+		if err = os.RemoveAll(staging); err == nil {
+			err = os.MkdirAll(staging, 0o700)
+		}
+		if err == nil {
+			manifest.Collections, err = dump(ctx, db, names, staging, false)
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to back up collections", "error", err)
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(staging, manifestFile), data, 0o600); err != nil {
+		slog.Error("Failed to write manifest", "error", err)
+		return nil, err
+	}
+
+	final := filepath.Join(s.Dir, manifest.Id)
+	if archive {
+		final += archiveExt
+		if err := pack(staging, final); err != nil {
+			slog.Error("Failed to write snapshot archive", "error", err)
+			return nil, err
+		}
+	} else if err := os.Rename(staging, final); err != nil {
+		slog.Error("Failed to move snapshot into place", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Snapshot written successfully", "id", manifest.Id, "collections", len(manifest.Collections), "consistent", manifest.Consistent)
+	return &manifest, nil
+}
+
+func collectionNames(ctx context.Context, db *mongo.Database) ([]string, error) {
+	names, err := db.ListCollectionNames(ctx, bson.D{{Key: "type", Value: "collection"}})
+	if err != nil {
+		slog.Error("Failed to list collections", "error", err)
+		return nil, err
+	}
+
+	collections := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, "system.") || strings.HasPrefix(name, restorePrefix) {
+			continue
+		}
+		collections = append(collections, name)
+	}
+	sort.Strings(collections)
+	return collections, nil
+}
+
+// dump writes each collection to dir. With consistent set, every read goes
+// through one snapshot session so they all see the same point in time.
+func dump(ctx context.Context, db *mongo.Database, names []string, dir string, consistent bool) ([]CollectionManifest, error) {
+	readCtx := ctx
+	if consistent {
+		session, err := db.Client().StartSession(options.Session().SetSnapshot(true))
+		if err != nil {
+			return nil, err
+		}
+		defer session.EndSession(ctx)
+		readCtx = mongo.NewSessionContext(ctx, session)
+	}
+
+	collections := make([]CollectionManifest, 0, len(names))
+	for _, name := range names {
+		cm, err := dumpCollection(ctx, readCtx, db.Collection(name), dir)
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %w", name, err)
+		}
+		collections = append(collections, *cm)
+		slog.Debug("Collection backed up", "collection", name, "documents", cm.Documents)
+	}
+	return collections, nil
+}
+
+func dumpCollection(ctx context.Context, readCtx context.Context, coll *mongo.Collection, dir string) (*CollectionManifest, error) {
+	cm := &CollectionManifest{Name: coll.Name(), File: url.PathEscape(coll.Name()) + ".ndjson.gz"}
+
+	indexes, err := indexSpecs(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+	cm.Indexes = indexes
+
+	f, err := os.OpenFile(filepath.Join(dir, cm.File), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	zw := gzip.NewWriter(io.MultiWriter(f, hash, counter))
+
+	cursor, err := coll.Find(readCtx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(readCtx)
+
+	for cursor.Next(readCtx) {
+		line, err := bson.MarshalExtJSON(cursor.Current, true, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := zw.Write(append(line, '\n')); err != nil {
+			return nil, err
+		}
+		cm.Documents++
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+
+	cm.Bytes = counter.n
+	cm.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return cm, nil
+}
+
+// indexSpecs returns the specifications of every index but _id, without the
+// fields that createIndexes does not accept back.
+func indexSpecs(ctx context.Context, coll *mongo.Collection) ([]json.RawMessage, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []bson.D
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+
+	specs := []json.RawMessage{}
+	for _, index := range indexes {
+		spec := bson.D{}
+		isId := false
+		for _, e := range index {
+			switch e.Key {
+			case "v", "ns":
+				continue
+			case "name":
+				isId = e.Value == "_id_"
+			}
+			spec = append(spec, e)
+		}
+		if isId {
+			continue
+		}
+		data, err := bson.MarshalExtJSON(spec, true, false)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, data)
+	}
+	return specs, nil
+}
+
+func snapshotsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	// IllegalOperation and InvalidOptions are returned by standalone servers
+	// and by versions before 5.0.
+	return serverErr.HasErrorCode(20) || serverErr.HasErrorCode(72) || serverErr.HasErrorMessage("snapshot")
+}
+
+// pack writes the files of dir to a tar archive at path, by way of a
+// temporary file so that a partial archive is never left under the final
+// name.
+func pack(dir string, path string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for _, entry := range entries {
+		if err := addFile(tw, filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func addFile(tw *tar.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
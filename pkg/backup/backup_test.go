@@ -0,0 +1,178 @@
+package backup
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// writeSnapshot writes a snapshot directory the way Backup does, from
+// documents rather than a database.
+func writeSnapshot(t *testing.T, dir string, created time.Time, docs map[string][]bson.D) *Manifest {
+	t.Helper()
+	manifest := Manifest{Id: created.Format(idLayout), Database: "content", CreatedAt: created, Consistent: true}
+	path := filepath.Join(dir, manifest.Id)
+	assert.NoError(t, os.MkdirAll(path, 0o700))
+
+	for name, collDocs := range docs {
+		cm := CollectionManifest{Name: name, File: name + ".ndjson.gz"}
+		f, err := os.Create(filepath.Join(path, cm.File))
+		assert.NoError(t, err)
+		zw := gzip.NewWriter(f)
+		for _, doc := range collDocs {
+			line, err := bson.MarshalExtJSON(doc, true, false)
+			assert.NoError(t, err)
+			zw.Write(append(line, '\n'))
+			cm.Documents++
+		}
+		zw.Close()
+		f.Close()
+
+		data, _ := os.ReadFile(filepath.Join(path, cm.File))
+		sum := sha256.Sum256(data)
+		cm.SHA256 = hex.EncodeToString(sum[:])
+		cm.Bytes = int64(len(data))
+		manifest.Collections = append(manifest.Collections, cm)
+	}
+
+	data, _ := json.Marshal(manifest)
+	assert.NoError(t, os.WriteFile(filepath.Join(path, manifestFile), data, 0o600))
+	return &manifest
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := Store{Dir: dir}
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	for days := 5; days >= 0; days-- {
+		writeSnapshot(t, dir, now.AddDate(0, 0, -days), map[string][]bson.D{"courses": {{{Key: "id", Value: "a"}}}})
+	}
+	os.MkdirAll(filepath.Join(dir, ".20240611T000000Z.tmp"), 0o700)
+
+	t.Run("List", func(t *testing.T) {
+		manifests, err := store.List()
+		assert.NoError(t, err)
+		assert.Len(t, manifests, 6)
+		assert.Equal(t, "20240610T120000Z", manifests[0].Id)
+	})
+
+	t.Run("Open Missing Snapshot", func(t *testing.T) {
+		_, err := store.Open("20200101T000000Z")
+		assert.ErrorIs(t, err, ErrSnapshotNotFound)
+		_, err = store.Open("../etc")
+		assert.Error(t, err)
+	})
+
+	t.Run("Prune By Count", func(t *testing.T) {
+		deleted, err := store.Prune(4, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"20240606T120000Z", "20240605T120000Z"}, deleted)
+	})
+
+	t.Run("Prune By Age", func(t *testing.T) {
+		deleted, err := store.Prune(0, 36*time.Hour, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"20240608T120000Z", "20240607T120000Z"}, deleted)
+	})
+
+	t.Run("Prune Keeps The Newest", func(t *testing.T) {
+		_, err := store.Prune(0, time.Nanosecond, now.AddDate(1, 0, 0))
+		assert.NoError(t, err)
+		manifests, _ := store.List()
+		assert.Len(t, manifests, 1)
+	})
+}
+
+func TestReadDocuments(t *testing.T) {
+	dir := t.TempDir()
+	docs := []bson.D{
+		{{Key: "id", Value: "a"}, {Key: "views", Value: int32(3)}, {Key: "created_at", Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{{Key: "id", Value: "b"}},
+	}
+	manifest := writeSnapshot(t, dir, time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC), map[string][]bson.D{"courses": docs})
+	store := Store{Dir: dir}
+
+	read := func(snapshot *Snapshot) ([]bson.D, error) {
+		cm, _ := snapshot.Collection("courses")
+		var got []bson.D
+		err := readDocuments(snapshot, cm, func(doc bson.D) error {
+			got = append(got, doc)
+			return nil
+		})
+		return got, err
+	}
+
+	t.Run("Directory", func(t *testing.T) {
+		snapshot, err := store.Open(manifest.Id)
+		assert.NoError(t, err)
+		got, err := read(snapshot)
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.Equal(t, int32(3), got[0][1].Value)
+		id, ok := contentId(got[1])
+		assert.True(t, ok)
+		assert.Equal(t, "b", id)
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		path := filepath.Join(dir, manifest.Id)
+		assert.NoError(t, pack(path, path+archiveExt))
+		assert.NoError(t, os.RemoveAll(path))
+
+		snapshot, err := store.Open(manifest.Id)
+		assert.NoError(t, err)
+		got, err := read(snapshot)
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+	})
+
+	t.Run("Checksum Mismatch", func(t *testing.T) {
+		snapshot, err := store.Open(manifest.Id)
+		assert.NoError(t, err)
+		snapshot.Collections[0].SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+		_, err = read(snapshot)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultConfig, cfg)
+	})
+
+	t.Run("All Settings", func(t *testing.T) {
+		t.Setenv("YAN_CMS_BACKUP_DIR", "/var/backups/cms")
+		t.Setenv("YAN_CMS_BACKUP_INTERVAL", "6h")
+		t.Setenv("YAN_CMS_BACKUP_KEEP", "0")
+		t.Setenv("YAN_CMS_BACKUP_MAX_AGE", "720h")
+		t.Setenv("YAN_CMS_BACKUP_ARCHIVE", "true")
+
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, Config{Dir: "/var/backups/cms", Interval: 6 * time.Hour, MaxAge: 720 * time.Hour, Archive: true}, cfg)
+	})
+
+	t.Run("Invalid Settings", func(t *testing.T) {
+		for env, value := range map[string]string{
+			"YAN_CMS_BACKUP_INTERVAL": "0s",
+			"YAN_CMS_BACKUP_MAX_AGE":  "a month",
+			"YAN_CMS_BACKUP_KEEP":     "-1",
+		} {
+			t.Setenv(env, value)
+			_, err := ConfigFromEnv()
+			assert.Error(t, err, env)
+			t.Setenv(env, "")
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// restorePrefix names the collection a restore is written to before it
+	// replaces the live one.
+	restorePrefix = "_restore."
+
+	restoreBatchSize = 1000
+	// Documents are at most 16 MB, and extended JSON adds some overhead.
+	maxLineBytes = 32 << 20
+)
+
+var ErrChecksumMismatch = errors.New("snapshot file does not match its checksum")
+
+// Restorer writes snapshots back to the content database and records the
+// restored items as changed, so that sync clients pick them up.
+type Restorer struct {
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+}
+
+// RestoreDatabase restores every collection in the snapshot. Collections
+// created since the snapshot was taken are left as they are.
+func (r *Restorer) RestoreDatabase(ctx context.Context, snapshot *Snapshot) error {
+	slog.Debug("RestoreDatabase called", "snapshot", snapshot.Id)
+	for _, cm := range snapshot.Collections {
+		if _, err := r.RestoreCollection(ctx, snapshot, cm.Name); err != nil {
+			return err
+		}
+	}
+	slog.Info("Database restored successfully", "snapshot", snapshot.Id, "collections", len(snapshot.Collections))
+	return nil
+}
+
+// RestoreCollection replaces a collection with its contents in the snapshot
+// and returns the number of documents restored. The snapshot is loaded into a
+// separate collection and checked against its checksum first, so the live
+// collection is only replaced once the whole snapshot has been read.
+func (r *Restorer) RestoreCollection(ctx context.Context, snapshot *Snapshot, name string) (int64, error) {
+	slog.Debug("RestoreCollection called", "snapshot", snapshot.Id, "collection", name)
+	cm, ok := snapshot.Collection(name)
+	if !ok {
+		return 0, fmt.Errorf("collection %s is not in snapshot %s", name, snapshot.Id)
+	}
+
+	staging := r.DB.Collection(restorePrefix + name)
+	if err := staging.Drop(ctx); err != nil {
+		slog.Error("Failed to drop staging collection", "collection", staging.Name(), "error", err)
+		return 0, err
+	}
+	if err := r.DB.CreateCollection(ctx, staging.Name()); err != nil {
+		slog.Error("Failed to create staging collection", "collection", staging.Name(), "error", err)
+		return 0, err
+	}
+
+	if len(cm.Indexes) > 0 {
+		indexes := bson.A{}
+		for _, spec := range cm.Indexes {
+			var index bson.D
+			if err := bson.UnmarshalExtJSON(spec, true, &index); err != nil {
+				return 0, fmt.Errorf("invalid index in manifest: %s", err.Error())
+			}
+			indexes = append(indexes, index)
+		}
+		err := r.DB.RunCommand(ctx, bson.D{{Key: "createIndexes", Value: staging.Name()}, {Key: "indexes", Value: indexes}}).Err()
+		if err != nil {
+			slog.Error("Failed to create indexes", "collection", staging.Name(), "error", err)
+			return 0, err
+		}
+	}
+
+	restored := []string{}
+	batch := []any{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := staging.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		batch = batch[:0]
+		return err
+	}
+	err := readDocuments(snapshot, cm, func(doc bson.D) error {
+		if id, ok := contentId(doc); ok {
+			restored = append(restored, id)
+		}
+		batch = append(batch, doc)
+		if len(batch) < restoreBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		slog.Error("Failed to load snapshot", "collection", name, "error", err)
+		staging.Drop(ctx)
+		return 0, err
+	}
+
+	current, err := r.DB.Collection(name).Distinct(ctx, "id", bson.D{})
+	if err != nil {
+		slog.Error("Failed to read current ids", "collection", name, "error", err)
+		staging.Drop(ctx)
+		return 0, err
+	}
+
+	err = r.DB.Client().Database("admin").RunCommand(ctx, bson.D{
+		{Key: "renameCollection", Value: r.DB.Name() + "." + staging.Name()},
+		{Key: "to", Value: r.DB.Name() + "." + name},
+		{Key: "dropTarget", Value: true},
+	}).Err()
+	if err != nil {
+		slog.Error("Failed to replace collection", "collection", name, "error", err)
+		staging.Drop(ctx)
+		return 0, err
+	}
+
+	keep := make(map[string]bool, len(restored))
+	for _, id := range restored {
+		keep[id] = true
+	}
+	removed := []string{}
+	for _, value := range current {
+		if id, ok := value.(string); ok && !keep[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	repo := repositories.ContentRepository{DB: r.DB, SystemDB: r.SystemDB}
+	if err := repo.RecordChanges(name, restored, false); err != nil {
+		return 0, err
+	}
+	if err := repo.RecordChanges(name, removed, true); err != nil {
+		return 0, err
+	}
+
+	slog.Info("Collection restored successfully", "snapshot", snapshot.Id, "collection", name, "documents", cm.Documents, "removed", len(removed))
+	return cm.Documents, nil
+}
+
+// RestoreItem puts one item back as it was in the snapshot, whether it has
+// since been changed or deleted.
+func (r *Restorer) RestoreItem(ctx context.Context, snapshot *Snapshot, coll string, id string) error {
+	slog.Debug("RestoreItem called", "snapshot", snapshot.Id, "collection", coll, "id", id)
+	cm, ok := snapshot.Collection(coll)
+	if !ok {
+		return fmt.Errorf("collection %s is not in snapshot %s", coll, snapshot.Id)
+	}
+
+	// The whole file is read even once the item is found, to check it
+	// against the checksum before anything is written.
+	var found bson.D
+	err := readDocuments(snapshot, cm, func(doc bson.D) error {
+		if docId, ok := contentId(doc); ok && docId == id {
+			found = doc
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to read snapshot", "collection", coll, "error", err)
+		return err
+	}
+	if found == nil {
+		return fmt.Errorf("item %s is not in collection %s of snapshot %s", id, coll, snapshot.Id)
+	}
+
+	// The item may have been deleted and created again since, with another
+	// _id, which cannot be changed by a replace.
+	replacement := bson.D{}
+	for _, e := range found {
+		if e.Key != "_id" {
+			replacement = append(replacement, e)
+		}
+	}
+
+	_, err = r.DB.Collection(coll).ReplaceOne(ctx, bson.D{{Key: "id", Value: id}}, replacement, options.Replace().SetUpsert(true))
+	if err != nil {
+		slog.Error("Failed to restore item", "collection", coll, "id", id, "error", err)
+		return err
+	}
+
+	repo := repositories.ContentRepository{DB: r.DB, SystemDB: r.SystemDB}
+	if err := repo.RecordChanges(coll, []string{id}, false); err != nil {
+		return err
+	}
+
+	slog.Info("Item restored successfully", "snapshot", snapshot.Id, "collection", coll, "id", id)
+	return nil
+}
+
+// readDocuments calls fn for each document of a collection file, and then
+// checks the file against its checksum. Callers must not act on what they
+// were given until it returns nil.
+func readDocuments(snapshot *Snapshot, cm *CollectionManifest, fn func(bson.D) error) error {
+	f, err := snapshot.open(cm.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	tee := io.TeeReader(f, hash)
+	zr, err := gzip.NewReader(tee)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON(scanner.Bytes(), true, &doc); err != nil {
+			return fmt.Errorf("invalid document in %s: %s", cm.File, err.Error())
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Read whatever follows the gzip stream so the checksum covers the
+	// whole file.
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != cm.SHA256 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, cm.File)
+	}
+	return nil
+}
+
+func contentId(doc bson.D) (string, bool) {
+	for _, e := range doc {
+		if e.Key == "id" {
+			id, ok := e.Value.(string)
+			return id, ok
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,126 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Config struct {
+	// Dir is where snapshots are kept. Scheduled backups are off when it is
+	// empty.
+	Dir      string
+	Interval time.Duration
+	// Keep is the number of snapshots to keep, and MaxAge the age after
+	// which they are deleted. Zero disables either rule.
+	Keep    int
+	MaxAge  time.Duration
+	Archive bool
+}
+
+var DefaultConfig = Config{
+	Interval: 24 * time.Hour,
+	Keep:     7,
+}
+
+func ConfigFromEnv() (Config, error) {
+	slog.Debug("Loading backup environment variables...")
+	cfg := DefaultConfig
+	cfg.Dir = os.Getenv("YAN_CMS_BACKUP_DIR")
+
+	durations := map[string]*time.Duration{
+		"YAN_CMS_BACKUP_INTERVAL": &cfg.Interval,
+		"YAN_CMS_BACKUP_MAX_AGE":  &cfg.MaxAge,
+	}
+	for env, target := range durations {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			err := fmt.Errorf("invalid duration %q in %s", value, env)
+			slog.Error("Invalid backup configuration", "env", env, "error", err)
+			return Config{}, err
+		}
+		*target = d
+	}
+	if cfg.Interval <= 0 {
+		err := fmt.Errorf("YAN_CMS_BACKUP_INTERVAL must be positive")
+		slog.Error("Invalid backup configuration", "error", err)
+		return Config{}, err
+	}
+
+	if value := os.Getenv("YAN_CMS_BACKUP_KEEP"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			err := fmt.Errorf("invalid YAN_CMS_BACKUP_KEEP %q, expected a non-negative integer", value)
+			slog.Error("Invalid backup configuration", "error", err)
+			return Config{}, err
+		}
+		cfg.Keep = n
+	}
+
+	cfg.Archive = os.Getenv("YAN_CMS_BACKUP_ARCHIVE") == "true"
+
+	slog.Info("Backups configured", "dir", cfg.Dir, "interval", cfg.Interval, "keep", cfg.Keep, "max_age", cfg.MaxAge, "archive", cfg.Archive)
+	return cfg, nil
+}
+
+// Scheduler takes a snapshot every Interval and applies the retention rules
+// after each one.
+type Scheduler struct {
+	Config Config
+	Store  *Store
+	DB     *mongo.Database
+}
+
+func NewScheduler(cfg Config, db *mongo.Database) *Scheduler {
+	return &Scheduler{Config: cfg, Store: &Store{Dir: cfg.Dir}, DB: db}
+}
+
+// RunOnce takes a snapshot and prunes old ones.
+func (s *Scheduler) RunOnce(ctx context.Context) (*Manifest, error) {
+	manifest, err := s.Store.Backup(ctx, s.DB, s.Config.Archive)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Store.Prune(s.Config.Keep, s.Config.MaxAge, time.Now().UTC()); err != nil {
+		slog.Error("Failed to prune snapshots", "error", err)
+	}
+	return manifest, nil
+}
+
+// Run takes snapshots until ctx is done. The first one is taken once an
+// interval has passed since the latest snapshot in the store, so restarts do
+// not cause extra backups.
+func (s *Scheduler) Run(ctx context.Context) {
+	slog.Info("Backup scheduler started", "dir", s.Config.Dir, "interval", s.Config.Interval)
+
+	wait := time.Duration(0)
+	if latest, err := s.Store.Latest(); err != nil {
+		slog.Error("Failed to find the latest snapshot", "error", err)
+	} else if latest != nil {
+		wait = max(0, time.Until(latest.CreatedAt.Add(s.Config.Interval)))
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Backup scheduler stopped")
+			return
+		case <-timer.C:
+			if _, err := s.RunOnce(ctx); err != nil {
+				slog.Error("Scheduled backup failed", "error", err)
+			}
+			timer.Reset(s.Config.Interval)
+		}
+	}
+}
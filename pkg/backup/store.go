@@ -0,0 +1,205 @@
+// Package backup writes snapshots of the content database to local disk and
+// restores a database, a collection or a single item from them.
+//
+// Each snapshot is a directory, or a tar archive of the same files, named
+// after the time it was taken. It holds one gzipped file per collection, with
+// one document per line in canonical extended JSON, and a manifest.json that
+// lists the files with their SHA-256 checksums.
+package backup
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	manifestFile = "manifest.json"
+	archiveExt   = ".tar"
+	idLayout     = "20060102T150405Z"
+)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+type Manifest struct {
+	Id        string    `json:"id"`
+	Database  string    `json:"database"`
+	CreatedAt time.Time `json:"created_at"`
+	// Consistent is false when the server does not support snapshot reads,
+	// such as a standalone server, and the collections were read one after
+	// the other while writes could still happen.
+	Consistent  bool                 `json:"consistent"`
+	Archive     bool                 `json:"archive"`
+	Collections []CollectionManifest `json:"collections"`
+}
+
+type CollectionManifest struct {
+	Name      string `json:"name"`
+	File      string `json:"file"`
+	Documents int64  `json:"documents"`
+	Bytes     int64  `json:"bytes"`
+	SHA256    string `json:"sha256"`
+	// Indexes are the index specifications other than the default _id
+	// index, in extended JSON.
+	Indexes []json.RawMessage `json:"indexes,omitempty"`
+}
+
+// Collection returns the manifest entry of a collection.
+func (m *Manifest) Collection(name string) (*CollectionManifest, bool) {
+	for i := range m.Collections {
+		if m.Collections[i].Name == name {
+			return &m.Collections[i], true
+		}
+	}
+	return nil, false
+}
+
+// Store is a directory of snapshots.
+type Store struct {
+	Dir string
+}
+
+// List returns the manifests of every complete snapshot, newest first.
+func (s *Store) List() ([]Manifest, error) {
+	slog.Debug("List called", "dir", s.Dir)
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Manifest{}, nil
+		}
+		slog.Error("Failed to read backup directory", "dir", s.Dir, "error", err)
+		return nil, err
+	}
+
+	manifests := []Manifest{}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), archiveExt)
+		if _, err := time.Parse(idLayout, id); err != nil {
+			// Snapshots in progress are written under a temporary name.
+			continue
+		}
+		snapshot, err := s.Open(id)
+		if err != nil {
+			slog.Warn("Skipping unreadable snapshot", "id", id, "error", err)
+			continue
+		}
+		manifests = append(manifests, snapshot.Manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Id > manifests[j].Id })
+	return manifests, nil
+}
+
+// Open reads the manifest of a snapshot, whichever form it was written in.
+func (s *Store) Open(id string) (*Snapshot, error) {
+	if _, err := time.Parse(idLayout, id); err != nil {
+		return nil, fmt.Errorf("invalid snapshot id %q", id)
+	}
+
+	snapshot := &Snapshot{path: filepath.Join(s.Dir, id)}
+	if _, err := os.Stat(snapshot.path); errors.Is(err, os.ErrNotExist) {
+		snapshot.path += archiveExt
+		snapshot.archive = true
+		if _, err := os.Stat(snapshot.path); errors.Is(err, os.ErrNotExist) {
+			return nil, ErrSnapshotNotFound
+		}
+	}
+
+	f, err := snapshot.open(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&snapshot.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %s", err.Error())
+	}
+	return snapshot, nil
+}
+
+// Latest returns the newest snapshot, or nil when there is none.
+func (s *Store) Latest() (*Manifest, error) {
+	manifests, err := s.List()
+	if err != nil || len(manifests) == 0 {
+		return nil, err
+	}
+	return &manifests[0], nil
+}
+
+// Prune deletes the snapshots beyond the newest keep, and those older than
+// maxAge. A zero keep or maxAge disables that rule. The newest snapshot is
+// never deleted. It returns the ids it deleted.
+func (s *Store) Prune(keep int, maxAge time.Duration, now time.Time) ([]string, error) {
+	slog.Debug("Prune called", "dir", s.Dir, "keep", keep, "max_age", maxAge)
+	manifests, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for i, m := range manifests {
+		if i == 0 {
+			continue
+		}
+		expired := maxAge > 0 && now.Sub(m.CreatedAt) > maxAge
+		if !expired && (keep <= 0 || i < keep) {
+			continue
+		}
+
+		path := filepath.Join(s.Dir, m.Id)
+		if m.Archive {
+			path += archiveExt
+		}
+		if err := os.RemoveAll(path); err != nil {
+			slog.Error("Failed to delete snapshot", "id", m.Id, "error", err)
+			return deleted, err
+		}
+		slog.Info("Snapshot deleted", "id", m.Id)
+		deleted = append(deleted, m.Id)
+	}
+	return deleted, nil
+}
+
+type Snapshot struct {
+	Manifest
+
+	path    string
+	archive bool
+}
+
+// open returns a reader for a file of the snapshot.
+func (s *Snapshot) open(name string) (io.ReadCloser, error) {
+	if !s.archive {
+		return os.Open(filepath.Join(s.path, name))
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			f.Close()
+			return nil, fmt.Errorf("%s is missing from the archive", name)
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if header.Name == name {
+			return struct {
+				io.Reader
+				io.Closer
+			}{tr, f}, nil
+		}
+	}
+}
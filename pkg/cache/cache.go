@@ -0,0 +1,450 @@
+// Package cache keeps recently read content in memory, so that repeated reads
+// of the same items and listings do not each go to MongoDB.
+//
+// Entries expire after a TTL and the least recently used ones are evicted
+// once the cache is full. Writes through the content repository invalidate
+// exactly the entries they affect, and change stream events do the same for
+// writes made through other replicas.
+package cache
+
+import (
+	"container/list"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kind int
+
+const (
+	kindContent kind = iota
+	kindCollection
+	kindClass
+	kindLastChanged
+)
+
+type key struct {
+	collection string
+	kind       kind
+	name       string
+}
+
+type entry struct {
+	key     key
+	value   any
+	expires time.Time
+}
+
+// collection tracks the entries of one collection. It only exists while the
+// collection has entries, so that reads of any number of names cannot grow
+// the cache.
+type collection struct {
+	keys map[key]struct{}
+	// generation is the generation of the cache when the collection was
+	// last invalidated, so that a read which started before a write does
+	// not put what it read back afterwards.
+	generation uint64
+	hits       uint64
+	misses     uint64
+}
+
+type Stats struct {
+	Size          int                        `json:"size"`
+	Entries       int                        `json:"entries"`
+	Hits          uint64                     `json:"hits"`
+	Misses        uint64                     `json:"misses"`
+	Evictions     uint64                     `json:"evictions"`
+	Invalidations uint64                     `json:"invalidations"`
+	Collections   map[string]CollectionStats `json:"collections"`
+}
+
+type CollectionStats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	// TTL is a duration such as "30s", or "0s" when the collection is not
+	// cached.
+	TTL string `json:"ttl"`
+}
+
+// Cache is safe for concurrent use. A nil *Cache caches nothing, so callers
+// need not check whether it is configured.
+type Cache struct {
+	mu          sync.Mutex
+	config      Config
+	lru         *list.List
+	entries     map[key]*list.Element
+	collections map[string]*collection
+	// generation counts invalidations. forgotten is the latest generation
+	// at which a collection without entries may have been invalidated.
+	generation    uint64
+	forgotten     uint64
+	hits          uint64
+	misses        uint64
+	evictions     uint64
+	invalidations uint64
+	now           func() time.Time
+}
+
+// New returns a cache for cfg, or nil when cfg.Size is zero.
+func New(cfg Config) *Cache {
+	if cfg.Size <= 0 {
+		return nil
+	}
+	return &Cache{
+		config:      cfg,
+		lru:         list.New(),
+		entries:     map[key]*list.Element{},
+		collections: map[string]*collection{},
+		now:         time.Now,
+	}
+}
+
+// Generation returns a token to pass to the Put methods. Take it before
+// reading coll from the database.
+func (c *Cache) Generation(coll string) uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *Cache) Content(coll string, id string) (*models.ReadContent, bool) {
+	value, ok := c.get(key{coll, kindContent, id})
+	if !ok {
+		return nil, false
+	}
+	content := *value.(*models.ReadContent)
+	content.Fields = copyFields(content.Fields)
+	return &content, true
+}
+
+func (c *Cache) PutContent(coll string, id string, generation uint64, content *models.ReadContent) {
+	if c == nil {
+		return
+	}
+	copied := *content
+	copied.Fields = copyFields(copied.Fields)
+	c.put(key{coll, kindContent, id}, generation, &copied)
+}
+
+func (c *Cache) Collection(coll string) ([]models.Content, bool) {
+	value, ok := c.get(key{coll, kindCollection, ""})
+	if !ok {
+		return nil, false
+	}
+	return copyContents(value.([]models.Content)), true
+}
+
+func (c *Cache) PutCollection(coll string, generation uint64, contents []models.Content) {
+	if c == nil {
+		return
+	}
+	c.put(key{coll, kindCollection, ""}, generation, copyContents(contents))
+}
+
+func (c *Cache) Class(coll string, class string) ([]models.Content, bool) {
+	value, ok := c.get(key{coll, kindClass, class})
+	if !ok {
+		return nil, false
+	}
+	return copyContents(value.([]models.Content)), true
+}
+
+func (c *Cache) PutClass(coll string, class string, generation uint64, contents []models.Content) {
+	if c == nil {
+		return
+	}
+	c.put(key{coll, kindClass, class}, generation, copyContents(contents))
+}
+
+// LastChanged holds when anything in the collection last changed. It is
+// invalidated by every write to the collection.
+func (c *Cache) LastChanged(coll string) (time.Time, bool) {
+	value, ok := c.get(key{coll, kindLastChanged, ""})
+	if !ok {
+		return time.Time{}, false
+	}
+	return value.(time.Time), true
+}
+
+func (c *Cache) PutLastChanged(coll string, generation uint64, at time.Time) {
+	if c == nil {
+		return
+	}
+	c.put(key{coll, kindLastChanged, ""}, generation, at)
+}
+
+// Invalidate drops the items with the given ids, the collection listing and
+// the listings of the given classes. Pass every class the items were in
+// before the write and are in after it.
+func (c *Cache) Invalidate(coll string, ids []string, classes ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := []key{{coll, kindCollection, ""}, {coll, kindLastChanged, ""}}
+	for _, id := range ids {
+		keys = append(keys, key{coll, kindContent, id})
+	}
+	for _, class := range classes {
+		keys = append(keys, key{coll, kindClass, class})
+	}
+
+	c.advance(coll)
+	for _, k := range keys {
+		if element, ok := c.entries[k]; ok {
+			c.remove(element)
+			c.invalidations++
+		}
+	}
+}
+
+// InvalidateClasses is Invalidate for writes that do not know which classes
+// the items were in. It drops every class listing of the collection.
+func (c *Cache) InvalidateClasses(coll string, ids []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(coll, func(k key) bool {
+		return k.kind != kindContent || slices.Contains(ids, k.name)
+	})
+}
+
+// InvalidateCollection drops every entry of the collection.
+func (c *Cache) InvalidateCollection(coll string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(coll, func(key) bool { return true })
+}
+
+// Purge drops every entry. Statistics are kept.
+func (c *Cache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name := range c.collections {
+		c.invalidate(name, func(key) bool { return true })
+	}
+	slog.Info("Cache purged")
+}
+
+// HandleStreamEvent invalidates what a change stream event affects. Change
+// events only carry the document from before an update when the collection
+// records pre-images, so updates drop every class listing in case the item
+// moved between classes.
+func (c *Cache) HandleStreamEvent(se events.StreamEvent) {
+	if c == nil {
+		return
+	}
+	if se.Type == models.EventCollectionDeleted || se.ContentId == "" {
+		c.InvalidateCollection(se.Collection)
+		return
+	}
+
+	ids := []string{se.ContentId}
+	if se.Class == "" || (se.Type == models.EventContentUpdated && se.PreviousClass == "") {
+		c.InvalidateClasses(se.Collection, ids)
+		return
+	}
+	c.Invalidate(se.Collection, ids, se.Class, se.PreviousClass)
+}
+
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{Collections: map[string]CollectionStats{}}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{
+		Size:          c.config.Size,
+		Entries:       c.lru.Len(),
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+		Invalidations: c.invalidations,
+		Collections:   map[string]CollectionStats{},
+	}
+	// Collections with a TTL of their own are listed even when they have
+	// no entries, so that their settings can be checked.
+	for name := range c.config.Collections {
+		stats.Collections[name] = CollectionStats{TTL: c.config.ttl(name).String()}
+	}
+	for name, coll := range c.collections {
+		stats.Collections[name] = CollectionStats{
+			Entries: len(coll.keys),
+			Hits:    coll.hits,
+			Misses:  coll.misses,
+			TTL:     c.config.ttl(name).String(),
+		}
+	}
+	return stats
+}
+
+func (c *Cache) get(k key) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[k]
+	if ok && c.now().After(element.Value.(*entry).expires) {
+		c.remove(element)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		if coll, found := c.collections[k.collection]; found {
+			coll.misses++
+		}
+		return nil, false
+	}
+
+	c.hits++
+	c.collections[k.collection].hits++
+	c.lru.MoveToFront(element)
+	return element.Value.(*entry).value, true
+}
+
+func (c *Cache) put(k key, generation uint64, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.config.ttl(k.collection)
+	if ttl <= 0 {
+		return
+	}
+	coll, ok := c.collections[k.collection]
+	if !ok {
+		coll = &collection{keys: map[key]struct{}{}, generation: c.forgotten}
+	}
+	if generation < coll.generation {
+		return
+	}
+	c.collections[k.collection] = coll
+
+	if element, ok := c.entries[k]; ok {
+		c.remove(element)
+	}
+	c.entries[k] = c.lru.PushFront(&entry{key: k, value: value, expires: c.now().Add(ttl)})
+	coll.keys[k] = struct{}{}
+
+	for c.lru.Len() > c.config.Size {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// invalidate drops the entries of the collection that match and moves it to
+// a new generation. The caller holds the lock.
+func (c *Cache) invalidate(name string, match func(key) bool) {
+	coll := c.advance(name)
+	if coll == nil {
+		return
+	}
+	for k := range coll.keys {
+		if match(k) {
+			c.remove(c.entries[k])
+			c.invalidations++
+		}
+	}
+}
+
+// remove drops an entry, and the collection it belongs to once that has no
+// entries left. The caller holds the lock.
+func (c *Cache) remove(element *list.Element) {
+	e := element.Value.(*entry)
+	c.lru.Remove(element)
+	delete(c.entries, e.key)
+
+	coll := c.collections[e.key.collection]
+	delete(coll.keys, e.key)
+	if len(coll.keys) == 0 {
+		c.forgotten = max(c.forgotten, coll.generation)
+		delete(c.collections, e.key.collection)
+	}
+}
+
+// advance moves the collection to a new generation, and returns it if it has
+// entries. The caller holds the lock.
+func (c *Cache) advance(name string) *collection {
+	c.generation++
+	coll, ok := c.collections[name]
+	if !ok {
+		c.forgotten = c.generation
+		return nil
+	}
+	coll.generation = c.generation
+	return coll
+}
+
+// copyContents clones a listing along with the custom fields of its items,
+// so that neither the caller nor the cache sees changes made by the other.
+func copyContents(contents []models.Content) []models.Content {
+	copied := slices.Clone(contents)
+	for i := range copied {
+		copied[i].Fields = copyFields(copied[i].Fields)
+	}
+	return copied
+}
+
+func copyFields(fields map[string]any) map[string]any {
+	if fields == nil {
+		return nil
+	}
+	copied := make(map[string]any, len(fields))
+	for name, value := range fields {
+		copied[name] = copyValue(value)
+	}
+	return copied
+}
+
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return copyFields(v)
+	case primitive.M:
+		return primitive.M(copyFields(v))
+	case []any:
+		return copyValues(v)
+	case primitive.A:
+		return primitive.A(copyValues(v))
+	case primitive.D:
+		copied := make(primitive.D, len(v))
+		for i, e := range v {
+			copied[i] = primitive.E{Key: e.Key, Value: copyValue(e.Value)}
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func copyValues(values []any) []any {
+	copied := make([]any, len(values))
+	for i, value := range values {
+		copied[i] = copyValue(value)
+	}
+	return copied
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(cfg Config) (*Cache, *time.Time) {
+	c := New(cfg)
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+// fill caches an item, the collection listing and two class listings.
+func fill(c *Cache, coll string) {
+	gen := c.Generation(coll)
+	c.PutContent(coll, "a", gen, &models.ReadContent{Id: "a", Class: "lessons"})
+	c.PutContent(coll, "b", gen, &models.ReadContent{Id: "b", Class: "quizzes"})
+	c.PutCollection(coll, gen, []models.Content{{Id: "a"}, {Id: "b"}})
+	c.PutClass(coll, "lessons", gen, []models.Content{{Id: "a"}})
+	c.PutClass(coll, "quizzes", gen, []models.Content{{Id: "b"}})
+}
+
+func TestCache(t *testing.T) {
+	t.Run("Hits And Misses", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		_, ok := c.Content("courses", "a")
+		assert.False(t, ok)
+
+		fill(c, "courses")
+		content, ok := c.Content("courses", "a")
+		assert.True(t, ok)
+		assert.Equal(t, "lessons", content.Class)
+
+		content.Class = "changed"
+		content, _ = c.Content("courses", "a")
+		assert.Equal(t, "lessons", content.Class)
+		_, ok = c.Content("courses", "c")
+		assert.False(t, ok)
+
+		// The miss before the collection had entries only counts in total
+		stats := c.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(2), stats.Misses)
+		assert.Equal(t, 5, stats.Entries)
+		assert.Equal(t, CollectionStats{Entries: 5, Hits: 2, Misses: 1, TTL: "30s"}, stats.Collections["courses"])
+	})
+
+	t.Run("Reads Do Not Add Collections", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		c.Content("courses", "a")
+		c.Collection("guides")
+		assert.Empty(t, c.collections)
+
+		fill(c, "courses")
+		c.InvalidateCollection("courses")
+		assert.Empty(t, c.collections)
+	})
+
+	t.Run("Custom Fields Are Copied", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		gen := c.Generation("courses")
+		fields := map[string]any{"tags": []any{"go"}, "meta": map[string]any{"level": 1}}
+		c.PutContent("courses", "a", gen, &models.ReadContent{Id: "a", Fields: fields})
+		c.PutCollection("courses", gen, []models.Content{{Id: "a", Fields: fields}})
+		fields["meta"].(map[string]any)["level"] = 2
+
+		content, _ := c.Content("courses", "a")
+		assert.Equal(t, 1, content.Fields["meta"].(map[string]any)["level"])
+		content.Fields["tags"].([]any)[0] = "rust"
+
+		content, _ = c.Content("courses", "a")
+		assert.Equal(t, []any{"go"}, content.Fields["tags"])
+		contents, _ := c.Collection("courses")
+		assert.Equal(t, 1, contents[0].Fields["meta"].(map[string]any)["level"])
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		c, now := newTestCache(DefaultConfig)
+		fill(c, "courses")
+		*now = now.Add(31 * time.Second)
+		_, ok := c.Collection("courses")
+		assert.False(t, ok)
+		assert.Equal(t, 4, c.Stats().Entries)
+	})
+
+	t.Run("Least Recently Used Are Evicted", func(t *testing.T) {
+		c, _ := newTestCache(Config{Size: 2, TTL: time.Minute})
+		gen := c.Generation("courses")
+		c.PutContent("courses", "a", gen, &models.ReadContent{Id: "a"})
+		c.PutContent("courses", "b", gen, &models.ReadContent{Id: "b"})
+		c.Content("courses", "a")
+		c.PutContent("courses", "c", gen, &models.ReadContent{Id: "c"})
+
+		_, ok := c.Content("courses", "b")
+		assert.False(t, ok)
+		_, ok = c.Content("courses", "a")
+		assert.True(t, ok)
+		assert.Equal(t, uint64(1), c.Stats().Evictions)
+	})
+
+	t.Run("Reads From Before A Write Are Not Cached", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		gen := c.Generation("courses")
+		c.Invalidate("courses", []string{"a"}, "lessons")
+		c.PutContent("courses", "a", gen, &models.ReadContent{Id: "a"})
+		_, ok := c.Content("courses", "a")
+		assert.False(t, ok)
+
+		// Not even once the collection has had entries and lost them
+		fill(c, "courses")
+		gen = c.Generation("courses")
+		c.InvalidateCollection("courses")
+		c.PutContent("courses", "a", gen, &models.ReadContent{Id: "a"})
+		_, ok = c.Content("courses", "a")
+		assert.False(t, ok)
+
+		c.PutContent("courses", "a", c.Generation("courses"), &models.ReadContent{Id: "a"})
+		_, ok = c.Content("courses", "a")
+		assert.True(t, ok)
+	})
+
+	t.Run("Invalidate Is Precise", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		fill(c, "courses")
+		fill(c, "guides")
+		c.Invalidate("courses", []string{"a"}, "lessons")
+
+		_, ok := c.Content("courses", "a")
+		assert.False(t, ok)
+		_, ok = c.Collection("courses")
+		assert.False(t, ok)
+		_, ok = c.Class("courses", "lessons")
+		assert.False(t, ok)
+
+		_, ok = c.Content("courses", "b")
+		assert.True(t, ok)
+		_, ok = c.Class("courses", "quizzes")
+		assert.True(t, ok)
+		_, ok = c.Content("guides", "a")
+		assert.True(t, ok)
+		assert.Equal(t, uint64(3), c.Stats().Invalidations)
+	})
+
+	t.Run("Invalidate Classes", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		fill(c, "courses")
+		c.InvalidateClasses("courses", []string{"a"})
+
+		_, ok := c.Class("courses", "quizzes")
+		assert.False(t, ok)
+		_, ok = c.Content("courses", "b")
+		assert.True(t, ok)
+	})
+
+	t.Run("Collection Settings", func(t *testing.T) {
+		c, now := newTestCache(Config{Size: 100, TTL: time.Minute, Collections: map[string]time.Duration{"drafts": 0, "courses": time.Hour}})
+		fill(c, "drafts")
+		fill(c, "courses")
+		_, ok := c.Content("drafts", "a")
+		assert.False(t, ok)
+
+		*now = now.Add(30 * time.Minute)
+		_, ok = c.Content("courses", "a")
+		assert.True(t, ok)
+		assert.Equal(t, "0s", c.Stats().Collections["drafts"].TTL)
+	})
+
+	t.Run("Stream Events", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+
+		fill(c, "courses")
+		c.HandleStreamEvent(events.StreamEvent{Type: models.EventContentCreated, Collection: "courses", ContentId: "c", Class: "lessons"})
+		_, ok := c.Class("courses", "lessons")
+		assert.False(t, ok)
+		_, ok = c.Class("courses", "quizzes")
+		assert.True(t, ok)
+
+		fill(c, "courses")
+		c.HandleStreamEvent(events.StreamEvent{Type: models.EventContentUpdated, Collection: "courses", ContentId: "a", Class: "lessons"})
+		_, ok = c.Class("courses", "quizzes")
+		assert.False(t, ok)
+		_, ok = c.Content("courses", "b")
+		assert.True(t, ok)
+
+		fill(c, "courses")
+		c.HandleStreamEvent(events.StreamEvent{Type: models.EventContentDeleted, Collection: "courses"})
+		assert.Equal(t, 0, c.Stats().Entries)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		c, _ := newTestCache(DefaultConfig)
+		fill(c, "courses")
+		fill(c, "guides")
+		c.Purge()
+		assert.Equal(t, 0, c.Stats().Entries)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		c := New(Config{})
+		assert.Nil(t, c)
+		c.PutContent("courses", "a", c.Generation("courses"), &models.ReadContent{Id: "a"})
+		_, ok := c.Content("courses", "a")
+		assert.False(t, ok)
+		c.Invalidate("courses", []string{"a"})
+		assert.Equal(t, 0, c.Stats().Entries)
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultConfig, cfg)
+	})
+
+	t.Run("All Settings", func(t *testing.T) {
+		t.Setenv("YAN_CMS_READ_CACHE_SIZE", "500")
+		t.Setenv("YAN_CMS_READ_CACHE_TTL", "1m")
+		t.Setenv("YAN_CMS_READ_CACHE_COLLECTIONS", "drafts=off, courses=5m")
+
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, Config{
+			Size:        500,
+			TTL:         time.Minute,
+			Collections: map[string]time.Duration{"drafts": 0, "courses": 5 * time.Minute},
+		}, cfg)
+	})
+
+	t.Run("Invalid Settings", func(t *testing.T) {
+		for env, value := range map[string]string{
+			"YAN_CMS_READ_CACHE_SIZE":        "-1",
+			"YAN_CMS_READ_CACHE_TTL":         "-5s",
+			"YAN_CMS_READ_CACHE_COLLECTIONS": "courses",
+		} {
+			t.Setenv(env, value)
+			_, err := ConfigFromEnv()
+			assert.Error(t, err, env)
+			t.Setenv(env, "")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// Size is the most entries kept across all collections. The cache is off
+	// when it is zero.
+	Size int
+	TTL  time.Duration
+	// Collections overrides TTL for some collections. A zero TTL keeps a
+	// collection out of the cache.
+	Collections map[string]time.Duration
+}
+
+var DefaultConfig = Config{
+	Size: 10000,
+	TTL:  30 * time.Second,
+}
+
+func (c Config) ttl(coll string) time.Duration {
+	if ttl, ok := c.Collections[coll]; ok {
+		return ttl
+	}
+	return c.TTL
+}
+
+// ConfigFromEnv reads YAN_CMS_READ_CACHE_SIZE, YAN_CMS_READ_CACHE_TTL and
+// YAN_CMS_READ_CACHE_COLLECTIONS. The last is a comma-separated list of
+// <collection>=<ttl>, where the TTL may be "off".
+func ConfigFromEnv() (Config, error) {
+	slog.Debug("Loading read cache environment variables...")
+	cfg := DefaultConfig
+
+	if value := os.Getenv("YAN_CMS_READ_CACHE_SIZE"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			err := fmt.Errorf("invalid YAN_CMS_READ_CACHE_SIZE %q, expected a non-negative integer", value)
+			slog.Error("Invalid read cache configuration", "error", err)
+			return Config{}, err
+		}
+		cfg.Size = n
+	}
+
+	if value := os.Getenv("YAN_CMS_READ_CACHE_TTL"); value != "" {
+		ttl, err := parseTTL(value)
+		if err != nil {
+			err := fmt.Errorf("invalid YAN_CMS_READ_CACHE_TTL: %s", err.Error())
+			slog.Error("Invalid read cache configuration", "error", err)
+			return Config{}, err
+		}
+		cfg.TTL = ttl
+	}
+
+	if value := os.Getenv("YAN_CMS_READ_CACHE_COLLECTIONS"); value != "" {
+		cfg.Collections = map[string]time.Duration{}
+		for _, rule := range strings.Split(value, ",") {
+			coll, value, ok := strings.Cut(strings.TrimSpace(rule), "=")
+			ttl, err := parseTTL(value)
+			if !ok || coll == "" || err != nil {
+				err := fmt.Errorf("invalid YAN_CMS_READ_CACHE_COLLECTIONS rule %q, expected <collection>=<ttl>", rule)
+				slog.Error("Invalid read cache configuration", "error", err)
+				return Config{}, err
+			}
+			cfg.Collections[coll] = ttl
+		}
+	}
+
+	slog.Info("Read cache configured", "size", cfg.Size, "ttl", cfg.TTL, "collections", cfg.Collections)
+	return cfg, nil
+}
+
+func parseTTL(value string) (time.Duration, error) {
+	if value == "off" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("negative duration %q", value)
+	}
+	return ttl, nil
+}
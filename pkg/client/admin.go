@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// The methods in this file need the client to be created WithToken and the
+// admin token.
+
+// CreateAPIKey issues a key. The secret in the result is only returned once.
+func (c *Client) CreateAPIKey(ctx context.Context, key *models.CreateAPIKey) (*models.IssuedAPIKey, error) {
+	var issued models.IssuedAPIKey
+	if err := c.call(ctx, http.MethodPost, "/admin/api-keys", nil, key, &issued); err != nil {
+		return nil, err
+	}
+	return &issued, nil
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	err := c.call(ctx, http.MethodGet, "/admin/api-keys", nil, nil, &keys)
+	return keys, err
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/admin/api-keys/"+escape(id), nil, nil, nil)
+}
+
+func (c *Client) RotateAPIKey(ctx context.Context, id string) (*models.IssuedAPIKey, error) {
+	var issued models.IssuedAPIKey
+	if err := c.call(ctx, http.MethodPost, "/admin/api-keys/"+escape(id)+"/rotate", nil, nil, &issued); err != nil {
+		return nil, err
+	}
+	return &issued, nil
+}
+
+// RegisterCollection registers what a collection is for and who owns it,
+// replacing what was registered before. The name and timestamps of info are
+// ignored.
+func (c *Client) RegisterCollection(ctx context.Context, coll string, info *models.CollectionInfo) (*models.CollectionInfo, error) {
+	var registered models.CollectionInfo
+	if err := c.call(ctx, http.MethodPut, "/admin/collections/"+escape(coll), nil, info, &registered); err != nil {
+		return nil, err
+	}
+	return &registered, nil
+}
+
+func (c *Client) UnregisterCollection(ctx context.Context, coll string) error {
+	return c.call(ctx, http.MethodDelete, "/admin/collections/"+escape(coll), nil, nil, nil)
+}
+
+// RegisterSchema adds a version of the JSON Schema that the custom fields of
+// the items of a class must match, and returns it with its version.
+func (c *Client) RegisterSchema(ctx context.Context, coll string, class string, schema map[string]any) (*models.ContentSchema, error) {
+	var registered models.ContentSchema
+	if err := c.call(ctx, http.MethodPut, "/admin/schemas/"+escape(coll)+"/"+escape(class), nil, schema, &registered); err != nil {
+		return nil, err
+	}
+	return &registered, nil
+}
+
+// ListSchemaVersions returns every version of the schema of a class, oldest
+// first.
+func (c *Client) ListSchemaVersions(ctx context.Context, coll string, class string) ([]models.ContentSchema, error) {
+	versions := []models.ContentSchema{}
+	err := c.call(ctx, http.MethodGet, "/admin/schemas/"+escape(coll)+"/"+escape(class), nil, nil, &versions)
+	return versions, err
+}
+
+func (c *Client) DeleteSchema(ctx context.Context, coll string, class string) error {
+	return c.call(ctx, http.MethodDelete, "/admin/schemas/"+escape(coll)+"/"+escape(class), nil, nil, nil)
+}
+
+// SchemaReport checks the items of a class against a version of its schema,
+// or the latest when version is 0, and lists those that do not match.
+func (c *Client) SchemaReport(ctx context.Context, coll string, class string, version int) (*models.SchemaReport, error) {
+	var report models.SchemaReport
+	if err := c.call(ctx, http.MethodGet, "/admin/schemas/"+escape(coll)+"/"+escape(class)+"/report", schemaVersionQuery(version), nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, webhook *models.CreateWebhook) (*models.Webhook, error) {
+	var created models.Webhook
+	if err := c.call(ctx, http.MethodPost, "/admin/webhooks", nil, webhook, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	err := c.call(ctx, http.MethodGet, "/admin/webhooks", nil, nil, &webhooks)
+	return webhooks, err
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := c.call(ctx, http.MethodGet, "/admin/webhooks/"+escape(id), nil, nil, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, id string, update *models.UpdateWebhook) error {
+	return c.call(ctx, http.MethodPut, "/admin/webhooks/"+escape(id), nil, update, nil)
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, "/admin/webhooks/"+escape(id), nil, nil, nil)
+}
+
+func (c *Client) ListDeliveries(ctx context.Context, webhookId string) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	err := c.call(ctx, http.MethodGet, "/admin/webhooks/"+escape(webhookId)+"/deliveries", nil, nil, &deliveries)
+	return deliveries, err
+}
+
+// Redeliver queues a delivery to be sent again and returns the new
+// delivery's ID.
+func (c *Client) Redeliver(ctx context.Context, webhookId string, deliveryId string) (string, error) {
+	var id string
+	err := c.call(ctx, http.MethodPost, "/admin/webhooks/"+escape(webhookId)+"/deliveries/"+escape(deliveryId)+"/redeliver", nil, nil, &id)
+	return id, err
+}
+
+type AuditFilter struct {
+	Action     string
+	Principal  string
+	RequestId  string
+	Collection string
+	ContentId  string
+	Class      string
+	From       *time.Time
+	To         *time.Time
+}
+
+func (f *AuditFilter) query() url.Values {
+	query := url.Values{}
+	if f == nil {
+		return query
+	}
+	for param, value := range map[string]string{
+		"action":     f.Action,
+		"principal":  f.Principal,
+		"request_id": f.RequestId,
+		"collection": f.Collection,
+		"content_id": f.ContentId,
+		"class":      f.Class,
+	} {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+	if f.From != nil {
+		query.Set("from", f.From.Format(time.RFC3339))
+	}
+	if f.To != nil {
+		query.Set("to", f.To.Format(time.RFC3339))
+	}
+	return query
+}
+
+// ListAudit returns one page of audit entries, newest first. See Audit to
+// iterate over every page.
+func (c *Client) ListAudit(ctx context.Context, filter *AuditFilter, limit int, offset int) ([]models.AuditEntry, error) {
+	query := filter.query()
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	entries := []models.AuditEntry{}
+	err := c.call(ctx, http.MethodGet, "/audit", query, nil, &entries)
+	return entries, err
+}
+
+// ExportAudit streams every matching audit entry, oldest first, as NDJSON or,
+// with format "csv", as CSV. The caller must close the returned reader.
+func (c *Client) ExportAudit(ctx context.Context, filter *AuditFilter, format string) (io.ReadCloser, error) {
+	query := filter.query()
+	if format != "" {
+		query.Set("format", format)
+	}
+
+	res, err := c.send(ctx, http.MethodGet, "/audit/export", query, nil, "*/*")
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// BulkCreate creates contents in one request and reports on each item,
+// in order. The IDs and timestamps of contents are ignored. With atomic
+// set, nothing is created unless every item can be, and when that happens
+// the report is returned alongside the error, as with ImportCollection.
+func (c *Client) BulkCreate(ctx context.Context, coll string, contents []models.Content, atomic bool) (*models.BulkReport, error) {
+	return c.bulk(ctx, http.MethodPost, coll, contents, atomic)
+}
+
+// BulkUpdate changes the fields that are set in each update, like
+// UpdateContent, in one request.
+func (c *Client) BulkUpdate(ctx context.Context, coll string, updates []models.BulkUpdate, atomic bool) (*models.BulkReport, error) {
+	return c.bulk(ctx, http.MethodPatch, coll, updates, atomic)
+}
+
+// BulkDelete deletes the items with ids in one request. IDs that do not
+// exist are reported as deleted.
+func (c *Client) BulkDelete(ctx context.Context, coll string, ids []string, atomic bool) (*models.BulkReport, error) {
+	return c.bulk(ctx, http.MethodDelete, coll, ids, atomic)
+}
+
+func (c *Client) bulk(ctx context.Context, method string, coll string, body any, atomic bool) (*models.BulkReport, error) {
+	var query url.Values
+	if atomic {
+		query = url.Values{"atomic": {"true"}}
+	}
+
+	var report models.BulkReport
+	err := c.call(ctx, method, "/contents/"+escape(coll)+"/bulk", query, body, &report)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity && apiErr.Data != nil {
+		if json.Unmarshal(apiErr.Data, &report) == nil {
+			return &report, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
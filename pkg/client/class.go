@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// SummarizeClasses returns the classes of a collection in sorted order, with
+// how many items each has and when the last of them was updated.
+func (c *Client) SummarizeClasses(ctx context.Context, coll string) ([]models.ClassSummary, error) {
+	var classes []models.ClassSummary
+	if err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll)+"/classes", nil, nil, &classes); err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// RenameClass moves every item of a class to the class to, which must not
+// have any items yet.
+func (c *Client) RenameClass(ctx context.Context, coll string, class string, to string) (*models.ClassMoveResult, error) {
+	return c.moveClass(ctx, coll, class, "rename", models.ClassMove{To: to})
+}
+
+// MergeClass moves every item of a class to the class to, which may already
+// have items.
+func (c *Client) MergeClass(ctx context.Context, coll string, class string, to string) (*models.ClassMoveResult, error) {
+	return c.moveClass(ctx, coll, class, "merge", models.ClassMove{To: to})
+}
+
+// MoveToClass moves the items of a class with ids to the class to. IDs that
+// are not in the class are reported as missing.
+func (c *Client) MoveToClass(ctx context.Context, coll string, class string, to string, ids []string) (*models.ClassMoveResult, error) {
+	return c.moveClass(ctx, coll, class, "move", models.ClassMove{To: to, Ids: ids})
+}
+
+func (c *Client) moveClass(ctx context.Context, coll string, class string, action string, move models.ClassMove) (*models.ClassMoveResult, error) {
+	var result models.ClassMoveResult
+	path := "/contents/" + escape(coll) + "/class/" + escape(class) + "/" + action
+	if err := c.call(ctx, http.MethodPost, path, nil, move, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Schema returns the schema of a class, the latest version when version is
+// 0.
+func (c *Client) Schema(ctx context.Context, coll string, class string, version int) (*models.ContentSchema, error) {
+	var schema models.ContentSchema
+	if err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll)+"/class/"+escape(class)+"/schema", schemaVersionQuery(version), nil, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func schemaVersionQuery(version int) url.Values {
+	if version == 0 {
+		return nil
+	}
+	return url.Values{"version": {strconv.Itoa(version)}}
+}
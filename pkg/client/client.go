@@ -0,0 +1,255 @@
+// Package client is a Go client for the CMS REST API.
+//
+//	c := client.New("http://cms:8000", client.WithToken(os.Getenv("CMS_API_KEY")))
+//	content, err := c.GetContent(ctx, "courses", id)
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+//
+// Every method takes a context. GET, PUT and DELETE requests are retried with
+// exponential backoff on network errors, 429 and 502 to 504 responses; POST
+// and PATCH requests are not, since they are not idempotent, unless their
+// context carries a key from WithIdempotencyKey.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Token      string
+	UserAgent  string
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithToken authenticates every request with an API key or the admin token.
+func WithToken(token string) Option {
+	return func(c *Client) { c.Token = token }
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.HTTPClient = httpClient }
+}
+
+// WithRetries sets how many times idempotent requests are retried and the
+// bounds of the backoff between attempts. Zero retries disables retrying.
+func WithRetries(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.MaxRetries = maxRetries
+		c.MinBackoff = minBackoff
+		c.MaxBackoff = maxBackoff
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.UserAgent = userAgent }
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		UserAgent:  "yan-cms-go-client",
+		MaxRetries: DefaultMaxRetries,
+		MinBackoff: DefaultMinBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// upload is a request body that is sent as it is rather than encoded as JSON.
+type upload struct {
+	contentType string
+	data        []byte
+}
+
+type envelope struct {
+	Error   bool            `json:"error"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// call sends a request and decodes the data field of the response envelope
+// into out, unless out is nil.
+func (c *Client) call(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	res, err := c.send(ctx, method, path, query, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("decoding response data: %w", err)
+	}
+	return nil
+}
+
+// send performs a request, retrying it when it is idempotent, and returns the
+// response if it succeeded. Error responses are decoded into an *Error.
+func (c *Client) send(ctx context.Context, method string, path string, query url.Values, body any, accept string) (*http.Response, error) {
+	var payload []byte
+	contentType := "application/json"
+	if u, ok := body.(upload); ok {
+		payload = u.data
+		contentType = u.contentType
+	} else if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	key := idempotencyKeyFrom(ctx)
+	retries := 0
+	if (method != http.MethodPost && method != http.MethodPatch) || key != "" {
+		retries = c.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", accept)
+		if c.UserAgent != "" {
+			req.Header.Set("User-Agent", c.UserAgent)
+		}
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		res, err := c.HTTPClient.Do(req)
+		if err == nil && res.StatusCode < 400 {
+			return res, nil
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			apiErr := decodeError(res)
+			res.Body.Close()
+			if !retryable(res.StatusCode) {
+				return nil, apiErr
+			}
+			retryAfter = apiErr.RetryAfter
+			err = apiErr
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if attempt >= retries {
+			return nil, err
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a random wait of up to MinBackoff doubled per attempt, and
+// no more than MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.MinBackoff << attempt
+	if ceiling <= 0 || ceiling > c.MaxBackoff {
+		ceiling = c.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func decodeError(res *http.Response) *Error {
+	apiErr := &Error{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	var env envelope
+	var gql graphQLResponse
+	if err := json.Unmarshal(body, &env); err == nil && env.Message != "" {
+		apiErr.Message = env.Message
+		if len(env.Data) > 0 && string(env.Data) != "null" {
+			apiErr.Data = env.Data
+		}
+	} else if err := json.Unmarshal(body, &gql); err == nil && len(gql.Errors) > 0 {
+		apiErr.Message = gql.Errors[0].Message
+	}
+
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
+
+// Health reports whether the service is up.
+func (c *Client) Health(ctx context.Context) error {
+	res, err := c.send(ctx, http.MethodGet, "/health", nil, nil, "text/plain")
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
@@ -0,0 +1,411 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/client"
+	"github.com/YanSystems/cms/pkg/client/clienttest"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContent(class string, title string) *models.Content {
+	return &models.Content{
+		Class:       class,
+		Title:       title,
+		Description: "Description",
+		Body:        "Body",
+		CreatorId:   uuid.New().String(),
+	}
+}
+
+func TestContent(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	c := client.New(srv.URL, client.WithRetries(0, 0, 0))
+	ctx := context.Background()
+
+	id, err := c.CreateContent(ctx, "courses", newContent("lessons", "Intro"))
+	assert.NoError(t, err)
+	_, err = c.CreateContent(ctx, "courses", newContent("quizzes", "Quiz"))
+	assert.NoError(t, err)
+
+	t.Run("Get Content", func(t *testing.T) {
+		content, err := c.GetContent(ctx, "courses", id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Intro", content.Title)
+	})
+
+	t.Run("Get Class", func(t *testing.T) {
+		contents, err := c.GetClass(ctx, "courses", "quizzes")
+		assert.NoError(t, err)
+		assert.Len(t, contents, 1)
+	})
+
+	t.Run("Update Content", func(t *testing.T) {
+		title := "Introduction"
+		assert.NoError(t, c.UpdateContent(ctx, "courses", id, &models.UpdateContent{Title: &title}))
+		content, err := c.GetContent(ctx, "courses", id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Introduction", content.Title)
+	})
+
+	t.Run("Update Custom Fields", func(t *testing.T) {
+		fields := map[string]any{"difficulty": "easy", "duration": 45}
+		assert.NoError(t, c.UpdateContent(ctx, "courses", id, &models.UpdateContent{Fields: fields}))
+		content, err := c.GetContent(ctx, "courses", id)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"difficulty": "easy", "duration": float64(45)}, content.Fields)
+	})
+
+	t.Run("Patch Content", func(t *testing.T) {
+		content, err := c.MergePatchContent(ctx, "courses", id, map[string]any{"description": nil, "views": 4})
+		assert.NoError(t, err)
+		assert.Empty(t, content.Description)
+		assert.Equal(t, 4, content.Views)
+
+		content, err = c.PatchContent(ctx, "courses", id, []client.PatchOperation{
+			{Op: "test", Path: "/views", Value: 4},
+			{Op: "replace", Path: "/title", Value: "Lesson 1"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Lesson 1", content.Title)
+
+		_, err = c.PatchContent(ctx, "courses", id, []client.PatchOperation{{Op: "test", Path: "/views", Value: 5}})
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	})
+
+	t.Run("Bulk", func(t *testing.T) {
+		report, err := c.BulkCreate(ctx, "lessons", []models.Content{*newContent("lessons", "One"), *newContent("lessons", "Two")}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Succeeded)
+		assert.Equal(t, http.StatusCreated, report.Results[1].Status)
+
+		report, err = c.BulkCreate(ctx, "lessons", []models.Content{*newContent("lessons", "Three"), {Title: "Untitled"}}, true)
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, http.StatusFailedDependency, report.Results[0].Status)
+		assert.Equal(t, http.StatusBadRequest, report.Results[1].Status)
+
+		contents, err := c.GetCollection(ctx, "lessons")
+		assert.NoError(t, err)
+		assert.Len(t, contents, 2)
+
+		title := "First"
+		report, err = c.BulkUpdate(ctx, "lessons", []models.BulkUpdate{
+			{Id: contents[0].Id, UpdateContent: models.UpdateContent{Title: &title}},
+			{Id: "missing", UpdateContent: models.UpdateContent{Title: &title}},
+		}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, http.StatusNotFound, report.Results[1].Status)
+
+		report, err = c.BulkDelete(ctx, "lessons", []string{contents[0].Id, contents[1].Id}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Succeeded)
+		contents, err = c.GetCollection(ctx, "lessons")
+		assert.NoError(t, err)
+		assert.Empty(t, contents)
+	})
+
+	t.Run("Classes", func(t *testing.T) {
+		first, err := c.CreateContent(ctx, "modules", newContent("drafts", "One"))
+		assert.NoError(t, err)
+		_, err = c.CreateContent(ctx, "modules", newContent("drafts", "Two"))
+		assert.NoError(t, err)
+		_, err = c.CreateContent(ctx, "modules", newContent("published", "Three"))
+		assert.NoError(t, err)
+
+		classes, err := c.SummarizeClasses(ctx, "modules")
+		assert.NoError(t, err)
+		assert.Len(t, classes, 2)
+		assert.Equal(t, "drafts", classes[0].Class)
+		assert.Equal(t, int64(2), classes[0].Count)
+
+		_, err = c.RenameClass(ctx, "modules", "drafts", "published")
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+
+		result, err := c.MoveToClass(ctx, "modules", "drafts", "review", []string{first, "missing"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{first}, result.Moved)
+		assert.Equal(t, []string{"missing"}, result.Missing)
+
+		result, err = c.MergeClass(ctx, "modules", "drafts", "review")
+		assert.NoError(t, err)
+		assert.Len(t, result.Moved, 1)
+
+		result, err = c.RenameClass(ctx, "modules", "review", "ready")
+		assert.NoError(t, err)
+		assert.Len(t, result.Moved, 2)
+
+		_, err = c.MergeClass(ctx, "modules", "drafts", "ready")
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("Catalog", func(t *testing.T) {
+		catalog, err := c.Catalog(ctx)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, catalog)
+		assert.Equal(t, "courses", catalog[0].Name)
+
+		stats, err := c.CollectionStats(ctx, "courses")
+		assert.NoError(t, err)
+		assert.Equal(t, catalog[0].Count, stats.Count)
+		assert.Len(t, stats.ClassSummaries, stats.Classes)
+
+		_, err = c.CollectionStats(ctx, "typo")
+		assert.ErrorIs(t, err, client.ErrNotFound)
+	})
+
+	t.Run("Missing Fields", func(t *testing.T) {
+		_, err := c.CreateContent(ctx, "courses", &models.Content{Title: "Untitled"})
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "missing fields in request payload", apiErr.Message)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		assert.NoError(t, c.DeleteContent(ctx, "courses", id))
+		_, err := c.GetContent(ctx, "courses", id)
+		assert.ErrorIs(t, err, client.ErrNotFound)
+	})
+
+	t.Run("Delete Collection", func(t *testing.T) {
+		assert.NoError(t, c.DeleteCollection(ctx, "courses"))
+		contents, err := c.GetCollection(ctx, "courses")
+		assert.NoError(t, err)
+		assert.Empty(t, contents)
+	})
+}
+
+func TestRetries(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	c := client.New(srv.URL, client.WithRetries(2, time.Millisecond, 5*time.Millisecond))
+	ctx := context.Background()
+
+	t.Run("Idempotent Requests Are Retried", func(t *testing.T) {
+		srv.FailNext(http.StatusServiceUnavailable, http.StatusBadGateway)
+		_, err := c.GetCollection(ctx, "courses")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Retries Run Out", func(t *testing.T) {
+		srv.FailNext(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		_, err := c.GetCollection(ctx, "courses")
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	})
+
+	t.Run("Creates Are Not Retried", func(t *testing.T) {
+		srv.FailNext(http.StatusServiceUnavailable)
+		_, err := c.CreateContent(ctx, "courses", newContent("lessons", "Intro"))
+		assert.Error(t, err)
+		assert.Empty(t, srv.Contents("courses"))
+	})
+
+	t.Run("Creates With Idempotency Keys Are Retried", func(t *testing.T) {
+		keyed := client.WithIdempotencyKey(ctx, "create-intro")
+		content := newContent("lessons", "Intro")
+		srv.FailNext(http.StatusServiceUnavailable)
+		id, err := c.CreateContent(keyed, "courses", content)
+		assert.NoError(t, err)
+
+		again, err := c.CreateContent(keyed, "courses", content)
+		assert.NoError(t, err)
+		assert.Equal(t, id, again)
+		assert.Len(t, srv.Contents("courses"), 1)
+
+		_, err = c.CreateContent(keyed, "courses", newContent("lessons", "Outro"))
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	})
+
+	t.Run("Client Errors Are Not Retried", func(t *testing.T) {
+		var calls atomic.Int32
+		forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":true,"message":"api key is not permitted to read collection courses"}`))
+		}))
+		defer forbidden.Close()
+
+		_, err := client.New(forbidden.URL, client.WithToken("yan_key")).GetCollection(ctx, "courses")
+		assert.ErrorIs(t, err, client.ErrForbidden)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Context Cancelled While Waiting", func(t *testing.T) {
+		slow := client.New(srv.URL, client.WithRetries(3, time.Hour, time.Hour))
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		srv.FailNext(http.StatusServiceUnavailable)
+		_, err := slow.GetCollection(ctx, "courses")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+func TestAuthorizationHeader(t *testing.T) {
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+		w.Write([]byte(`{"error":false,"message":"ok","data":[]}`))
+	}))
+	defer srv.Close()
+
+	_, err := client.New(srv.URL, client.WithToken("yan_secret")).ListAPIKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer yan_secret", header)
+}
+
+func TestChanges(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	c := client.New(srv.URL)
+	ctx := context.Background()
+
+	first, _ := c.CreateContent(ctx, "courses", newContent("lessons", "One"))
+
+	it := c.Changes(ctx, "courses", "", 0)
+	var snapshot []string
+	for it.Next() {
+		snapshot = append(snapshot, it.Change().Id)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{first}, snapshot)
+	token := it.Token()
+
+	second, _ := c.CreateContent(ctx, "courses", newContent("lessons", "Two"))
+	third, _ := c.CreateContent(ctx, "courses", newContent("lessons", "Three"))
+	assert.NoError(t, c.DeleteContent(ctx, "courses", first))
+
+	it = c.Changes(ctx, "courses", token, 2)
+	var changed []string
+	var deleted []string
+	for it.Next() {
+		if it.Change().Deleted {
+			deleted = append(deleted, it.Change().Id)
+		} else {
+			changed = append(changed, it.Change().Id)
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{second, third}, changed)
+	assert.Equal(t, []string{first}, deleted)
+
+	it = c.Changes(ctx, "courses", it.Token(), 0)
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+
+	// The snapshot is paged like the changes
+	it = c.Changes(ctx, "courses", "", 1)
+	snapshot = nil
+	for it.Next() {
+		snapshot = append(snapshot, it.Change().Id)
+	}
+	assert.NoError(t, it.Err())
+	assert.ElementsMatch(t, []string{second, third}, snapshot)
+
+	it = c.Changes(ctx, "courses", it.Token(), 0)
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestStreamEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "7", r.URL.Query().Get("last_event_id"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: reset\ndata: {}\n\n: keepalive\n\n"))
+		w.Write([]byte("id: 8\nevent: content.deleted\ndata: {\"id\":\"8\",\"type\":\"content.deleted\",\"collection\":\"courses\",\"content_id\":\"a\"}\n\n"))
+	}))
+	defer srv.Close()
+
+	stream, err := client.New(srv.URL).StreamEvents(context.Background(), "courses", "", "7")
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	assert.True(t, stream.Next())
+	assert.Equal(t, "reset", stream.Event().Name)
+
+	assert.True(t, stream.Next())
+	assert.Equal(t, "content.deleted", stream.Event().Name)
+	assert.Equal(t, "a", stream.Event().Data.ContentId)
+	assert.Equal(t, "8", stream.LastEventId())
+
+	assert.False(t, stream.Next())
+	assert.NoError(t, stream.Err())
+}
+
+func TestExportImport(t *testing.T) {
+	staging := clienttest.NewServer()
+	defer staging.Close()
+	production := clienttest.NewServer()
+	defer production.Close()
+
+	ctx := context.Background()
+	from := client.New(staging.URL)
+	to := client.New(production.URL)
+
+	id, _ := from.CreateContent(ctx, "courses", newContent("lessons", "Intro"))
+	from.CreateContent(ctx, "courses", newContent("quizzes", "Quiz"))
+
+	export := func() []byte {
+		body, err := from.ExportCollection(ctx, "courses", "", true)
+		assert.NoError(t, err)
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		assert.NoError(t, err)
+		return data
+	}
+
+	t.Run("Dry Run", func(t *testing.T) {
+		report, err := to.ImportCollection(ctx, "courses", bytes.NewReader(export()), client.ImportOptions{DryRun: true})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Created)
+		assert.Empty(t, production.Contents("courses"))
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		report, err := to.ImportCollection(ctx, "courses", bytes.NewReader(export()), client.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Created)
+
+		content, err := to.GetContent(ctx, "courses", id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Intro", content.Title)
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		report, err := to.ImportCollection(ctx, "courses", bytes.NewReader(export()), client.ImportOptions{})
+		var apiErr *client.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+		assert.Equal(t, 2, report.Failed)
+
+		report, err = to.ImportCollection(ctx, "courses", bytes.NewReader(export()), client.ImportOptions{OnConflict: "skip"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Skipped)
+	})
+}
@@ -0,0 +1,836 @@
+// Package clienttest provides an in-memory fake of the CMS content API for
+// testing code that uses pkg/client without a database:
+//
+//	srv := clienttest.NewServer()
+//	defer srv.Close()
+//	c := client.New(srv.URL)
+//
+// It implements the catalog, content, class, patch, bulk, change, export and
+// import routes with the same validation, envelopes, error messages and
+// Idempotency-Key handling as the real service. Admin, audit, event stream
+// and GraphQL routes are not implemented and respond with 501.
+package clienttest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/idempotency"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/patch"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	collections map[string]map[string]models.Content
+	changes     map[string][]change
+	failures    []int
+}
+
+type change struct {
+	seq     int64
+	id      string
+	deleted bool
+}
+
+func NewServer() *Server {
+	s := &Server{
+		collections: map[string]map[string]models.Content{},
+		changes:     map[string][]change{},
+	}
+
+	router := chi.NewRouter()
+	router.Use(s.injectFailures)
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	idempotent := idempotency.Middleware(idempotency.DefaultConfig, idempotency.NewMemoryStore())
+	router.Get("/contents", s.handleListCollections)
+	router.With(idempotent).Post("/contents/{collection}", s.handleCreate)
+	router.Get("/contents/{collection}", s.handleGetCollection)
+	router.Get("/contents/{collection}/stats", s.handleGetCollectionStats)
+	router.Get("/contents/{collection}/changes", s.handleGetChanges)
+	router.Get("/contents/{collection}/export", s.handleExport)
+	router.With(idempotent).Post("/contents/{collection}/import", s.handleImport)
+	router.With(idempotent).Post("/contents/{collection}/bulk", s.handleBulk)
+	router.With(idempotent).Patch("/contents/{collection}/bulk", s.handleBulk)
+	router.With(idempotent).Delete("/contents/{collection}/bulk", s.handleBulk)
+	router.Get("/contents/{collection}/id/{id}", s.handleGet)
+	router.Get("/contents/{collection}/classes", s.handleListClasses)
+	router.Get("/contents/{collection}/class/{class}", s.handleGetClass)
+	router.Post("/contents/{collection}/class/{class}/{action:rename|merge|move}", s.handleMoveClass)
+	router.Put("/contents/{collection}/id/{id}", s.handleUpdate)
+	router.Patch("/contents/{collection}/id/{id}", s.handlePatch)
+	router.Delete("/contents/{collection}", s.handleDeleteCollection)
+	router.Delete("/contents/{collection}/id/{id}", s.handleDelete)
+	router.Delete("/contents/{collection}/class/{class}", s.handleDeleteClass)
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		utils.ErrorJSON(w, errors.New("not implemented by the fake server"), http.StatusNotImplemented)
+	})
+
+	s.Server = httptest.NewServer(router)
+	return s
+}
+
+// Seed stores contents as they are, keeping their IDs and timestamps.
+func (s *Server) Seed(coll string, contents ...models.Content) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range contents {
+		s.put(coll, c)
+	}
+}
+
+// Contents returns the items stored in a collection, oldest first.
+func (s *Server) Contents(coll string) []models.Content {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(coll, "")
+}
+
+// FailNext makes the next requests fail with the given statuses, one per
+// request, to exercise error handling and retries.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+func (s *Server) injectFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		var status int
+		if len(s.failures) > 0 {
+			status, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			utils.ErrorJSON(w, errors.New(strings.ToLower(http.StatusText(status))), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) put(coll string, c models.Content) {
+	if s.collections[coll] == nil {
+		s.collections[coll] = map[string]models.Content{}
+	}
+	s.collections[coll][c.Id] = c
+	s.record(coll, c.Id, false)
+}
+
+func (s *Server) record(coll string, id string, deleted bool) {
+	changes := s.changes[coll]
+	seq := int64(len(changes) + 1)
+	s.changes[coll] = append(changes, change{seq: seq, id: id, deleted: deleted})
+}
+
+func (s *Server) list(coll string, class string) []models.Content {
+	contents := []models.Content{}
+	for _, c := range s.collections[coll] {
+		if class == "" || c.Class == class {
+			contents = append(contents, c)
+		}
+	}
+	sort.Slice(contents, func(i, j int) bool {
+		if contents[i].CreatedAt.Equal(contents[j].CreatedAt) {
+			return contents[i].Id < contents[j].Id
+		}
+		return contents[i].CreatedAt.Before(contents[j].CreatedAt)
+	})
+	return contents
+}
+
+func ok(w http.ResponseWriter, status int, message string, data any) {
+	utils.WriteJSON(w, status, models.JsonResponse{Error: false, Message: message, Data: data})
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+
+	var c models.Content
+	if err := utils.ReadJSON(w, r, &c); err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+	if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+		utils.ErrorJSON(w, errors.New("missing fields in request payload"))
+		return
+	}
+	if _, err := uuid.Parse(c.CreatorId); err != nil {
+		utils.ErrorJSON(w, fmt.Errorf("field CreatorId is not valid: %v", err))
+		return
+	}
+
+	c.Id = uuid.New().String()
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = c.CreatedAt
+
+	s.mu.Lock()
+	s.put(coll, c)
+	s.mu.Unlock()
+
+	ok(w, http.StatusCreated, "Successfully created content", c.Id)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	c, found := s.collections[chi.URLParam(r, "collection")][chi.URLParam(r, "id")]
+	s.mu.Unlock()
+
+	if !found {
+		utils.ErrorJSON(w, errors.New("content not found"))
+		return
+	}
+	ok(w, http.StatusOK, "Successfully retrieved content", c)
+}
+
+func (s *Server) handleGetCollection(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	contents := s.list(chi.URLParam(r, "collection"), "")
+	s.mu.Unlock()
+
+	ok(w, http.StatusOK, "Successfully retrieved collection", contents)
+}
+
+func (s *Server) handleGetClass(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	contents := s.list(chi.URLParam(r, "collection"), chi.URLParam(r, "class"))
+	s.mu.Unlock()
+
+	ok(w, http.StatusOK, "Successfully retrieved class", contents)
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	id := chi.URLParam(r, "id")
+
+	var update models.UpdateContent
+	if err := utils.ReadJSON(w, r, &update); err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, found := s.collections[coll][id]
+	if !found {
+		utils.ErrorJSON(w, errors.New("content not found"))
+		return
+	}
+	if update.Class != nil {
+		c.Class = *update.Class
+	}
+	if update.Title != nil {
+		c.Title = *update.Title
+	}
+	if update.Description != nil {
+		c.Description = *update.Description
+	}
+	if update.Body != nil {
+		c.Body = *update.Body
+	}
+	if update.Views != nil {
+		c.Views = *update.Views
+	}
+	if update.CreatorId != nil {
+		c.CreatorId = *update.CreatorId
+	}
+	if update.IsPublic != nil {
+		c.IsPublic = *update.IsPublic
+	}
+	if update.Fields != nil {
+		c.Fields = update.Fields
+	}
+	c.UpdatedAt = time.Now().UTC()
+	s.put(coll, c)
+
+	ok(w, http.StatusOK, "Successfully updated content", id)
+}
+
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	id := chi.URLParam(r, "id")
+
+	apply := patch.Apply
+	switch strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]) {
+	case patch.MergePatchType:
+		apply = patch.Merge
+	case patch.JSONPatchType:
+	default:
+		utils.ErrorJSON(w, fmt.Errorf("unsupported media type %q", r.Header.Get("Content-Type")), http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, found := s.collections[coll][id]
+	if !found {
+		utils.ErrorJSON(w, errors.New("content not found"))
+		return
+	}
+	doc, _ := json.Marshal((*models.ReadContent)(&before))
+	patched, err := apply(doc, body)
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		utils.ErrorJSON(w, err, http.StatusConflict)
+		return
+	case errors.Is(err, patch.ErrCannotApply):
+		utils.ErrorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		utils.ErrorJSON(w, err)
+		return
+	}
+
+	var c models.Content
+	if err := json.Unmarshal(patched, &c); err != nil || c.Id != id || c.Class == "" {
+		utils.ErrorJSON(w, errors.New("patched content is not valid"), http.StatusUnprocessableEntity)
+		return
+	}
+	c.CreatedAt = before.CreatedAt
+	c.UpdatedAt = time.Now().UTC()
+	s.put(coll, c)
+
+	ok(w, http.StatusOK, "Successfully patched content", (*models.ReadContent)(&c))
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	id := chi.URLParam(r, "id")
+
+	s.mu.Lock()
+	if _, found := s.collections[coll][id]; found {
+		delete(s.collections[coll], id)
+		s.record(coll, id, true)
+	}
+	s.mu.Unlock()
+
+	ok(w, http.StatusOK, "Successfully deleted content", nil)
+}
+
+func (s *Server) handleDeleteClass(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+
+	s.mu.Lock()
+	for _, c := range s.list(coll, chi.URLParam(r, "class")) {
+		delete(s.collections[coll], c.Id)
+		s.record(coll, c.Id, true)
+	}
+	s.mu.Unlock()
+
+	ok(w, http.StatusOK, "Successfully deleted class", nil)
+}
+
+func (s *Server) handleListClasses(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	classes := s.summarizeClasses(chi.URLParam(r, "collection"))
+	s.mu.Unlock()
+	ok(w, http.StatusOK, "Successfully listed classes", classes)
+}
+
+func (s *Server) summarizeClasses(coll string) []models.ClassSummary {
+	summaries := map[string]*models.ClassSummary{}
+	for _, c := range s.list(coll, "") {
+		summary := summaries[c.Class]
+		if summary == nil {
+			summary = &models.ClassSummary{Class: c.Class}
+			summaries[c.Class] = summary
+		}
+		summary.Count++
+		if c.UpdatedAt.After(summary.UpdatedAt) {
+			summary.UpdatedAt = c.UpdatedAt
+		}
+	}
+
+	classes := []models.ClassSummary{}
+	for _, summary := range summaries {
+		classes = append(classes, *summary)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Class < classes[j].Class })
+	return classes
+}
+
+// handleListCollections serves the catalog. The fake has no registry, so
+// every collection is unregistered and has no size on disk.
+func (s *Server) handleListCollections(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	names := []string{}
+	for name, contents := range s.collections {
+		if len(contents) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	catalog := []models.CollectionStats{}
+	for _, name := range names {
+		catalog = append(catalog, s.stats(name))
+	}
+	s.mu.Unlock()
+
+	ok(w, http.StatusOK, "Successfully listed collections", catalog)
+}
+
+func (s *Server) handleGetCollectionStats(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+
+	s.mu.Lock()
+	stats := s.stats(coll)
+	stats.ClassSummaries = s.summarizeClasses(coll)
+	s.mu.Unlock()
+
+	if stats.Count == 0 {
+		utils.ErrorJSON(w, errors.New("collection not found"), http.StatusNotFound)
+		return
+	}
+	ok(w, http.StatusOK, "Successfully retrieved collection stats", stats)
+}
+
+func (s *Server) stats(coll string) models.CollectionStats {
+	stats := models.CollectionStats{Name: coll}
+	classes := map[string]bool{}
+	var lastModified time.Time
+	for _, c := range s.collections[coll] {
+		stats.Count++
+		if c.IsPublic {
+			stats.Public++
+		}
+		classes[c.Class] = true
+		if c.UpdatedAt.After(lastModified) {
+			lastModified = c.UpdatedAt
+		}
+	}
+	stats.Private = stats.Count - stats.Public
+	stats.Classes = len(classes)
+	if stats.Count > 0 {
+		stats.PublicRatio = float64(stats.Public) / float64(stats.Count)
+		stats.LastModified = &lastModified
+	}
+	return stats
+}
+
+// classActions gives the past tense of each class move, for its message.
+var classActions = map[string]string{"rename": "renamed", "merge": "merged", "move": "moved"}
+
+func (s *Server) handleMoveClass(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	action := chi.URLParam(r, "action")
+
+	var move models.ClassMove
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+	switch {
+	case move.To == "":
+		utils.ErrorJSON(w, errors.New("missing to in request payload"))
+		return
+	case move.To == class:
+		utils.ErrorJSON(w, errors.New("to must be a different class"))
+		return
+	case action == "move" && len(move.Ids) == 0:
+		utils.ErrorJSON(w, errors.New("field ids is not valid: must list at least one item to move"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.list(coll, class)
+	if action != "move" && len(items) == 0 {
+		utils.ErrorJSON(w, errors.New("class not found"), http.StatusNotFound)
+		return
+	}
+	if action == "rename" && len(s.list(coll, move.To)) > 0 {
+		utils.ErrorJSON(w, errors.New("class already exists"), http.StatusConflict)
+		return
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range move.Ids {
+		wanted[id] = true
+	}
+	result := models.ClassMoveResult{From: class, To: move.To, Moved: []string{}}
+	now := time.Now().UTC()
+	for _, c := range items {
+		if action == "move" && !wanted[c.Id] {
+			continue
+		}
+		c.Class = move.To
+		c.UpdatedAt = now
+		s.put(coll, c)
+		result.Moved = append(result.Moved, c.Id)
+		delete(wanted, c.Id)
+	}
+	for _, id := range move.Ids {
+		if wanted[id] {
+			result.Missing = append(result.Missing, id)
+			delete(wanted, id)
+		}
+	}
+
+	ok(w, http.StatusOK, fmt.Sprintf("Successfully %s %d items", classActions[action], len(result.Moved)), result)
+}
+
+func (s *Server) handleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+
+	s.mu.Lock()
+	for _, c := range s.list(coll, "") {
+		s.record(coll, c.Id, true)
+	}
+	delete(s.collections, coll)
+	s.mu.Unlock()
+
+	ok(w, http.StatusOK, "Successfully deleted collection", nil)
+}
+
+// handleGetChanges serves the latest change per item after the token, which
+// here is simply the sequence number of the last change seen. Snapshot pages
+// add the id of the last item served after a slash.
+func (s *Server) handleGetChanges(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	query := r.URL.Query()
+
+	limit := 500
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			utils.ErrorJSON(w, errors.New("invalid limit, expected a positive integer"))
+			return
+		}
+		limit = min(n, 1000)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := s.changes[coll]
+	res := models.SyncResponse{Changes: []models.SyncChange{}}
+
+	if token := query.Get("since"); token == "" || strings.Contains(token, "/") {
+		seq, after := len(changes), ""
+		if token != "" {
+			var err error
+			value, rest, _ := strings.Cut(token, "/")
+			if seq, err = strconv.Atoi(value); err != nil || seq < 0 || seq > len(changes) || rest == "" {
+				utils.ErrorJSON(w, errors.New("invalid sync token"))
+				return
+			}
+			after = rest
+		}
+
+		contents := s.list(coll, "")
+		sort.Slice(contents, func(i, j int) bool { return contents[i].Id < contents[j].Id })
+		for _, c := range contents {
+			if c.Id <= after {
+				continue
+			}
+			if len(res.Changes) == limit {
+				res.HasMore = true
+				break
+			}
+			res.Changes = append(res.Changes, models.SyncChange{Id: c.Id, Content: &c})
+		}
+		res.NextToken = strconv.Itoa(seq)
+		if res.HasMore {
+			res.NextToken = fmt.Sprintf("%d/%s", seq, res.Changes[len(res.Changes)-1].Id)
+		}
+		ok(w, http.StatusOK, "Successfully retrieved changes", res)
+		return
+	}
+
+	since, err := strconv.Atoi(query.Get("since"))
+	if err != nil || since < 0 || since > len(changes) {
+		utils.ErrorJSON(w, errors.New("invalid sync token"))
+		return
+	}
+
+	latest := map[string]int64{}
+	for _, ch := range changes {
+		latest[ch.id] = ch.seq
+	}
+
+	next := int64(since)
+	for _, ch := range changes[since:] {
+		if latest[ch.id] != ch.seq {
+			continue
+		}
+		if len(res.Changes) == limit {
+			res.HasMore = true
+			break
+		}
+		change := models.SyncChange{Id: ch.id, Deleted: ch.deleted}
+		if !ch.deleted {
+			c := s.collections[coll][ch.id]
+			change.Content = &c
+		}
+		res.Changes = append(res.Changes, change)
+		next = ch.seq
+	}
+	if !res.HasMore {
+		next = int64(len(changes))
+	}
+	res.NextToken = strconv.FormatInt(next, 10)
+
+	ok(w, http.StatusOK, "Successfully retrieved changes", res)
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	contents := s.list(chi.URLParam(r, "collection"), r.URL.Query().Get("class"))
+	s.mu.Unlock()
+
+	var out io.Writer = w
+	if r.URL.Query().Get("gzip") == "true" {
+		w.Header().Set("Content-Type", "application/gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		out = zw
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	encoder := json.NewEncoder(out)
+	for _, c := range contents {
+		encoder.Encode(c)
+	}
+}
+
+// handleImport applies an import line by line. Unlike the real service it
+// does not check ids or timestamps beyond filling in missing ones.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	query := r.URL.Query()
+	onConflict := query.Get("on_conflict")
+	if onConflict == "" {
+		onConflict = "fail"
+	}
+
+	var body io.Reader = bufio.NewReader(r.Body)
+	if magic, _ := body.(*bufio.Reader).Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			utils.ErrorJSON(w, err)
+			return
+		}
+		body = zr
+	}
+
+	report := models.ImportReport{DryRun: query.Get("dry_run") == "true", Errors: []models.ImportError{}}
+	var items []models.Content
+	var lines []int
+	scanner := bufio.NewScanner(body)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		report.Lines++
+
+		var c models.Content
+		if err := json.Unmarshal(text, &c); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, models.ImportError{Line: line, Message: "invalid JSON: " + err.Error()})
+			continue
+		}
+		if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+			report.Failed++
+			report.Errors = append(report.Errors, models.ImportError{Line: line, Id: c.Id, Message: "missing fields in request payload"})
+			continue
+		}
+		if query.Get("ids") == "regenerate" || c.Id == "" {
+			c.Id = uuid.New().String()
+		}
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = time.Now().UTC()
+			c.UpdatedAt = c.CreatedAt
+		}
+		items = append(items, c)
+		lines = append(lines, line)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if onConflict == "fail" {
+		conflicts := 0
+		for i, c := range items {
+			if _, found := s.collections[coll][c.Id]; found {
+				conflicts++
+				report.Failed++
+				report.Errors = append(report.Errors, models.ImportError{Line: lines[i], Id: c.Id, Message: "content with this ID already exists"})
+			}
+		}
+		if conflicts > 0 {
+			utils.WriteJSON(w, http.StatusConflict, models.JsonResponse{
+				Error:   true,
+				Message: fmt.Sprintf("import aborted, %d items already exist", conflicts),
+				Data:    report,
+			})
+			return
+		}
+	}
+
+	for _, c := range items {
+		_, found := s.collections[coll][c.Id]
+		switch {
+		case found && onConflict == "skip":
+			report.Skipped++
+			continue
+		case found:
+			report.Replaced++
+		default:
+			report.Created++
+		}
+		if !report.DryRun {
+			s.put(coll, c)
+		}
+	}
+
+	ok(w, http.StatusOK, "Successfully imported content", report)
+}
+
+// handleBulk checks every item of a bulk request before writing any, so that
+// atomic requests are all or nothing without transactions.
+func (s *Server) handleBulk(w http.ResponseWriter, r *http.Request) {
+	coll := chi.URLParam(r, "collection")
+	atomic := r.URL.Query().Get("atomic") == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []models.BulkItemResult
+	var writes []func()
+	item := func(id string, status int, err error, write func()) {
+		result := models.BulkItemResult{Index: len(results), Id: id, Status: status}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			writes = append(writes, write)
+		}
+		results = append(results, result)
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var contents []models.Content
+		if err := utils.ReadJSON(w, r, &contents); err != nil {
+			utils.ErrorJSON(w, err)
+			return
+		}
+		now := time.Now().UTC()
+		for _, c := range contents {
+			c.Id = uuid.New().String()
+			c.CreatedAt, c.UpdatedAt = now, now
+			if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+				item(c.Id, http.StatusBadRequest, errors.New("missing fields in request payload"), nil)
+				continue
+			}
+			item(c.Id, http.StatusCreated, nil, func() { s.put(coll, c) })
+		}
+	case http.MethodPatch:
+		var updates []models.BulkUpdate
+		if err := utils.ReadJSON(w, r, &updates); err != nil {
+			utils.ErrorJSON(w, err)
+			return
+		}
+		for _, update := range updates {
+			c, found := s.collections[coll][update.Id]
+			if !found {
+				item(update.Id, http.StatusNotFound, errors.New("content not found"), nil)
+				continue
+			}
+			if update.Class != nil {
+				c.Class = *update.Class
+			}
+			if update.Title != nil {
+				c.Title = *update.Title
+			}
+			if update.Description != nil {
+				c.Description = *update.Description
+			}
+			if update.Body != nil {
+				c.Body = *update.Body
+			}
+			if update.Views != nil {
+				c.Views = *update.Views
+			}
+			if update.IsPublic != nil {
+				c.IsPublic = *update.IsPublic
+			}
+			if update.Fields != nil {
+				c.Fields = update.Fields
+			}
+			c.UpdatedAt = time.Now().UTC()
+			item(c.Id, http.StatusOK, nil, func() { s.put(coll, c) })
+		}
+	case http.MethodDelete:
+		var ids []string
+		if err := utils.ReadJSON(w, r, &ids); err != nil {
+			utils.ErrorJSON(w, err)
+			return
+		}
+		for _, id := range ids {
+			item(id, http.StatusOK, nil, func() {
+				if _, found := s.collections[coll][id]; found {
+					delete(s.collections[coll], id)
+					s.record(coll, id, true)
+				}
+			})
+		}
+	}
+
+	report := models.BulkReport{Atomic: atomic, Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			report.Failed++
+		}
+	}
+	if atomic && report.Failed > 0 {
+		for i := range report.Results {
+			if report.Results[i].Error == "" {
+				report.Results[i].Status = http.StatusFailedDependency
+				report.Results[i].Error = "not written because another item failed"
+			}
+		}
+		report.Failed = len(results)
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, models.JsonResponse{Error: true, Message: "bulk write aborted", Data: report})
+		return
+	}
+
+	for _, write := range writes {
+		write()
+	}
+	report.Succeeded = len(results) - report.Failed
+	ok(w, http.StatusOK, fmt.Sprintf("Successfully wrote %d of %d items", report.Succeeded, len(results)), report)
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// Catalog returns the collections the caller may read, including registered
+// ones without items, with the stats of each.
+func (c *Client) Catalog(ctx context.Context) ([]models.CollectionStats, error) {
+	catalog := []models.CollectionStats{}
+	err := c.call(ctx, http.MethodGet, "/contents", nil, nil, &catalog)
+	return catalog, err
+}
+
+// CollectionStats describes one collection, with a summary of each of its
+// classes.
+func (c *Client) CollectionStats(ctx context.Context, coll string) (*models.CollectionStats, error) {
+	var stats models.CollectionStats
+	if err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll)+"/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// CreateContent creates an item and returns its ID. The ID and timestamps of
+// content are ignored.
+func (c *Client) CreateContent(ctx context.Context, coll string, content *models.Content) (string, error) {
+	var id string
+	err := c.call(ctx, http.MethodPost, "/contents/"+escape(coll), nil, content, &id)
+	return id, err
+}
+
+func (c *Client) GetContent(ctx context.Context, coll string, id string) (*models.Content, error) {
+	var content models.Content
+	if err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll)+"/id/"+escape(id), nil, nil, &content); err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+func (c *Client) GetCollection(ctx context.Context, coll string) ([]models.Content, error) {
+	contents := []models.Content{}
+	err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll), nil, nil, &contents)
+	return contents, err
+}
+
+func (c *Client) GetClass(ctx context.Context, coll string, class string) ([]models.Content, error) {
+	contents := []models.Content{}
+	err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll)+"/class/"+escape(class), nil, nil, &contents)
+	return contents, err
+}
+
+// UpdateContent changes the fields that are set in update.
+func (c *Client) UpdateContent(ctx context.Context, coll string, id string, update *models.UpdateContent) error {
+	return c.call(ctx, http.MethodPut, "/contents/"+escape(coll)+"/id/"+escape(id), nil, update, nil)
+}
+
+// PatchOperation is one operation of a JSON Patch (RFC 6902). Value is sent
+// even when it is nil, which sets a field to null.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value"`
+}
+
+// MergePatchContent applies a JSON Merge Patch (RFC 7396) to an item and
+// returns the result. A nil value clears a field.
+func (c *Client) MergePatchContent(ctx context.Context, coll string, id string, patch map[string]any) (*models.Content, error) {
+	return c.patch(ctx, coll, id, "application/merge-patch+json", patch)
+}
+
+// PatchContent applies a JSON Patch to an item and returns the result. When
+// a test operation fails nothing is changed and the error has status 409.
+func (c *Client) PatchContent(ctx context.Context, coll string, id string, ops []PatchOperation) (*models.Content, error) {
+	return c.patch(ctx, coll, id, "application/json-patch+json", ops)
+}
+
+func (c *Client) patch(ctx context.Context, coll string, id string, contentType string, body any) (*models.Content, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+	var content models.Content
+	err = c.call(ctx, http.MethodPatch, "/contents/"+escape(coll)+"/id/"+escape(id), nil, upload{contentType: contentType, data: data}, &content)
+	if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+func (c *Client) DeleteContent(ctx context.Context, coll string, id string) error {
+	return c.call(ctx, http.MethodDelete, "/contents/"+escape(coll)+"/id/"+escape(id), nil, nil, nil)
+}
+
+func (c *Client) DeleteClass(ctx context.Context, coll string, class string) error {
+	return c.call(ctx, http.MethodDelete, "/contents/"+escape(coll)+"/class/"+escape(class), nil, nil, nil)
+}
+
+func (c *Client) DeleteCollection(ctx context.Context, coll string) error {
+	return c.call(ctx, http.MethodDelete, "/contents/"+escape(coll), nil, nil, nil)
+}
+
+// GetChanges returns one page of changes made to a collection since a sync
+// token, or the first page of a snapshot of the collection when since is
+// empty. A limit of zero uses the server default. See Changes to iterate over every page.
+func (c *Client) GetChanges(ctx context.Context, coll string, since string, limit int) (*models.SyncResponse, error) {
+	query := url.Values{}
+	if since != "" {
+		query.Set("since", since)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var res models.SyncResponse
+	if err := c.call(ctx, http.MethodGet, "/contents/"+escape(coll)+"/changes", query, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListCollections returns the names of every collection the caller may
+// read. It is answered by the GraphQL API.
+func (c *Client) ListCollections(ctx context.Context) ([]string, error) {
+	var data struct {
+		Collections []struct {
+			Name string `json:"name"`
+		} `json:"collections"`
+	}
+	if err := c.GraphQL(ctx, "{ collections { name } }", nil, &data); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(data.Collections))
+	for i, coll := range data.Collections {
+		names[i] = coll.Name
+	}
+	return names, nil
+}
+
+// ListClasses returns the classes used in a collection. It is answered by
+// the GraphQL API.
+func (c *Client) ListClasses(ctx context.Context, coll string) ([]string, error) {
+	var data struct {
+		Classes []string `json:"classes"`
+	}
+	query := "query ($collection: String!) { classes(collection: $collection) }"
+	if err := c.GraphQL(ctx, query, map[string]any{"collection": coll}, &data); err != nil {
+		return nil, err
+	}
+	return data.Classes, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// Error is an error response from the service, decoded from the JsonResponse
+// envelope. Match it against the Err values above with errors.Is.
+type Error struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is set on rate limited responses.
+	RetryAfter time.Duration
+	// Data is the data field of the response, which some errors carry, such
+	// as the report of an import that was aborted.
+	Data json.RawMessage
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cms: %s (status %d)", e.Message, e.StatusCode)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		// Missing items are reported with a 400 and a "... not found" message.
+		return e.StatusCode == http.StatusNotFound || strings.HasSuffix(e.Message, "not found")
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// GraphQLError holds the errors returned alongside a GraphQL result.
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return "cms: graphql: " + strings.Join(e.Messages, "; ")
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQL runs a query or mutation against /graphql and decodes its data into
+// out. Errors in the result are returned as a *GraphQLError, after out has
+// been filled with whatever data came back.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	res, err := c.send(ctx, http.MethodPost, "/graphql", nil, graphQLRequest{Query: query, Variables: variables}, "application/json")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var result graphQLResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if out != nil && len(result.Data) > 0 && string(result.Data) != "null" {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("decoding response data: %w", err)
+		}
+	}
+	if len(result.Errors) > 0 {
+		gqlErr := &GraphQLError{}
+		for _, e := range result.Errors {
+			gqlErr.Messages = append(gqlErr.Messages, e.Message)
+		}
+		return gqlErr
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package client
+
+import "context"
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context that sends key as the Idempotency-Key
+// of the requests made with it. The service runs a create, bulk write or
+// import once per key, so requests with a key are retried like idempotent
+// ones. Use a new key, such as a UUID, for each write, and the same key when
+// the write itself is repeated.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
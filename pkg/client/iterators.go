@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// ChangeIterator walks every change to a collection since a sync token,
+// fetching pages as it goes:
+//
+//	it := c.Changes(ctx, "courses", token, 0)
+//	for it.Next() {
+//		apply(it.Change())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//	token = it.Token()
+type ChangeIterator struct {
+	client *Client
+	ctx    context.Context
+	coll   string
+	token  string
+	prev   string
+	limit  int
+
+	page    []models.SyncChange
+	i       int
+	fetched bool
+	hasMore bool
+	err     error
+}
+
+// Changes returns an iterator over the changes since the sync token since,
+// or over a snapshot of the collection when since is empty. limit sets the
+// page size, zero uses the server default.
+func (c *Client) Changes(ctx context.Context, coll string, since string, limit int) *ChangeIterator {
+	return &ChangeIterator{client: c, ctx: ctx, coll: coll, token: since, limit: limit}
+}
+
+func (it *ChangeIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.i+1 < len(it.page) {
+		it.i++
+		return true
+	}
+	if it.fetched && !it.hasMore {
+		return false
+	}
+
+	for {
+		res, err := it.client.GetChanges(it.ctx, it.coll, it.token, it.limit)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.fetched = true
+		it.page, it.i = res.Changes, 0
+		it.prev, it.token, it.hasMore = it.token, res.NextToken, res.HasMore
+		if len(it.page) > 0 {
+			return true
+		}
+		if !it.hasMore {
+			return false
+		}
+	}
+}
+
+func (it *ChangeIterator) Change() models.SyncChange {
+	return it.page[it.i]
+}
+
+// Token returns the sync token to resume from once the changes returned so
+// far have been applied. It advances a page at a time, so resuming after
+// stopping part way through a page sees the rest of that page again.
+func (it *ChangeIterator) Token() string {
+	if it.i+1 < len(it.page) {
+		return it.prev
+	}
+	return it.token
+}
+
+func (it *ChangeIterator) Err() error {
+	return it.err
+}
+
+// AuditIterator walks every audit entry matching a filter, newest first.
+type AuditIterator struct {
+	client   *Client
+	ctx      context.Context
+	filter   *AuditFilter
+	pageSize int
+
+	page   []models.AuditEntry
+	i      int
+	offset int
+	done   bool
+	err    error
+}
+
+// Audit returns an iterator over the audit log. pageSize sets how many
+// entries are fetched at a time, zero uses the server default.
+func (c *Client) Audit(ctx context.Context, filter *AuditFilter, pageSize int) *AuditIterator {
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	return &AuditIterator{client: c, ctx: ctx, filter: filter, pageSize: pageSize, i: -1}
+}
+
+func (it *AuditIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.i+1 < len(it.page) {
+		it.i++
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	entries, err := it.client.ListAudit(it.ctx, it.filter, it.pageSize, it.offset)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.i = entries, 0
+	it.offset += len(entries)
+	it.done = len(entries) < it.pageSize
+	return len(entries) > 0
+}
+
+func (it *AuditIterator) Entry() models.AuditEntry {
+	return it.page[it.i]
+}
+
+func (it *AuditIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/YanSystems/cms/pkg/events"
+)
+
+// Event is one server-sent event from a collection's change feed. Name is
+// the SSE event name: an event type such as "content.updated", or "reset"
+// when the server could not resume from the requested event and the client
+// should reload the collection.
+type Event struct {
+	Name string
+	Data events.StreamEvent
+}
+
+// EventStream reads a collection's change feed. It does not reconnect; to
+// resume after an error, open a new stream with the ID of the last event.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	event   Event
+	lastId  string
+	err     error
+}
+
+// StreamEvents opens the change feed of a collection, optionally limited to
+// one class and resuming after lastEventId.
+func (c *Client) StreamEvents(ctx context.Context, coll string, class string, lastEventId string) (*EventStream, error) {
+	query := url.Values{}
+	if class != "" {
+		query.Set("class", class)
+	}
+	if lastEventId != "" {
+		query.Set("last_event_id", lastEventId)
+	}
+
+	res, err := c.send(ctx, http.MethodGet, "/contents/"+escape(coll)+"/events", query, nil, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{body: res.Body, scanner: bufio.NewScanner(res.Body), lastId: lastEventId}, nil
+}
+
+// Next blocks until the next event arrives, and returns false when the
+// stream ends or fails.
+func (s *EventStream) Next() bool {
+	var name, id string
+	var data strings.Builder
+
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if line == "" {
+			if name == "" && data.Len() == 0 {
+				continue
+			}
+			s.event = Event{Name: name}
+			if name != "reset" {
+				if err := json.Unmarshal([]byte(data.String()), &s.event.Data); err != nil {
+					s.err = fmt.Errorf("decoding event: %w", err)
+					return false
+				}
+			}
+			if id != "" {
+				s.lastId = id
+			}
+			return true
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			name = value
+		case "id":
+			id = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+
+	s.err = s.scanner.Err()
+	return false
+}
+
+func (s *EventStream) Event() Event {
+	return s.event
+}
+
+// LastEventId returns the ID to pass to StreamEvents to resume the feed.
+func (s *EventStream) LastEventId() string {
+	return s.lastId
+}
+
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
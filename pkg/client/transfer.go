@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// ExportCollection streams every item of a collection, or of one class when
+// class is set, as NDJSON, gzipped if compress is true. The caller must close
+// the returned reader.
+func (c *Client) ExportCollection(ctx context.Context, coll string, class string, compress bool) (io.ReadCloser, error) {
+	query := url.Values{}
+	if class != "" {
+		query.Set("class", class)
+	}
+	if compress {
+		query.Set("gzip", "true")
+	}
+
+	res, err := c.send(ctx, http.MethodGet, "/contents/"+escape(coll)+"/export", query, nil, "*/*")
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// ImportOptions control an import. The zero value preserves ids and fails
+// on conflicts, as the service does by default.
+type ImportOptions struct {
+	// Ids is "preserve" or "regenerate".
+	Ids string
+	// OnConflict is "skip", "overwrite" or "fail".
+	OnConflict string
+	DryRun     bool
+}
+
+// ImportCollection sends an NDJSON export, plain or gzipped, to a
+// collection. When the import is aborted on conflicts the report is returned
+// alongside the error, so the conflicting lines can be shown.
+func (c *Client) ImportCollection(ctx context.Context, coll string, body io.Reader, opts ImportOptions) (*models.ImportReport, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading import: %w", err)
+	}
+
+	query := url.Values{}
+	if opts.Ids != "" {
+		query.Set("ids", opts.Ids)
+	}
+	if opts.OnConflict != "" {
+		query.Set("on_conflict", opts.OnConflict)
+	}
+	if opts.DryRun {
+		query.Set("dry_run", strconv.FormatBool(opts.DryRun))
+	}
+
+	var report models.ImportReport
+	err = c.call(ctx, http.MethodPost, "/contents/"+escape(coll)+"/import", query,
+		upload{contentType: "application/x-ndjson", data: data}, &report)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict && apiErr.Data != nil {
+		if json.Unmarshal(apiErr.Data, &report) == nil {
+			return &report, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,203 @@
+// Package codec encodes responses in the media type a client asks for in its
+// Accept header, and decodes request bodies by their Content-Type.
+//
+// Every format is derived from the JSON encoding of a value, so field names,
+// omitted fields and timestamps are the same whichever format is used.
+package codec
+
+import (
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNotRepresentable is returned by encoders that cannot represent a value,
+// such as CSV for anything but a listing. Negotiation moves on to the next
+// acceptable encoder.
+var ErrNotRepresentable = errors.New("value cannot be represented in this format")
+
+type Encoder struct {
+	// MediaTypes are the types the encoder is selected by. The first one is
+	// sent as the Content-Type.
+	MediaTypes []string
+	Encode     func(v any) ([]byte, error)
+}
+
+// ContentType is the Content-Type header to send with what the encoder
+// writes.
+func (e *Encoder) ContentType() string {
+	if strings.HasPrefix(e.MediaTypes[0], "text/") {
+		return e.MediaTypes[0] + "; charset=utf-8"
+	}
+	return e.MediaTypes[0]
+}
+
+type Decoder struct {
+	MediaTypes []string
+	Decode     func(data []byte, v any) error
+}
+
+var (
+	mu       sync.RWMutex
+	encoders []*Encoder
+	decoders []*Decoder
+)
+
+// RegisterEncoder adds an encoder. Among media types the client accepts
+// equally, encoders registered first are preferred.
+func RegisterEncoder(e Encoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	encoders = append(encoders, &e)
+}
+
+func RegisterDecoder(d Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	decoders = append(decoders, &d)
+}
+
+func init() {
+	RegisterEncoder(Encoder{MediaTypes: []string{"application/json"}, Encode: encodeJSON})
+	RegisterEncoder(Encoder{MediaTypes: []string{"application/xml", "text/xml"}, Encode: encodeXML})
+	RegisterEncoder(Encoder{MediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"}, Encode: encodeYAML})
+	RegisterEncoder(Encoder{MediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}, Encode: encodeMsgpack})
+	RegisterEncoder(Encoder{MediaTypes: []string{"text/csv"}, Encode: encodeCSV})
+
+	RegisterDecoder(Decoder{MediaTypes: []string{"application/json"}, Decode: decodeJSON})
+	RegisterDecoder(Decoder{MediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"}, Decode: decodeYAML})
+	RegisterDecoder(Decoder{MediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}, Decode: decodeMsgpack})
+}
+
+// JSON is the encoder used when the client does not say what it accepts.
+func JSON() *Encoder {
+	mu.RLock()
+	defer mu.RUnlock()
+	return encoders[0]
+}
+
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q value of the most specific range that matches any
+// of mediaTypes, so that "application/msgpack;q=0" rules out the aliases of
+// MessagePack that "application/*" would otherwise match.
+func quality(ranges []mediaRange, mediaTypes []string) float64 {
+	best, specificity := 0.0, -1
+	for _, mediaType := range mediaTypes {
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		for _, r := range ranges {
+			s := -1
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			}
+			if s > specificity || (s == specificity && s >= 0 && r.q > best) {
+				best, specificity = r.q, s
+			}
+		}
+	}
+	return best
+}
+
+// Negotiate returns the encoders the Accept header allows, most preferred
+// first. An empty header accepts JSON.
+func Negotiate(accept string) []*Encoder {
+	mu.RLock()
+	defer mu.RUnlock()
+	if strings.TrimSpace(accept) == "" {
+		return []*Encoder{encoders[0]}
+	}
+
+	ranges := parseAccept(accept)
+	type candidate struct {
+		encoder *Encoder
+		q       float64
+	}
+	candidates := []candidate{}
+	for _, e := range encoders {
+		if q := quality(ranges, e.MediaTypes); q > 0 {
+			candidates = append(candidates, candidate{e, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	acceptable := make([]*Encoder, len(candidates))
+	for i, c := range candidates {
+		acceptable[i] = c.encoder
+	}
+	return acceptable
+}
+
+// DecoderFor returns the decoder for a Content-Type header. Requests without
+// one are decoded as JSON.
+func DecoderFor(contentType string) (*Decoder, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if contentType == "" {
+		return decoders[0], true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	for _, d := range decoders {
+		for _, t := range d.MediaTypes {
+			if t == mediaType {
+				return d, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// EncoderTypes and DecoderTypes list the supported media types, for error
+// messages.
+func EncoderTypes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	types := []string{}
+	for _, e := range encoders {
+		types = append(types, e.MediaTypes[0])
+	}
+	return types
+}
+
+func DecoderTypes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	types := []string{}
+	for _, d := range decoders {
+		types = append(types, d.MediaTypes[0])
+	}
+	return types
+}
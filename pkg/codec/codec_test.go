@@ -0,0 +1,140 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTime = time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+var listing = models.JsonResponse{
+	Message: "Successfully retrieved class",
+	Data: []models.Content{
+		{Id: "a", Class: "lessons", Title: "=1+1", Body: "x < y", Views: 3, UpdatedAt: testTime, CreatedAt: testTime},
+		{Id: "b", Class: "lessons", Title: "true", IsPublic: true, UpdatedAt: testTime, CreatedAt: testTime},
+	},
+}
+
+func TestNegotiate(t *testing.T) {
+	types := func(accept string) []string {
+		result := []string{}
+		for _, e := range Negotiate(accept) {
+			result = append(result, e.MediaTypes[0])
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"application/json"}, types(""))
+	assert.Equal(t, []string{"application/json", "application/xml", "application/yaml", "application/msgpack", "text/csv"}, types("*/*"))
+	assert.Equal(t, []string{"application/msgpack"}, types("application/x-msgpack"))
+	assert.Equal(t, []string{"text/csv", "application/json"}, types("text/csv, application/json;q=0.5"))
+	assert.Equal(t, []string{"application/xml", "application/yaml", "text/csv"}, types("text/*;q=0.8, application/json;q=0, application/msgpack;q=0, application/*;q=0.5, application/xml"))
+	assert.Empty(t, types("image/png"))
+}
+
+func TestDecoderFor(t *testing.T) {
+	d, ok := DecoderFor("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, "application/json", d.MediaTypes[0])
+
+	d, ok = DecoderFor("")
+	assert.True(t, ok)
+	assert.Equal(t, "application/json", d.MediaTypes[0])
+
+	_, ok = DecoderFor("application/xml")
+	assert.False(t, ok)
+}
+
+func TestEncodeXML(t *testing.T) {
+	out, err := encodeXML(models.JsonResponse{Data: map[string]any{"my courses": nil, "xmlns": 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><error>false</error><message></message><data><entry key="my courses"/><entry key="xmlns">1</entry></data></response>`+"\n", string(out))
+
+	out, err = encodeXML(listing)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `<data><item><id>a</id><class>lessons</class><title>=1+1</title><body>x &lt; y</body><views>3</views>`)
+}
+
+func TestEncodeYAML(t *testing.T) {
+	out, err := encodeYAML(listing)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "error: false\nmessage: Successfully retrieved class\ndata:\n  - id: a\n    class: lessons\n")
+	assert.Contains(t, string(out), `title: "true"`)
+	assert.Contains(t, string(out), "views: 3\n")
+	assert.Contains(t, string(out), "updated_at: \"2024-06-10T12:00:00Z\"\n")
+}
+
+func TestEncodeCSV(t *testing.T) {
+	out, err := encodeCSV(listing)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,class,title,body,views,updated_at,created_at,is_public\n"+
+		"a,lessons,'=1+1,x < y,3,2024-06-10T12:00:00Z,2024-06-10T12:00:00Z,\n"+
+		"b,lessons,true,,,2024-06-10T12:00:00Z,2024-06-10T12:00:00Z,true\n", string(out))
+
+	out, err = encodeCSV([]map[string]any{{"id": "a", "tags": []string{"x"}}, {"id": "b", "extra": map[string]int{"n": 1}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,tags,extra\na,\"[\"\"x\"\"]\",\nb,,\"{\"\"n\"\":1}\"\n", string(out))
+
+	_, err = encodeCSV(models.JsonResponse{Data: listing.Data.([]models.Content)[0]})
+	assert.ErrorIs(t, err, ErrNotRepresentable)
+	_, err = encodeCSV(models.JsonResponse{Error: true, Message: "content not found"})
+	assert.ErrorIs(t, err, ErrNotRepresentable)
+}
+
+func TestMsgpack(t *testing.T) {
+	t.Run("Encoding", func(t *testing.T) {
+		out, err := encodeMsgpack(map[string]any{"a": []any{nil, true, 1, -1, 200, -200, 70000, 1.5, "hi"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{
+			0x81, 0xa1, 'a', 0x99,
+			0xc0, 0xc3, 0x01, 0xff,
+			0xd1, 0x00, 0xc8,
+			0xd1, 0xff, 0x38,
+			0xd2, 0x00, 0x01, 0x11, 0x70,
+			0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+			0xa2, 'h', 'i',
+		}, out)
+	})
+
+	t.Run("Round Trip", func(t *testing.T) {
+		content := listing.Data.([]models.Content)[0]
+		out, err := encodeMsgpack(content)
+		assert.NoError(t, err)
+
+		var decoded models.Content
+		assert.NoError(t, decodeMsgpack(out, &decoded))
+		assert.Equal(t, content, decoded)
+	})
+
+	t.Run("Timestamp Extension", func(t *testing.T) {
+		data := []byte{0x81, 0xaa}
+		data = append(data, "updated_at"...)
+		data = append(data, 0xd6, 0xff, 0x66, 0x66, 0xea, 0xc0)
+
+		var decoded models.Content
+		assert.NoError(t, decodeMsgpack(data, &decoded))
+		assert.Equal(t, testTime, decoded.UpdatedAt)
+	})
+
+	t.Run("Invalid Data", func(t *testing.T) {
+		var decoded map[string]any
+		assert.Error(t, decodeMsgpack([]byte{0x81, 0xa1}, &decoded))
+		assert.Error(t, decodeMsgpack([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, &decoded))
+		assert.Error(t, decodeMsgpack([]byte{0x81, 0x01, 0x01}, &decoded))
+		assert.Error(t, decodeMsgpack([]byte{0x80, 0x80}, &decoded))
+	})
+}
+
+func TestDecodeYAML(t *testing.T) {
+	var c models.Content
+	err := decodeYAML([]byte("class: lessons\ntitle: Intro\nviews: 2\nis_public: true\n"), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Content{Class: "lessons", Title: "Intro", Views: 2, IsPublic: true}, c)
+
+	assert.Error(t, decodeYAML([]byte("a: 1\n---\nb: 2\n"), &c))
+	assert.Error(t, decodeYAML([]byte(""), &c))
+}
@@ -0,0 +1,126 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// encodeCSV writes a listing as one row per item, with a header row of every
+// field found in the items. Only the data of a response is written, since
+// the message does not fit the rows. Nested values are written as JSON.
+func encodeCSV(v any) ([]byte, error) {
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := tree.(object); ok {
+		for _, f := range obj {
+			if f.key == "data" {
+				tree = f.value
+			}
+		}
+	}
+
+	items, ok := tree.([]any)
+	if !ok {
+		return nil, ErrNotRepresentable
+	}
+
+	columns := []string{}
+	index := map[string]int{}
+	for _, item := range items {
+		obj, ok := item.(object)
+		if !ok {
+			return nil, ErrNotRepresentable
+		}
+		for _, f := range obj {
+			if _, ok := index[f.key]; !ok {
+				index[f.key] = len(columns)
+				columns = append(columns, f.key)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if len(columns) > 0 {
+		w.Write(columns)
+	}
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for _, f := range item.(object) {
+			cell, err := csvCell(f.value)
+			if err != nil {
+				return nil, err
+			}
+			row[index[f.key]] = cell
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func csvCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case object, []any:
+		data, err := json.Marshal(fromTree(v))
+		return string(data), err
+	case string:
+		// Spreadsheets run cells that start like a formula, so such text is
+		// quoted the way they expect.
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v, nil
+		}
+		return v, nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// fromTree turns a tree back into values that encoding/json writes in the
+// same order.
+func fromTree(tree any) any {
+	switch v := tree.(type) {
+	case object:
+		return orderedJSON(v)
+	case []any:
+		arr := make([]any, len(v))
+		for i, item := range v {
+			arr[i] = fromTree(item)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+type orderedJSON object
+
+func (o orderedJSON) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(fromTree(f.value))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
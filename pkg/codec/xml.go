@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// xmlName matches keys that can be used as element names as they are. Other
+// keys, such as collection names with spaces, are written as
+// <entry key="...">.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// encodeXML writes v under a <response> element. Object keys become child
+// elements, array items become <item> elements and null becomes an empty
+// element.
+func encodeXML(v any) ([]byte, error) {
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := writeXML(&buf, "response", tree); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeXML(buf *bytes.Buffer, name string, tree any) error {
+	start := name
+	if !xmlName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		var key bytes.Buffer
+		if err := xml.EscapeText(&key, []byte(name)); err != nil {
+			return err
+		}
+		name = "entry"
+		start = fmt.Sprintf(`entry key="%s"`, key.String())
+	}
+
+	switch v := tree.(type) {
+	case nil:
+		fmt.Fprintf(buf, "<%s/>", start)
+		return nil
+	case object:
+		fmt.Fprintf(buf, "<%s>", start)
+		for _, f := range v {
+			if err := writeXML(buf, f.key, f.value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		fmt.Fprintf(buf, "<%s>", start)
+		for _, item := range v {
+			if err := writeXML(buf, "item", item); err != nil {
+				return err
+			}
+		}
+	default:
+		fmt.Fprintf(buf, "<%s>", start)
+		if err := xml.EscapeText(buf, []byte(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(buf, "</%s>", name)
+	return nil
+}
@@ -0,0 +1,32 @@
+// Package docs serves the OpenAPI document for the REST API and a page that
+// renders it. The document is maintained by hand next to this file, and the
+// server tests check it against the registered routes.
+package docs
+
+import (
+	_ "embed"
+	"log/slog"
+	"net/http"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed index.html
+var page []byte
+
+func HandleSpec(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleSpec called")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(Spec)
+}
+
+func HandleDocs(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleDocs called")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
@@ -0,0 +1,172 @@
+package events
+
+import (
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+type StreamEvent struct {
+	Id            string          `json:"id"`
+	Type          string          `json:"type"`
+	Collection    string          `json:"collection"`
+	ContentId     string          `json:"content_id,omitempty"`
+	Class         string          `json:"class,omitempty"`
+	PreviousClass string          `json:"previous_class,omitempty"`
+	Ids           []string        `json:"ids,omitempty"`
+	Content       *models.Content `json:"content,omitempty"`
+	Time          time.Time       `json:"timestamp"`
+}
+
+// matches reports whether subscribers to a collection, or to one of its
+// classes, should get the event. Deletes seen by the change stream have no
+// class when the collection does not record pre-images, so they go to every
+// class, as the deleted item may have been in any of them.
+func (e *StreamEvent) matches(coll string, class string) bool {
+	if e.Collection != coll {
+		return false
+	}
+	if class == "" || e.Type == models.EventCollectionDeleted {
+		return true
+	}
+	if e.Type == models.EventContentDeleted && e.Class == "" {
+		return true
+	}
+	return e.Class == class || e.PreviousClass == class
+}
+
+type Subscription struct {
+	C          <-chan StreamEvent
+	c          chan StreamEvent
+	collection string
+	class      string
+	broker     *Broker
+}
+
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// Broker fans stream events out to subscribers and keeps the most recent ones
+// in a ring buffer, so that clients reconnecting with the ID of the last event
+// they saw can catch up on what they missed.
+type Broker struct {
+	mu          sync.Mutex
+	buffer      []StreamEvent
+	next        int
+	full        bool
+	seq         uint64
+	subscribers map[*Subscription]struct{}
+}
+
+const subscriberBuffer = 64
+
+func NewBroker(size int) *Broker {
+	return &Broker{
+		buffer:      make([]StreamEvent, size),
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Handle turns bus events into stream events with broker-assigned IDs.
+func (b *Broker) Handle(e Event) {
+	se := StreamEvent{
+		Type:       e.Type,
+		Collection: e.Collection,
+		ContentId:  e.ContentId,
+		Class:      e.Class,
+		Ids:        e.Ids,
+		Content:    e.After,
+		Time:       e.Time,
+	}
+	if e.PreviousClass != "" {
+		se.PreviousClass = e.PreviousClass
+	} else if e.Before != nil && e.Before.Class != e.Class {
+		se.PreviousClass = e.Before.Class
+	}
+
+	b.mu.Lock()
+	b.seq++
+	se.Id = strconv.FormatUint(b.seq, 10)
+	b.mu.Unlock()
+
+	b.Publish(se)
+}
+
+func (b *Broker) Publish(se StreamEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buffer[b.next] = se
+	b.next = (b.next + 1) % len(b.buffer)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for sub := range b.subscribers {
+		if !se.matches(sub.collection, sub.class) {
+			continue
+		}
+		select {
+		case sub.c <- se:
+		default:
+			// A subscriber that can't keep up is cut off. It can reconnect
+			// with its last event ID and replay from the buffer.
+			slog.Warn("Stream subscriber too slow, disconnecting", "collection", sub.collection)
+			delete(b.subscribers, sub)
+			close(sub.c)
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the buffered events after
+// lastEventID. The returned bool is false when lastEventID was given but is
+// no longer in the buffer, meaning the client may have missed events.
+func (b *Broker) Subscribe(coll string, class string, lastEventID string) (*Subscription, []StreamEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan StreamEvent, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, collection: coll, class: class, broker: b}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	buffered := b.buffered()
+	for i, se := range buffered {
+		if se.Id != lastEventID {
+			continue
+		}
+		var backlog []StreamEvent
+		for _, missed := range buffered[i+1:] {
+			if missed.matches(coll, class) {
+				backlog = append(backlog, missed)
+			}
+		}
+		return sub, backlog, true
+	}
+
+	return sub, nil, false
+}
+
+func (b *Broker) buffered() []StreamEvent {
+	if !b.full {
+		return b.buffer[:b.next]
+	}
+	return append(append([]StreamEvent{}, b.buffer[b.next:]...), b.buffer[:b.next]...)
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.c)
+	}
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("Delivers Matching Events", func(t *testing.T) {
+		broker := NewBroker(10)
+		lessons, _, _ := broker.Subscribe("courses", "lessons", "")
+		defer lessons.Close()
+		all, _, _ := broker.Subscribe("courses", "", "")
+		defer all.Close()
+
+		broker.Handle(Event{Type: models.EventContentCreated, Collection: "courses", Class: "quizzes", ContentId: "q"})
+		broker.Handle(Event{Type: models.EventContentCreated, Collection: "courses", Class: "lessons", ContentId: "l"})
+		broker.Handle(Event{Type: models.EventContentCreated, Collection: "other", Class: "lessons", ContentId: "o"})
+
+		assert.Equal(t, "l", (<-lessons.C).ContentId)
+		assert.Len(t, lessons.C, 0)
+		assert.Equal(t, "q", (<-all.C).ContentId)
+		assert.Equal(t, "l", (<-all.C).ContentId)
+		assert.Len(t, all.C, 0)
+	})
+
+	t.Run("Class Changes Reach Both Classes", func(t *testing.T) {
+		broker := NewBroker(10)
+		quizzes, _, _ := broker.Subscribe("courses", "quizzes", "")
+		defer quizzes.Close()
+
+		broker.Handle(Event{
+			Type:       models.EventContentUpdated,
+			Collection: "courses",
+			Class:      "lessons",
+			Before:     &models.Content{Class: "quizzes"},
+			After:      &models.Content{Class: "lessons"},
+		})
+
+		se := <-quizzes.C
+		assert.Equal(t, "quizzes", se.PreviousClass)
+	})
+
+	t.Run("Collection Drops Reach Class Subscribers", func(t *testing.T) {
+		broker := NewBroker(10)
+		lessons, _, _ := broker.Subscribe("courses", "lessons", "")
+		defer lessons.Close()
+
+		broker.Handle(Event{Type: models.EventCollectionDeleted, Collection: "courses"})
+		assert.Equal(t, models.EventCollectionDeleted, (<-lessons.C).Type)
+	})
+
+	t.Run("Class-less Deletes Reach Class Subscribers", func(t *testing.T) {
+		broker := NewBroker(10)
+		lessons, _, _ := broker.Subscribe("courses", "lessons", "")
+		defer lessons.Close()
+
+		broker.Publish(StreamEvent{Type: models.EventContentDeleted, Collection: "courses", ContentId: "a"})
+		broker.Publish(StreamEvent{Type: models.EventContentDeleted, Collection: "courses", ContentId: "q", Class: "quizzes"})
+		assert.Equal(t, "a", (<-lessons.C).ContentId)
+		assert.Len(t, lessons.C, 0)
+	})
+
+	t.Run("Resumes From Last Event", func(t *testing.T) {
+		broker := NewBroker(3)
+		for _, id := range []string{"a", "b", "c", "d"} {
+			broker.Handle(Event{Type: models.EventContentCreated, Collection: "courses", ContentId: id})
+		}
+
+		sub, backlog, resumed := broker.Subscribe("courses", "", "2")
+		defer sub.Close()
+		assert.True(t, resumed)
+		assert.Len(t, backlog, 2)
+		assert.Equal(t, "c", backlog[0].ContentId)
+		assert.Equal(t, "d", backlog[1].ContentId)
+	})
+
+	t.Run("Reports Events Outside Buffer", func(t *testing.T) {
+		broker := NewBroker(2)
+		for _, id := range []string{"a", "b", "c"} {
+			broker.Handle(Event{Type: models.EventContentCreated, Collection: "courses", ContentId: id})
+		}
+
+		sub, backlog, resumed := broker.Subscribe("courses", "", "1")
+		defer sub.Close()
+		assert.False(t, resumed)
+		assert.Empty(t, backlog)
+	})
+
+	t.Run("Disconnects Slow Subscribers", func(t *testing.T) {
+		broker := NewBroker(10)
+		sub, _, _ := broker.Subscribe("courses", "", "")
+		for i := 0; i <= subscriberBuffer; i++ {
+			broker.Handle(Event{Type: models.EventContentCreated, Collection: "courses"})
+		}
+
+		count := 0
+		for range sub.C {
+			count++
+		}
+		assert.Equal(t, subscriberBuffer, count)
+		sub.Close()
+	})
+}
+
+func TestToStreamEvent(t *testing.T) {
+	insert := &changeEvent{OperationType: "insert", FullDocument: &models.Content{Id: "a", Class: "lessons"}}
+	insert.Id.Data = "token"
+	insert.Namespace.Collection = "courses"
+
+	se, ok := toStreamEvent(insert)
+	assert.True(t, ok)
+	assert.Equal(t, "token", se.Id)
+	assert.Equal(t, models.EventContentCreated, se.Type)
+	assert.Equal(t, "a", se.ContentId)
+	assert.Equal(t, "lessons", se.Class)
+
+	remove := &changeEvent{OperationType: "delete", FullDocumentBeforeChange: &models.Content{Id: "a", Class: "lessons"}}
+	se, ok = toStreamEvent(remove)
+	assert.True(t, ok)
+	assert.Equal(t, models.EventContentDeleted, se.Type)
+	assert.Equal(t, "a", se.ContentId)
+	assert.Nil(t, se.Content)
+
+	rename := &changeEvent{OperationType: "rename"}
+	rename.Namespace = namespace{DB: "content", Collection: "_restore.courses"}
+	rename.To = namespace{DB: "content", Collection: "courses"}
+	se, ok = toStreamEvent(rename)
+	assert.True(t, ok)
+	assert.Equal(t, models.EventCollectionDeleted, se.Type)
+	assert.Equal(t, "courses", se.Collection)
+
+	_, ok = toStreamEvent(&changeEvent{OperationType: "invalidate"})
+	assert.False(t, ok)
+}
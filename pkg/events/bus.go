@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+type Event struct {
+	Id         string
+	Type       string
+	Collection string
+	ContentId  string
+	Class      string
+	// PreviousClass is the class items were moved from, for class.moved.
+	PreviousClass string
+	Ids           []string
+	Before        *models.Content
+	After         *models.Content
+	Principal     string
+	RequestId     string
+	SourceIP      string
+	Time          time.Time
+}
+
+type Handler func(Event)
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish runs every handler in turn before returning. Handlers that do slow
+// work, such as network calls, should hand the event off to a goroutine.
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	slog.Debug("Publishing event", "id", e.Id, "type", e.Type, "collection", e.Collection, "handlers", len(handlers))
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+// PublishFrom stamps e with an id, the time and the caller details of r
+// before publishing it. It is a no-op on a nil bus.
+func (b *Bus) PublishFrom(r *http.Request, e Event) {
+	b.PublishContext(r.Context(), utils.ClientIP(r), e)
+}
+
+// PublishContext is PublishFrom for callers that are not serving an HTTP
+// request. The principal and request ID are read from ctx.
+func (b *Bus) PublishContext(ctx context.Context, sourceIP string, e Event) {
+	if b == nil {
+		slog.Debug("Event bus not configured, skipping event", "type", e.Type)
+		return
+	}
+
+	e.Id = uuid.New().String()
+	e.Principal = auth.FromContext(ctx).String()
+	e.RequestId = middleware.GetReqID(ctx)
+	e.SourceIP = sourceIP
+	e.Time = time.Now().UTC()
+
+	b.Publish(e)
+}
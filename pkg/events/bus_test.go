@@ -0,0 +1,22 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+
+	var first, second []string
+	bus.Subscribe(func(e Event) { first = append(first, e.Type) })
+	bus.Subscribe(func(e Event) { second = append(second, e.ContentId) })
+
+	bus.Publish(Event{Type: models.EventContentCreated, ContentId: "a"})
+	bus.Publish(Event{Type: models.EventContentDeleted, ContentId: "b"})
+
+	assert.Equal(t, []string{models.EventContentCreated, models.EventContentDeleted}, first)
+	assert.Equal(t, []string{"a", "b"}, second)
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type namespace struct {
+	DB         string `bson:"db"`
+	Collection string `bson:"coll"`
+}
+
+type changeEvent struct {
+	Id struct {
+		Data string `bson:"_data"`
+	} `bson:"_id"`
+	OperationType            string          `bson:"operationType"`
+	Namespace                namespace       `bson:"ns"`
+	To                       namespace       `bson:"to"`
+	ClusterTime              time.Time       `bson:"wallTime"`
+	FullDocument             *models.Content `bson:"fullDocument"`
+	FullDocumentBeforeChange *models.Content `bson:"fullDocumentBeforeChange"`
+}
+
+// ChangeStreamWatcher feeds the broker from a MongoDB change stream on the
+// content database, so that writes made through other replicas or directly
+// against the database show up too. Event IDs are the change stream resume
+// tokens, which every replica sees identically.
+type ChangeStreamWatcher struct {
+	DB     *mongo.Database
+	Broker *Broker
+	// Observers are given every event as well, such as to invalidate caches
+	// after writes made through other replicas.
+	Observers []func(StreamEvent)
+}
+
+var changeStreamPipeline = mongo.Pipeline{
+	{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete", "drop", "rename"}}}}}}},
+}
+
+func (w *ChangeStreamWatcher) open(ctx context.Context, resumeToken bson.Raw) (*mongo.ChangeStream, error) {
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+	return w.DB.Watch(ctx, changeStreamPipeline, opts)
+}
+
+// Start opens the change stream and returns an error if the deployment does
+// not support change streams, such as a standalone server.
+func (w *ChangeStreamWatcher) Start(ctx context.Context) error {
+	stream, err := w.open(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Change stream opened", "db", w.DB.Name())
+	go w.run(ctx, stream)
+	return nil
+}
+
+func (w *ChangeStreamWatcher) run(ctx context.Context, stream *mongo.ChangeStream) {
+	backoff := time.Second
+	for {
+		for stream.Next(ctx) {
+			backoff = time.Second
+			var change changeEvent
+			if err := stream.Decode(&change); err != nil {
+				slog.Error("Failed to decode change event", "error", err)
+				continue
+			}
+			if se, ok := toStreamEvent(&change); ok {
+				for _, observe := range w.Observers {
+					observe(se)
+				}
+				w.Broker.Publish(se)
+			}
+		}
+
+		resumeToken := stream.ResumeToken()
+		err := stream.Err()
+		stream.Close(context.TODO())
+		if ctx.Err() != nil {
+			slog.Info("Change stream closed")
+			return
+		}
+
+		for {
+			slog.Error("Change stream interrupted, reopening", "error", err, "backoff", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, 30*time.Second)
+
+			stream, err = w.open(ctx, resumeToken)
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+func toStreamEvent(change *changeEvent) (StreamEvent, bool) {
+	se := StreamEvent{
+		Id:         change.Id.Data,
+		Collection: change.Namespace.Collection,
+		Time:       change.ClusterTime,
+	}
+
+	switch change.OperationType {
+	case "insert":
+		se.Type = models.EventContentCreated
+	case "update", "replace":
+		se.Type = models.EventContentUpdated
+	case "delete":
+		se.Type = models.EventContentDeleted
+	case "drop":
+		se.Type = models.EventCollectionDeleted
+		return se, true
+	case "rename":
+		// Restoring a collection renames a copy over it, which replaces
+		// every item at once. Subscribers are told the target was deleted,
+		// so that they drop what they hold of it and read it again. A
+		// collection renamed into another database is gone from this one.
+		se.Type = models.EventCollectionDeleted
+		if change.To.DB == change.Namespace.DB {
+			se.Collection = change.To.Collection
+		}
+		return se, true
+	default:
+		return se, false
+	}
+
+	if change.FullDocument != nil {
+		se.Content = change.FullDocument
+		se.ContentId = change.FullDocument.Id
+		se.Class = change.FullDocument.Class
+	}
+	if before := change.FullDocumentBeforeChange; before != nil {
+		if se.ContentId == "" {
+			se.ContentId = before.Id
+			se.Class = before.Class
+		} else if before.Class != se.Class {
+			se.PreviousClass = before.Class
+		}
+	}
+
+	return se, true
+}
@@ -0,0 +1,213 @@
+// Package feeds renders content as Atom and RSS 2.0 feeds, with the archive
+// links of RFC 5005 so that subscribers can page back through everything a
+// collection or class has published.
+package feeds
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+
+	atomNamespace    = "http://www.w3.org/2005/Atom"
+	historyNamespace = "http://purl.org/syndication/history/1.0"
+)
+
+// Feed is one document of a feed: the current one, which subscribers poll,
+// or one of its archives.
+type Feed struct {
+	Title string
+	// Self is the absolute URL of this document and Current that of the
+	// feed subscribers poll. They are the same for the current document.
+	Self    string
+	Current string
+	// PrevArchive and NextArchive link to the archive documents holding
+	// older and newer entries, when there are any.
+	PrevArchive string
+	NextArchive string
+	// Archive marks the document as a complete archive whose entries do not
+	// change, so that clients may cache it.
+	Archive bool
+	Updated time.Time
+	Entries []models.Content
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Id        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Author    atomPerson    `xml:"author"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Summary   *atomText     `xml:"summary,omitempty"`
+	Content   atomText      `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Namespace string      `xml:"xmlns,attr"`
+	History   string      `xml:"xmlns:fh,attr"`
+	Id        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Archive   *struct{}   `xml:"fh:archive"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+// Atom renders f as an Atom 1.0 document. Entries are identified by the
+// URN of their content id, and the CreatorId is given as the author.
+func Atom(f Feed) ([]byte, error) {
+	doc := atomFeed{
+		Namespace: atomNamespace,
+		History:   historyNamespace,
+		Id:        f.Current,
+		Title:     f.Title,
+		Updated:   f.Updated.UTC().Format(time.RFC3339),
+		Links:     links(f, "application/atom+xml"),
+		Entries:   []atomEntry{},
+	}
+	if f.Archive {
+		doc.Archive = &struct{}{}
+	}
+
+	for _, c := range f.Entries {
+		entry := atomEntry{
+			Id:        "urn:uuid:" + c.Id,
+			Title:     c.Title,
+			Updated:   c.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: c.CreatedAt.UTC().Format(time.RFC3339),
+			Author:    atomPerson{Name: c.CreatorId, URI: "urn:uuid:" + c.CreatorId},
+			Content:   atomText{Type: "html", Body: HTML(c.Body)},
+		}
+		if c.Class != "" {
+			entry.Category = &atomCategory{Term: c.Class}
+		}
+		if c.Description != "" {
+			entry.Summary = &atomText{Type: "text", Body: c.Description}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshal(doc)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	GUID        rssGUID `xml:"guid"`
+	Description string  `xml:"description,omitempty"`
+	Content     string  `xml:"content:encoded"`
+	Creator     string  `xml:"dc:creator"`
+	Category    string  `xml:"category,omitempty"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Archive       *struct{}  `xml:"fh:archive"`
+	Links         []atomLink `xml:"atom:link"`
+	Items         []rssItem  `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	History string     `xml:"xmlns:fh,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// RSS renders f as an RSS 2.0 document. The rendered body goes in
+// content:encoded and the description in description, and the links of
+// RFC 5005 are carried as atom:link elements.
+func RSS(f Feed) ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		Atom:    atomNamespace,
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		History: historyNamespace,
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Current,
+			Description:   "Public content of " + f.Title,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Links:         links(f, "application/rss+xml"),
+			Items:         []rssItem{},
+		},
+	}
+	if f.Archive {
+		doc.Channel.Archive = &struct{}{}
+	}
+
+	for _, c := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       c.Title,
+			GUID:        rssGUID{Value: "urn:uuid:" + c.Id},
+			Description: c.Description,
+			Content:     HTML(c.Body),
+			Creator:     c.CreatorId,
+			Category:    c.Class,
+			PubDate:     c.UpdatedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshal(doc)
+}
+
+func links(f Feed, mediaType string) []atomLink {
+	result := []atomLink{{Rel: "self", Type: mediaType, Href: f.Self}}
+	if f.Archive {
+		result = append(result, atomLink{Rel: "current", Type: mediaType, Href: f.Current})
+	}
+	if f.PrevArchive != "" {
+		result = append(result, atomLink{Rel: "prev-archive", Type: mediaType, Href: f.PrevArchive})
+	}
+	if f.NextArchive != "" {
+		result = append(result, atomLink{Rel: "next-archive", Type: mediaType, Href: f.NextArchive})
+	}
+	return result
+}
+
+func marshal(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
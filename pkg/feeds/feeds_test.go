@@ -0,0 +1,97 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTime = time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+var testFeed = Feed{
+	Title:       "courses / go",
+	Self:        "https://cms.example/feeds/courses/class/go.atom?page=2",
+	Current:     "https://cms.example/feeds/courses/class/go.atom",
+	PrevArchive: "https://cms.example/feeds/courses/class/go.atom?page=1",
+	Archive:     true,
+	Updated:     testTime,
+	Entries: []models.Content{{
+		Id:          "6f1c1a52-4fd4-4a7e-9a35-1f2b0f2c3d4e",
+		Class:       "go",
+		Title:       "Goroutines & channels",
+		Description: "Concurrency basics",
+		Body:        "Use `go f()`.",
+		CreatorId:   "0b7e6c8a-2f42-4f9e-8d55-5a3e1b2c4d6f",
+		UpdatedAt:   testTime,
+		CreatedAt:   testTime.Add(-time.Hour),
+	}},
+}
+
+func TestAtom(t *testing.T) {
+	out, err := Atom(testFeed)
+	assert.NoError(t, err)
+	doc := string(out)
+
+	assert.Contains(t, doc, `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:fh="http://purl.org/syndication/history/1.0">`)
+	assert.Contains(t, doc, `<id>https://cms.example/feeds/courses/class/go.atom</id>`)
+	assert.Contains(t, doc, `<fh:archive></fh:archive>`)
+	assert.Contains(t, doc, `<link rel="current" type="application/atom+xml" href="https://cms.example/feeds/courses/class/go.atom"></link>`)
+	assert.Contains(t, doc, `<link rel="prev-archive" type="application/atom+xml" href="https://cms.example/feeds/courses/class/go.atom?page=1"></link>`)
+	assert.NotContains(t, doc, "next-archive")
+	assert.Contains(t, doc, `<title>Goroutines &amp; channels</title>`)
+	assert.Contains(t, doc, `<updated>2024-06-10T12:00:00Z</updated>`)
+	assert.Contains(t, doc, `<published>2024-06-10T11:00:00Z</published>`)
+	assert.Contains(t, doc, `<name>0b7e6c8a-2f42-4f9e-8d55-5a3e1b2c4d6f</name>`)
+	assert.Contains(t, doc, `<summary type="text">Concurrency basics</summary>`)
+	assert.Contains(t, doc, `<content type="html">&lt;p&gt;Use &lt;code&gt;go f()&lt;/code&gt;.&lt;/p&gt;&#xA;</content>`)
+	assert.NoError(t, xml.Unmarshal(out, new(struct{})))
+
+	current := testFeed
+	current.Archive, current.Self, current.Entries = false, current.Current, nil
+	out, err = Atom(current)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "fh:archive")
+	assert.NotContains(t, string(out), `rel="current"`)
+}
+
+func TestRSS(t *testing.T) {
+	out, err := RSS(testFeed)
+	assert.NoError(t, err)
+	doc := string(out)
+
+	assert.Contains(t, doc, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"`)
+	assert.Contains(t, doc, `<atom:link rel="self" type="application/rss+xml" href="https://cms.example/feeds/courses/class/go.atom?page=2"></atom:link>`)
+	assert.Contains(t, doc, `<lastBuildDate>Mon, 10 Jun 2024 12:00:00 +0000</lastBuildDate>`)
+	assert.Contains(t, doc, `<guid isPermaLink="false">urn:uuid:6f1c1a52-4fd4-4a7e-9a35-1f2b0f2c3d4e</guid>`)
+	assert.Contains(t, doc, `<description>Concurrency basics</description>`)
+	assert.Contains(t, doc, `<dc:creator>0b7e6c8a-2f42-4f9e-8d55-5a3e1b2c4d6f</dc:creator>`)
+	assert.Contains(t, doc, `<pubDate>Mon, 10 Jun 2024 12:00:00 +0000</pubDate>`)
+	assert.NoError(t, xml.Unmarshal(out, new(struct{})))
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"Paragraphs", "First line\nsecond line\n\nNext", "<p>First line\nsecond line</p>\n<p>Next</p>\n"},
+		{"Headings", "# Title\n###### Small\n####### Not", "<h1>Title</h1>\n<h6>Small</h6>\n<p>####### Not</p>\n"},
+		{"Emphasis", "**bold** and *it* and 2 * 3", "<p><strong>bold</strong> and <em>it</em> and 2 * 3</p>\n"},
+		{"Code", "```go\nif a < b {}\n```\nInline `<b>`", "<pre><code class=\"language-go\">if a &lt; b {}</code></pre>\n<p>Inline <code>&lt;b&gt;</code></p>\n"},
+		{"Lists", "- one\n- two\n1. first\n2) second", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n"},
+		{"Quote", "> quoted\n> **text**\n\n---", "<blockquote>\n<p>quoted\n<strong>text</strong></p>\n</blockquote>\n<hr>\n"},
+		{"Links", "[docs](https://go.dev/doc?a=1&b=2) [x](javascript:alert`1`) [y](/local)", "<p><a href=\"https://go.dev/doc?a=1&amp;b=2\">docs</a> x <a href=\"/local\">y</a></p>\n"},
+		{"Raw HTML Is Escaped", "<script>alert('x')</script>", "<p>&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;</p>\n"},
+		{"Unicode", "Ünïcödé — ok", "<p>Ünïcödé — ok</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTML(tt.body))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package feeds
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HTML renders a content body written in Markdown as HTML. It covers the
+// subset lessons use: ATX headings, paragraphs, fenced code blocks, bullet
+// and numbered lists, block quotes, horizontal rules, inline code, links and
+// emphasis with asterisks. Raw HTML in the body is escaped rather than
+// passed through, and links are kept only for http, https and mailto URLs.
+func HTML(body string) string {
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + inline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case line == "":
+			flush()
+
+		case strings.HasPrefix(line, "```"):
+			flush()
+			code := []string{}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if lang := strings.TrimSpace(line[3:]); lang != "" {
+				b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+			}
+			b.WriteString(">" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingLevel(line) > 0:
+			flush()
+			level := headingLevel(line)
+			tag := "h" + strconv.Itoa(level)
+			b.WriteString("<" + tag + ">" + inline(strings.TrimSpace(line[level:])) + "</" + tag + ">\n")
+
+		case line == "---" || line == "***" || line == "___":
+			flush()
+			b.WriteString("<hr>\n")
+
+		case strings.HasPrefix(line, ">"):
+			flush()
+			quote := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + HTML(strings.Join(quote, "\n")) + "</blockquote>\n")
+
+		case listItem(line) != "":
+			flush()
+			tag := listItem(line)
+			b.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && listItem(strings.TrimSpace(lines[i])) == tag; i++ {
+				item := strings.TrimSpace(lines[i])
+				_, text, _ := strings.Cut(item, " ")
+				b.WriteString("<li>" + inline(strings.TrimSpace(text)) + "</li>\n")
+			}
+			i--
+			b.WriteString("</" + tag + ">\n")
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+// headingLevel returns the level of an ATX heading such as "## Setup", or 0
+// if the line is not one.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// listItem returns "ul" or "ol" for a line that starts a list item, and ""
+// otherwise.
+func listItem(line string) string {
+	if len(line) > 2 && (line[0] == '-' || line[0] == '*' || line[0] == '+') && line[1] == ' ' {
+		return "ul"
+	}
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits+1 < len(line) && (line[digits] == '.' || line[digits] == ')') && line[digits+1] == ' ' {
+		return "ol"
+	}
+	return ""
+}
+
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(rest[1:1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				b.WriteString("<strong>" + inline(rest[2:2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*':
+			if end := strings.IndexByte(rest[1:], '*'); end > 0 {
+				b.WriteString("<em>" + inline(rest[1:1+end]) + "</em>")
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if text, href, n, ok := link(rest); ok {
+				if safeURL(href) {
+					b.WriteString(`<a href="` + html.EscapeString(href) + `">` + inline(text) + "</a>")
+				} else {
+					b.WriteString(inline(text))
+				}
+				i += n
+				continue
+			}
+		}
+		// Only ASCII is ever escaped, so writing byte by byte keeps UTF-8 intact.
+		b.WriteString(html.EscapeString(rest[:1]))
+		i++
+	}
+	return b.String()
+}
+
+// link parses a "[text](href)" at the start of s and returns its length.
+func link(s string) (text string, href string, n int, ok bool) {
+	close := strings.Index(s, "](")
+	if close < 0 {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[close+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	return s[1:close], strings.TrimSpace(s[close+2 : close+2+end]), close + 3 + end, true
+}
+
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	default:
+		return false
+	}
+}
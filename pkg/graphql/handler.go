@@ -0,0 +1,265 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/policy"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxBodyBytes    = 1048576 // one megabyte
+	streamHeartbeat = 15 * time.Second
+)
+
+// Handler serves the GraphQL API. Queries and mutations are answered as
+// JSON; subscriptions are streamed as server-sent events and need an Accept
+// header of text/event-stream.
+type Handler struct {
+	DB            *mongo.Database
+	SystemDB      *mongo.Database
+	Events        *events.Bus
+	Broker        *events.Broker
+	Cache         *cache.Cache
+	Policy        *policy.Policy
+	MaxDepth      int
+	MaxComplexity int
+
+	schema graphql.Schema
+}
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewHandler(db *mongo.Database, systemDB *mongo.Database, bus *events.Bus, broker *events.Broker) (*Handler, error) {
+	h := &Handler{
+		DB:            db,
+		SystemDB:      systemDB,
+		Events:        bus,
+		Broker:        broker,
+		MaxDepth:      DefaultMaxDepth,
+		MaxComplexity: DefaultMaxComplexity,
+	}
+
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	h.schema = schema
+	return h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("GraphQL request received", "method", r.Method)
+
+	req, err := readRequest(r)
+	if err != nil {
+		slog.Error("Failed to read GraphQL request", "error", err)
+		writeResult(w, http.StatusBadRequest, errorResult(err))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		slog.Error("Failed to parse GraphQL query", "error", err)
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		slog.Error("GraphQL query failed validation", "errors", validation.Errors)
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+
+	op, err := findOperation(doc, req.OperationName)
+	if err != nil {
+		slog.Error("Failed to select GraphQL operation", "error", err)
+		writeResult(w, http.StatusBadRequest, errorResult(err))
+		return
+	}
+
+	if err := checkLimits(doc, op, req.Variables, h.MaxDepth, h.MaxComplexity); err != nil {
+		slog.Error("GraphQL query rejected", "error", err)
+		writeResult(w, http.StatusBadRequest, errorResult(err))
+		return
+	}
+
+	if op.Operation != ast.OperationTypeQuery && r.Method != http.MethodPost {
+		err := fmt.Errorf("%s operations must be sent with POST", op.Operation)
+		slog.Error("GraphQL operation rejected", "error", err)
+		writeResult(w, http.StatusMethodNotAllowed, errorResult(err))
+		return
+	}
+
+	params := graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(r.Context(), requestKey{}, r),
+	}
+
+	if op.Operation == ast.OperationTypeSubscription {
+		h.serveSubscription(w, r, params)
+		return
+	}
+
+	result := graphql.Execute(params)
+	if result.HasErrors() {
+		slog.Error("GraphQL operation returned errors", "errors", result.Errors)
+	}
+	writeResult(w, http.StatusOK, result)
+}
+
+// serveSubscription streams each subscription result as a "next" event and
+// sends "complete" when the subscription ends on the server side.
+func (h *Handler) serveSubscription(w http.ResponseWriter, r *http.Request, params graphql.ExecuteParams) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		err := errors.New("subscriptions require Accept: text/event-stream")
+		slog.Error("GraphQL subscription rejected", "error", err)
+		writeResult(w, http.StatusNotAcceptable, errorResult(err))
+		return
+	}
+
+	results := graphql.ExecuteSubscription(params)
+	defer func() {
+		// The executor blocks on sending its next result, so keep reading
+		// until it sees the request context end and closes the channel.
+		go func() {
+			for range results {
+			}
+		}()
+	}()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+	slog.Info("GraphQL subscription opened")
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			slog.Info("GraphQL subscription closed by client")
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			rc.Flush()
+		case result, ok := <-results:
+			if !ok {
+				fmt.Fprint(w, "event: complete\ndata: {}\n\n")
+				rc.Flush()
+				slog.Info("GraphQL subscription completed")
+				return
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				slog.Error("Failed to encode subscription result", "error", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			rc.Flush()
+		}
+	}
+}
+
+func readRequest(r *http.Request) (*Request, error) {
+	var req Request
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, errors.New("variables must be a JSON object")
+			}
+		}
+	case http.MethodPost:
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		if mediaType == "application/graphql" {
+			req.Query = string(body)
+		} else if err := json.Unmarshal(body, &req); err != nil {
+			return nil, errors.New("body must be a JSON object")
+		}
+	default:
+		return nil, fmt.Errorf("method %s is not supported", r.Method)
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, errors.New("query is required")
+	}
+	return &req, nil
+}
+
+func findOperation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil, errors.New("operationName is required when the query has several operations")
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op, nil
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+	return found, nil
+}
+
+func errorResult(err error) *graphql.Result {
+	return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		slog.Error("Failed to write GraphQL response", "error", err)
+	}
+}
@@ -0,0 +1,179 @@
+package graphql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	h, err := NewHandler(nil, nil, nil, events.NewBroker(10))
+	assert.NoError(t, err)
+	return h
+}
+
+func post(h http.Handler, principal *auth.Principal, query string, variables map[string]interface{}) (*httptest.ResponseRecorder, response) {
+	body, _ := json.Marshal(Request{Query: query, Variables: variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var res response
+	json.Unmarshal(rr.Body.Bytes(), &res)
+	return rr, res
+}
+
+func TestHandler(t *testing.T) {
+	h := newTestHandler(t)
+	reader := &auth.Principal{Kind: auth.KindAPIKey, Id: "key", Collections: []string{"courses"}, Operations: []string{auth.OpRead}}
+
+	t.Run("Missing Query", func(t *testing.T) {
+		rr, res := post(h, auth.Anonymous, "", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "query is required", res.Errors[0].Message)
+	})
+
+	t.Run("Invalid Query", func(t *testing.T) {
+		rr, res := post(h, auth.Anonymous, `{ contents(collection: "courses") { nope } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.NotEmpty(t, res.Errors)
+	})
+
+	t.Run("Mutation Outside Key Scope", func(t *testing.T) {
+		rr, res := post(h, reader, `mutation { deleteCollection(collection: "courses") }`, nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "api key is not permitted to delete collection courses", res.Errors[0].Message)
+	})
+
+	t.Run("Query Outside Key Scope", func(t *testing.T) {
+		_, res := post(h, reader, `query($c: String!) { classes(collection: $c) }`, map[string]interface{}{"c": "secrets"})
+		assert.Equal(t, "api key is not permitted to read collection secrets", res.Errors[0].Message)
+	})
+
+	t.Run("Order By Both Fields", func(t *testing.T) {
+		_, res := post(h, reader, `{ contents(collection: "courses", orderBy: {field: TITLE, customField: "duration"}) { totalCount } }`, nil)
+		assert.Equal(t, "orderBy needs either field or customField", res.Errors[0].Message)
+	})
+
+	t.Run("Filter Fields Not An Object", func(t *testing.T) {
+		_, res := post(h, reader, `{ contents(collection: "courses", filter: {fields: ["easy"]}) { totalCount } }`, nil)
+		assert.Equal(t, "fields must be an object", res.Errors[0].Message)
+	})
+
+	t.Run("Mutations Require POST", func(t *testing.T) {
+		query := url.Values{"query": {`mutation { deleteCollection(collection: "courses") }`}}
+		req := httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+
+	t.Run("Subscriptions Require Event Stream", func(t *testing.T) {
+		rr, _ := post(h, auth.Anonymous, `subscription { contentChanged(collection: "courses") { id } }`, nil)
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	})
+}
+
+func TestHandlerLimits(t *testing.T) {
+	h := newTestHandler(t)
+
+	t.Run("Depth", func(t *testing.T) {
+		h.MaxDepth = 2
+		defer func() { h.MaxDepth = DefaultMaxDepth }()
+
+		rr, res := post(h, auth.Anonymous, `{ collection(collection: "courses") { contents { items { id } } } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "query depth 4 exceeds the limit of 2", res.Errors[0].Message)
+	})
+
+	t.Run("Depth Through Fragments", func(t *testing.T) {
+		h.MaxDepth = 3
+		defer func() { h.MaxDepth = DefaultMaxDepth }()
+
+		query := `
+			query { collection(collection: "courses") { ...Page } }
+			fragment Page on Collection { contents { ... on ContentPage { items { id } } } }`
+		rr, res := post(h, auth.Anonymous, query, nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "query depth 4 exceeds the limit of 3", res.Errors[0].Message)
+	})
+
+	t.Run("Complexity", func(t *testing.T) {
+		query := `query($n: Int) { collections { contents(first: $n) { items { id title body } } } }`
+		rr, res := post(h, auth.Anonymous, query, map[string]interface{}{"n": 100})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "query complexity 4011 exceeds the limit of 1000", res.Errors[0].Message)
+	})
+}
+
+func TestSubscription(t *testing.T) {
+	h := newTestHandler(t)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := `{"query": "subscription { contentChanged(collection: \"courses\") { type contentId } }"}`
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	// The subscription is registered asynchronously, so keep publishing
+	// until the first event comes through.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				h.Broker.Handle(events.Event{Type: models.EventContentCreated, Collection: "courses", ContentId: "a"})
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := reader.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return
+		}
+		if line = strings.TrimRight(line, "\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	assert.Equal(t, "event: next", lines[0])
+	assert.JSONEq(t, `{"data":{"contentChanged":{"type":"content.created","contentId":"a"}}}`, strings.TrimPrefix(lines[1], "data: "))
+}
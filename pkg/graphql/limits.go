@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	DefaultMaxDepth      = 8
+	DefaultMaxComplexity = 1000
+)
+
+// checkLimits rejects operations that nest deeper than maxDepth or whose
+// estimated cost exceeds maxComplexity. Every field costs one, and the cost
+// of the fields selected under a paginated field is multiplied by the number
+// of items it asks for, so a query is priced by the work it can cause rather
+// than by its length.
+func checkLimits(doc *ast.Document, op *ast.OperationDefinition, variables map[string]interface{}, maxDepth int, maxComplexity int) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	w := &limitWalker{fragments: fragments, variables: variables}
+	depth, cost := w.walk(op.SelectionSet, 1, map[string]bool{})
+
+	if maxDepth > 0 && depth > maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxDepth)
+	}
+	if maxComplexity > 0 && cost > maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, maxComplexity)
+	}
+	return nil
+}
+
+// listCosts estimates the size of list fields that are not paginated with a
+// first argument, or that are when the argument is left out.
+var listCosts = map[string]int{
+	"contents":    defaultPageSize,
+	"collections": 10,
+}
+
+type limitWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// walk returns the deepest field level reached and the total cost of the
+// selection set. visiting guards against fragment cycles.
+func (w *limitWalker) walk(set *ast.SelectionSet, level int, visiting map[string]bool) (int, int) {
+	if set == nil {
+		return level - 1, 0
+	}
+
+	depth, cost := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			d, c = w.walk(s.SelectionSet, level+1, visiting)
+			if d < level {
+				d = level
+			}
+			c = 1 + c*w.multiplier(s)
+		case *ast.InlineFragment:
+			d, c = w.walk(s.SelectionSet, level, visiting)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			d, c = w.walk(fragment.SelectionSet, level, visiting)
+			delete(visiting, name)
+		}
+		depth = max(depth, d)
+		cost += c
+	}
+	return depth, cost
+}
+
+func (w *limitWalker) multiplier(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n, ok := w.variables[v.Name.Value].(float64); ok && n > 0 {
+				return int(n)
+			}
+			if n, ok := w.variables[v.Name.Value].(int); ok && n > 0 {
+				return n
+			}
+		}
+		return defaultPageSize
+	}
+	if n, ok := listCosts[field.Name.Value]; ok {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,722 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// jsonType carries custom fields, whose shape depends on the schema of the
+// class, as plain JSON values.
+var jsonType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: parseJSONLiteral,
+})
+
+var contentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Content",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"class":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"body":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"isPublic":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"views":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"creatorId":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"createdAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"updatedAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		// The default resolver matches Fields and SchemaVersion by name
+		"fields":        &graphql.Field{Type: jsonType},
+		"schemaVersion": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var contentPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ContentPage",
+	Fields: graphql.Fields{
+		"items":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(contentType)))},
+		"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"hasMore":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var contentFilterType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ContentFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"class":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"isPublic":      &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"creatorId":     &graphql.InputObjectFieldConfig{Type: graphql.ID},
+		"titleContains": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"fields":        &graphql.InputObjectFieldConfig{Type: jsonType},
+	},
+})
+
+var sortFieldType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "ContentSortField",
+	Values: graphql.EnumValueConfigMap{
+		"CREATED_AT": &graphql.EnumValueConfig{Value: "created_at"},
+		"UPDATED_AT": &graphql.EnumValueConfig{Value: "updated_at"},
+		"TITLE":      &graphql.EnumValueConfig{Value: "title"},
+		"VIEWS":      &graphql.EnumValueConfig{Value: "views"},
+		"CLASS":      &graphql.EnumValueConfig{Value: "class"},
+	},
+})
+
+var sortDirectionType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "SortDirection",
+	Values: graphql.EnumValueConfigMap{
+		"ASC":  &graphql.EnumValueConfig{Value: "asc"},
+		"DESC": &graphql.EnumValueConfig{Value: "desc"},
+	},
+})
+
+var contentOrderType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ContentOrder",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"field":       &graphql.InputObjectFieldConfig{Type: sortFieldType},
+		"customField": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"direction":   &graphql.InputObjectFieldConfig{Type: sortDirectionType, DefaultValue: "asc"},
+	},
+})
+
+var createContentInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateContentInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"class":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"body":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"isPublic":    &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+		"views":       &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+		"creatorId":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"fields":      &graphql.InputObjectFieldConfig{Type: jsonType},
+	},
+})
+
+var updateContentInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateContentInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"class":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"body":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"isPublic":    &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"views":       &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"creatorId":   &graphql.InputObjectFieldConfig{Type: graphql.ID},
+		"fields":      &graphql.InputObjectFieldConfig{Type: jsonType},
+	},
+})
+
+var contentEventType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ContentEvent",
+	Fields: graphql.Fields{
+		"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"type":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"collection":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"contentId":     &graphql.Field{Type: graphql.ID},
+		"class":         &graphql.Field{Type: graphql.String},
+		"previousClass": &graphql.Field{Type: graphql.String},
+		"ids":           &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+		"content":       &graphql.Field{Type: contentType},
+		"timestamp":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+	},
+})
+
+var listArgs = graphql.FieldConfigArgument{
+	"filter":  &graphql.ArgumentConfig{Type: contentFilterType},
+	"orderBy": &graphql.ArgumentConfig{Type: contentOrderType},
+	"first":   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+	"offset":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+}
+
+type contentPage struct {
+	Items      []models.Content
+	TotalCount int64
+	HasMore    bool
+}
+
+type requestKey struct{}
+
+func (h *Handler) newSchema() (graphql.Schema, error) {
+	collectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Collection",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+			"classes": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.repo().ListClasses(p.Source.(string))
+				},
+			},
+			"contents": &graphql.Field{
+				Type: graphql.NewNonNull(contentPageType),
+				Args: listArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.listContents(p.Source.(string), p.Args)
+				},
+			},
+		},
+	})
+
+	collectionArgs := graphql.FieldConfigArgument{
+		"collection": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	}
+	withCollection := func(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		merged := graphql.FieldConfigArgument{}
+		for name, arg := range collectionArgs {
+			merged[name] = arg
+		}
+		for name, arg := range args {
+			merged[name] = arg
+		}
+		return merged
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"content": &graphql.Field{
+				Type: contentType,
+				Args: withCollection(graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				}),
+				Resolve: h.resolveContent,
+			},
+			"contents": &graphql.Field{
+				Type:    graphql.NewNonNull(contentPageType),
+				Args:    withCollection(listArgs),
+				Resolve: h.resolveContents,
+			},
+			"classes": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Args:    collectionArgs,
+				Resolve: h.resolveClasses,
+			},
+			"collection": &graphql.Field{
+				Type:    graphql.NewNonNull(collectionType),
+				Args:    collectionArgs,
+				Resolve: h.resolveCollection,
+			},
+			"collections": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(collectionType))),
+				Resolve: h.resolveCollections,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createContent": &graphql.Field{
+				Type: graphql.NewNonNull(contentType),
+				Args: withCollection(graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createContentInputType)},
+				}),
+				Resolve: h.resolveCreateContent,
+			},
+			"updateContent": &graphql.Field{
+				Type: graphql.NewNonNull(contentType),
+				Args: withCollection(graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateContentInputType)},
+				}),
+				Resolve: h.resolveUpdateContent,
+			},
+			"deleteContent": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Args: withCollection(graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				}),
+				Resolve: h.resolveDeleteContent,
+			},
+			"deleteClass": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID))),
+				Args: withCollection(graphql.FieldConfigArgument{
+					"class": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				}),
+				Resolve: h.resolveDeleteClass,
+			},
+			"deleteCollection": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID))),
+				Args:    collectionArgs,
+				Resolve: h.resolveDeleteCollection,
+			},
+		},
+	})
+
+	subscription := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"contentChanged": &graphql.Field{
+				Type: graphql.NewNonNull(contentEventType),
+				Args: withCollection(graphql.FieldConfigArgument{
+					"class": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Subscribe: h.subscribeContentChanged,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:        query,
+		Mutation:     mutation,
+		Subscription: subscription,
+	})
+}
+
+func (h *Handler) repo() *repositories.ContentRepository {
+	return &repositories.ContentRepository{DB: h.DB, SystemDB: h.SystemDB, Cache: h.Cache}
+}
+
+// authorize applies the same collection scopes and collection policy to
+// GraphQL fields that the Authorize and policy middleware apply to REST
+// routes.
+func (h *Handler) authorize(ctx context.Context, coll string, op string) error {
+	principal := auth.FromContext(ctx)
+	if !principal.Allows(coll, op) {
+		err := fmt.Errorf("api key is not permitted to %s collection %s", op, coll)
+		slog.Error("Authorization failed", "principal", principal.String(), "error", err)
+		return err
+	}
+	if err := h.Policy.Check(coll, op); err != nil {
+		slog.Error("Collection policy check failed", "collection", coll, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) publish(ctx context.Context, e events.Event) {
+	r, ok := ctx.Value(requestKey{}).(*http.Request)
+	if !ok {
+		slog.Debug("No request in resolver context, skipping event", "type", e.Type)
+		return
+	}
+	h.Events.PublishFrom(r, e)
+}
+
+func (h *Handler) resolveContent(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	id := p.Args["id"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpRead); err != nil {
+		return nil, err
+	}
+
+	content, err := h.repo().GetContent(coll, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrContentNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return (*models.Content)(content), nil
+}
+
+func (h *Handler) resolveContents(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpRead); err != nil {
+		return nil, err
+	}
+	return h.listContents(coll, p.Args)
+}
+
+func (h *Handler) listContents(coll string, args map[string]interface{}) (interface{}, error) {
+	first, _ := args["first"].(int)
+	offset, _ := args["offset"].(int)
+	if first < 0 || first > maxPageSize {
+		return nil, fmt.Errorf("first must be between 0 and %d", maxPageSize)
+	}
+	if offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+
+	opts := repositories.ListOptions{Limit: int64(first), Offset: int64(offset)}
+	if filter, ok := args["filter"].(map[string]interface{}); ok {
+		opts.Class, _ = filter["class"].(string)
+		opts.CreatorId, _ = filter["creatorId"].(string)
+		opts.TitleContains, _ = filter["titleContains"].(string)
+		if isPublic, ok := filter["isPublic"].(bool); ok {
+			opts.IsPublic = &isPublic
+		}
+		fields, err := fieldsArg(filter)
+		if err != nil {
+			return nil, err
+		}
+		opts.Fields = fields
+	}
+	if order, ok := args["orderBy"].(map[string]interface{}); ok {
+		field, _ := order["field"].(string)
+		customField, _ := order["customField"].(string)
+		if (field == "") == (customField == "") {
+			return nil, errors.New("orderBy needs either field or customField")
+		}
+		opts.SortBy = field
+		if customField != "" {
+			opts.SortBy = "fields." + customField
+		}
+		opts.Descending = order["direction"] == "desc"
+	}
+
+	if first == 0 {
+		// Only the count was asked for. A zero limit means no limit to the
+		// repository, so fetch a single item and drop it.
+		countOpts := opts
+		countOpts.Limit = 1
+		_, total, err := h.repo().ListContent(coll, countOpts)
+		if err != nil {
+			return nil, err
+		}
+		return &contentPage{Items: []models.Content{}, TotalCount: total, HasMore: total > int64(offset)}, nil
+	}
+
+	items, total, err := h.repo().ListContent(coll, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &contentPage{
+		Items:      items,
+		TotalCount: total,
+		HasMore:    int64(offset+len(items)) < total,
+	}, nil
+}
+
+func (h *Handler) resolveClasses(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpRead); err != nil {
+		return nil, err
+	}
+	return h.repo().ListClasses(coll)
+}
+
+func (h *Handler) resolveCollection(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpRead); err != nil {
+		return nil, err
+	}
+	return coll, nil
+}
+
+// resolveCollections lists only the collections the caller may read.
+func (h *Handler) resolveCollections(p graphql.ResolveParams) (interface{}, error) {
+	names, err := h.repo().ListCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	principal := auth.FromContext(p.Context)
+	collections := []interface{}{}
+	for _, name := range names {
+		if principal.Allows(name, auth.OpRead) {
+			collections = append(collections, name)
+		}
+	}
+	return collections, nil
+}
+
+func (h *Handler) resolveCreateContent(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpWrite); err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+	c := models.Content{
+		Id:        uuid.New().String(),
+		UpdatedAt: time.Now().UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	c.Class, _ = input["class"].(string)
+	c.Title, _ = input["title"].(string)
+	c.Description, _ = input["description"].(string)
+	c.Body, _ = input["body"].(string)
+	c.IsPublic, _ = input["isPublic"].(bool)
+	c.Views, _ = input["views"].(int)
+	c.CreatorId, _ = input["creatorId"].(string)
+	fields, err := fieldsArg(input)
+	if err != nil {
+		return nil, err
+	}
+	c.Fields = fields
+
+	if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+		return nil, errors.New("missing fields in request payload")
+	}
+
+	id, err := h.repo().CreateContent(coll, &c)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Content created successfully", "id", id)
+
+	h.publish(p.Context, events.Event{
+		Type:       models.EventContentCreated,
+		Collection: coll,
+		ContentId:  id,
+		Class:      c.Class,
+		After:      &c,
+	})
+	return &c, nil
+}
+
+func (h *Handler) resolveUpdateContent(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	id := p.Args["id"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpWrite); err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+	var update models.UpdateContent
+	if v, ok := input["class"].(string); ok {
+		update.Class = &v
+	}
+	if v, ok := input["title"].(string); ok {
+		update.Title = &v
+	}
+	if v, ok := input["description"].(string); ok {
+		update.Description = &v
+	}
+	if v, ok := input["body"].(string); ok {
+		update.Body = &v
+	}
+	if v, ok := input["isPublic"].(bool); ok {
+		update.IsPublic = &v
+	}
+	if v, ok := input["views"].(int); ok {
+		if v < 0 {
+			return nil, errors.New("views must not be negative")
+		}
+		update.Views = &v
+	}
+	if v, ok := input["creatorId"].(string); ok {
+		if _, err := uuid.Parse(v); err != nil {
+			return nil, errors.New("creatorId is not a valid uuid")
+		}
+		update.CreatorId = &v
+	}
+	fields, err := fieldsArg(input)
+	if err != nil {
+		return nil, err
+	}
+	update.Fields = fields
+
+	repo := h.repo()
+	before, err := repo.GetContent(coll, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.UpdateContent(coll, id, &update); err != nil {
+		return nil, err
+	}
+	after, err := repo.GetContent(coll, id)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Content updated successfully", "id", id)
+
+	h.publish(p.Context, events.Event{
+		Type:       models.EventContentUpdated,
+		Collection: coll,
+		ContentId:  id,
+		Class:      after.Class,
+		Before:     (*models.Content)(before),
+		After:      (*models.Content)(after),
+	})
+	return (*models.Content)(after), nil
+}
+
+func (h *Handler) resolveDeleteContent(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	id := p.Args["id"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpDelete); err != nil {
+		return nil, err
+	}
+
+	repo := h.repo()
+	before, err := repo.RemoveContent(coll, id)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Content deleted successfully", "id", id)
+
+	if before != nil {
+		h.publish(p.Context, events.Event{
+			Type:       models.EventContentDeleted,
+			Collection: coll,
+			ContentId:  id,
+			Class:      before.Class,
+			Before:     before,
+		})
+	}
+	return id, nil
+}
+
+func (h *Handler) resolveDeleteClass(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	class := p.Args["class"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpDelete); err != nil {
+		return nil, err
+	}
+
+	ids, err := h.repo().DeleteClass(coll, class)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Class deleted successfully", "class", class)
+
+	h.publish(p.Context, events.Event{
+		Type:       models.EventClassDeleted,
+		Collection: coll,
+		Class:      class,
+		Ids:        ids,
+	})
+	return nonNil(ids), nil
+}
+
+func (h *Handler) resolveDeleteCollection(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpDelete); err != nil {
+		return nil, err
+	}
+
+	ids, err := h.repo().DeleteCollection(coll)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Collection deleted successfully", "collection", coll)
+
+	h.publish(p.Context, events.Event{
+		Type:       models.EventCollectionDeleted,
+		Collection: coll,
+		Ids:        ids,
+	})
+	return nonNil(ids), nil
+}
+
+// subscribeContentChanged feeds broker events to the subscription until the
+// request context ends or the broker drops the subscriber.
+func (h *Handler) subscribeContentChanged(p graphql.ResolveParams) (interface{}, error) {
+	coll := p.Args["collection"].(string)
+	class, _ := p.Args["class"].(string)
+	if err := h.authorize(p.Context, coll, auth.OpRead); err != nil {
+		return nil, err
+	}
+	if h.Broker == nil {
+		return nil, errors.New("event streaming is not available")
+	}
+
+	sub, _, _ := h.Broker.Subscribe(coll, class, "")
+	c := make(chan interface{})
+	go func() {
+		defer close(c)
+		defer sub.Close()
+		for {
+			select {
+			case <-p.Context.Done():
+				return
+			case se, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				select {
+				case c <- &se:
+				case <-p.Context.Done():
+					return
+				}
+			}
+		}
+	}()
+	return c, nil
+}
+
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
+// fieldsArg returns the custom fields of an input object, which must be a
+// JSON object when they are given.
+func fieldsArg(input map[string]interface{}) (map[string]interface{}, error) {
+	value, ok := input["fields"]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("fields must be an object")
+	}
+	return fields, nil
+}
+
+// parseJSONLiteral turns a JSON value written inline in a query into the
+// value decoding it from variables would give, except that integers stay
+// integers.
+func parseJSONLiteral(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.IntValue:
+		n, err := strconv.ParseInt(value.Value, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return n
+	case *ast.FloatValue:
+		n, err := strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			return nil
+		}
+		return n
+	case *ast.ListValue:
+		list := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			list[i] = parseJSONLiteral(item)
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = parseJSONLiteral(field.Value)
+		}
+		return object
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package idempotency
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+type Config struct {
+	// Window is how long a key and the response to its request are kept.
+	// Zero turns idempotency keys off.
+	Window time.Duration
+}
+
+var DefaultConfig = Config{Window: 24 * time.Hour}
+
+func (c Config) Enabled() bool {
+	return c.Window > 0
+}
+
+func ConfigFromEnv() (Config, error) {
+	slog.Debug("Loading idempotency environment variables...")
+	cfg := DefaultConfig
+
+	if value := os.Getenv("YAN_CMS_IDEMPOTENCY_WINDOW"); value == "off" || value == "0" {
+		cfg.Window = 0
+	} else if value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < 0 {
+			err := fmt.Errorf("invalid duration %q in YAN_CMS_IDEMPOTENCY_WINDOW", value)
+			slog.Error("Invalid idempotency window", "error", err)
+			return Config{}, err
+		}
+		cfg.Window = window
+	}
+
+	slog.Info("Idempotency keys configured", "window", cfg.Window)
+	return cfg, nil
+}
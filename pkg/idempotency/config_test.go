@@ -0,0 +1,38 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("YAN_CMS_IDEMPOTENCY_WINDOW", "")
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, cfg.Window)
+		assert.True(t, cfg.Enabled())
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		t.Setenv("YAN_CMS_IDEMPOTENCY_WINDOW", "1h")
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, cfg.Window)
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		t.Setenv("YAN_CMS_IDEMPOTENCY_WINDOW", "off")
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.False(t, cfg.Enabled())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("YAN_CMS_IDEMPOTENCY_WINDOW", "-1h")
+		_, err := ConfigFromEnv()
+		assert.Error(t, err)
+	})
+}
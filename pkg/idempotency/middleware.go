@@ -0,0 +1,165 @@
+// Package idempotency lets clients retry POST requests safely. A request
+// with an Idempotency-Key header is run once; retries with the same key and
+// payload get the response of the first one instead of running again.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/ratelimit"
+	"github.com/YanSystems/cms/pkg/utils"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// maxBodyBytes is the largest body fingerprinted, which is the largest
+	// any route takes, that of imports.
+	maxBodyBytes = 32 << 20
+)
+
+// Middleware runs requests that carry an Idempotency-Key once per key and
+// caller for cfg.Window. Requests without one pass straight through. When
+// the store fails, requests are run as if they had no key.
+func Middleware(cfg Config, store Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				err := fmt.Errorf("%s must not be longer than %d characters", Header, maxKeyLength)
+				slog.Error("Invalid idempotency key", "error", err)
+				utils.Error(w, r, err)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+			if err != nil {
+				slog.Error("Failed to read request body", "error", err)
+				utils.Error(w, r, err)
+				return
+			}
+			if len(body) > maxBodyBytes {
+				err := fmt.Errorf("request with an %s must not be larger than %d bytes", Header, maxBodyBytes)
+				slog.Error("Request body too large for idempotency key", "error", err)
+				utils.Error(w, r, err, http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Keys are per caller, so that one client cannot replay
+			// another's response by guessing its key.
+			scoped := ratelimit.ClientKey(r) + " " + key
+			fingerprint := Fingerprint(r, body)
+
+			existing, err := store.Claim(scoped, fingerprint, cfg.Window, time.Now().UTC())
+			if err != nil {
+				slog.Error("Idempotency store unavailable, running request", "key", key, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch {
+			case existing == nil:
+				run(w, r, next, store, Record{Key: scoped, Fingerprint: fingerprint})
+			case existing.Fingerprint != fingerprint:
+				err := fmt.Errorf("%s was already used for a different request", Header)
+				slog.Warn("Idempotency key reused", "key", key)
+				utils.Error(w, r, err, http.StatusUnprocessableEntity)
+			case !existing.Done:
+				err := fmt.Errorf("a request with this %s is still in progress", Header)
+				slog.Warn("Idempotent request in progress", "key", key)
+				w.Header().Set("Retry-After", "1")
+				utils.Error(w, r, err, http.StatusConflict)
+			default:
+				slog.Info("Replaying idempotent response", "key", key, "status", existing.Status)
+				w.Header().Set("Content-Type", existing.ContentType)
+				w.Header().Set(ReplayedHeader, "true")
+				w.WriteHeader(existing.Status)
+				w.Write(existing.Body)
+			}
+		})
+	}
+}
+
+// run serves a request that claimed its key and stores its response. Server
+// errors are not stored, so that the request can be retried with the same
+// key once the cause is fixed.
+func run(w http.ResponseWriter, r *http.Request, next http.Handler, store Store, record Record) {
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	stored := false
+	// A handler that panics leaves the key free as well.
+	defer func() {
+		if stored {
+			return
+		}
+		if err := store.Release(record.Key); err != nil {
+			slog.Error("Failed to release idempotency key", "error", err)
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+	if rec.status >= http.StatusInternalServerError {
+		return
+	}
+
+	record.Status = rec.status
+	record.ContentType = w.Header().Get("Content-Type")
+	record.Body = rec.body.Bytes()
+	// The request has taken effect whether or not this succeeds, so the
+	// claim is kept either way. Retries get a 409 until it goes stale.
+	stored = true
+	if err := store.Complete(record); err != nil {
+		slog.Error("Failed to store idempotent response", "error", err)
+	}
+}
+
+// Fingerprint identifies a request by what it asks for, so that a key sent
+// with a different payload can be told apart from a retry.
+func Fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding")} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	handler := Middleware(Config{Window: time.Hour}, NewMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `,"body":` + string(body) + `}`))
+	}))
+
+	serve := func(key string, body string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/contents/lessons", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(Header, key)
+		}
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Without Key", func(t *testing.T) {
+		serve("", `1`, nil)
+		serve("", `1`, nil)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Replays Response", func(t *testing.T) {
+		calls = 0
+		first := serve("a", `1`, nil)
+		second := serve("a", `1`, nil)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+		assert.Equal(t, "true", second.Header().Get(ReplayedHeader))
+		assert.Empty(t, first.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("Different Payload", func(t *testing.T) {
+		rr := serve("a", `2`, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Keys Are Per Caller", func(t *testing.T) {
+		rr := serve("a", `1`, &auth.Principal{Kind: auth.KindAPIKey, Id: "k1"})
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Empty(t, rr.Header().Get(ReplayedHeader))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Server Errors Are Not Kept", func(t *testing.T) {
+		calls = 0
+		status = http.StatusInternalServerError
+		serve("b", `1`, nil)
+		status = http.StatusCreated
+		rr := serve("b", `1`, nil)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Key Too Long", func(t *testing.T) {
+		rr := serve(strings.Repeat("k", maxKeyLength+1), `1`, nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	existing, err := store.Claim("a", "f1", time.Hour, now)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = store.Claim("a", "f1", time.Hour, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.False(t, existing.Done)
+
+	t.Run("Stale Claims Are Taken Over", func(t *testing.T) {
+		existing, err := store.Claim("a", "f2", time.Hour, now.Add(staleClaim))
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("Completed Records Expire", func(t *testing.T) {
+		assert.NoError(t, store.Complete(Record{Key: "a", Fingerprint: "f2", Status: http.StatusOK}))
+		existing, err := store.Claim("a", "f3", time.Hour, now.Add(staleClaim+time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, existing.Done)
+		assert.Equal(t, "f2", existing.Fingerprint)
+
+		existing, err = store.Claim("a", "f3", time.Hour, now.Add(staleClaim+time.Hour))
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+}
@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collection = "idempotency_keys"
+
+// MongoStore keeps records in the system database, so that a retry is
+// recognised whichever replica it reaches.
+type MongoStore struct {
+	DB *mongo.Database
+}
+
+func (m *MongoStore) EnsureIndexes(window time.Duration) error {
+	coll := m.DB.Collection(collection)
+	_, err := coll.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err == nil {
+		// Claims can outlive the window when it is shorter than
+		// staleClaim, so records are kept for the longer of the two.
+		err = utils.EnsureTTLIndex(coll, "created_at", max(window, staleClaim))
+	}
+	if err != nil {
+		slog.Error("Failed to create idempotency indexes", "error", err)
+	}
+	return err
+}
+
+// Claim inserts a record for key, relying on the unique index to let only
+// one request in. Records that are no longer live are deleted first, since
+// the TTL monitor only runs once a minute.
+func (m *MongoStore) Claim(key string, fingerprint string, window time.Duration, now time.Time) (*Record, error) {
+	coll := m.DB.Collection(collection)
+	_, err := coll.DeleteOne(context.TODO(), bson.D{
+		{Key: "key", Value: key},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "done", Value: true}, {Key: "created_at", Value: bson.D{{Key: "$lte", Value: now.Add(-window)}}}},
+			bson.D{{Key: "done", Value: false}, {Key: "created_at", Value: bson.D{{Key: "$lte", Value: now.Add(-staleClaim)}}}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = coll.InsertOne(context.TODO(), Record{Key: key, Fingerprint: fingerprint, CreatedAt: now})
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var existing Record
+	err = coll.FindOne(context.TODO(), bson.D{{Key: "key", Value: key}}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		// The holder released the key in the meantime.
+		return nil, errors.New("idempotency key was released while claiming it")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (m *MongoStore) Complete(record Record) error {
+	_, err := m.DB.Collection(collection).UpdateOne(
+		context.TODO(),
+		bson.D{{Key: "key", Value: record.Key}, {Key: "fingerprint", Value: record.Fingerprint}, {Key: "done", Value: false}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "done", Value: true},
+			{Key: "status", Value: record.Status},
+			{Key: "content_type", Value: record.ContentType},
+			{Key: "body", Value: record.Body},
+		}}},
+	)
+	return err
+}
+
+func (m *MongoStore) Release(key string) error {
+	_, err := m.DB.Collection(collection).DeleteOne(
+		context.TODO(),
+		bson.D{{Key: "key", Value: key}, {Key: "done", Value: false}},
+	)
+	return err
+}
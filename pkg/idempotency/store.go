@@ -0,0 +1,100 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// staleClaim is how long a request may hold a key without finishing before
+// it is presumed lost, such as to a crash, and the key can be used again.
+const staleClaim = 5 * time.Minute
+
+// Record is what is kept for a key: the fingerprint of the request that
+// first used it and, once that request has finished, its response.
+type Record struct {
+	Key         string    `bson:"key"`
+	Fingerprint string    `bson:"fingerprint"`
+	Done        bool      `bson:"done"`
+	Status      int       `bson:"status,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// live reports whether r still holds its key at now.
+func (r *Record) live(window time.Duration, now time.Time) bool {
+	if r.Done {
+		return now.Sub(r.CreatedAt) < window
+	}
+	return now.Sub(r.CreatedAt) < staleClaim
+}
+
+type Store interface {
+	// Claim takes key for a request with fingerprint. If another request
+	// holds it, it returns that request's record instead and takes nothing.
+	Claim(key string, fingerprint string, window time.Duration, now time.Time) (*Record, error)
+	// Complete stores the response of the request that claimed record.Key.
+	Complete(record Record) error
+	// Release gives up a claim without storing a response, so that the
+	// request can be tried again.
+	Release(key string) error
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*Record
+	lastSweep time.Time
+}
+
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]*Record{}}
+}
+
+func (m *MemoryStore) Claim(key string, fingerprint string, window time.Duration, now time.Time) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(window, now)
+	}
+
+	if existing, ok := m.records[key]; ok && existing.live(window, now) {
+		record := *existing
+		return &record, nil
+	}
+	m.records[key] = &Record{Key: key, Fingerprint: fingerprint, CreatedAt: now}
+	return nil, nil
+}
+
+func (m *MemoryStore) Complete(record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[record.Key]; ok && existing.Fingerprint == record.Fingerprint && !existing.Done {
+		record.CreatedAt = existing.CreatedAt
+		record.Done = true
+		m.records[record.Key] = &record
+	}
+	return nil
+}
+
+func (m *MemoryStore) Release(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[key]; ok && !existing.Done {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func (m *MemoryStore) sweep(window time.Duration, now time.Time) {
+	for key, record := range m.records {
+		if !record.live(window, now) {
+			delete(m.records, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,131 @@
+// Package migrations applies one-off changes to stored data, such as
+// backfilling a new field, in order and at most once per database.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const migrationsCollection = "migrations"
+
+type Migration struct {
+	// Id orders migrations and must never change once released, such as
+	// "0001_backfill_slugs".
+	Id          string
+	Description string
+	Up          func(ctx context.Context, content *mongo.Database, system *mongo.Database) error
+}
+
+// All lists every migration in the order they are applied. Append new ones
+// to the end; none have been needed yet.
+var All = []Migration{}
+
+type Status struct {
+	Id          string     `json:"id"`
+	Description string     `json:"description"`
+	AppliedAt   *time.Time `json:"applied_at"`
+}
+
+type applied struct {
+	Id        string    `bson:"id"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+type Runner struct {
+	DB         *mongo.Database
+	SystemDB   *mongo.Database
+	Migrations []Migration
+}
+
+func NewRunner(db *mongo.Database, systemDB *mongo.Database) *Runner {
+	return &Runner{DB: db, SystemDB: systemDB, Migrations: All}
+}
+
+// Status reports every migration and when it was applied, if it has been.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	slog.Debug("Status called")
+	done, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(r.Migrations))
+	for i, m := range r.Migrations {
+		statuses[i] = Status{Id: m.Id, Description: m.Description}
+		if at, ok := done[m.Id]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// Up applies the pending migrations in order and returns the ids of those it
+// applied. It stops at the first one that fails.
+func (r *Runner) Up(ctx context.Context) ([]string, error) {
+	slog.Debug("Up called")
+	done, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ran := []string{}
+	for _, m := range pending(r.Migrations, done) {
+		slog.Info("Applying migration", "id", m.Id)
+		if err := m.Up(ctx, r.DB, r.SystemDB); err != nil {
+			err := fmt.Errorf("migration %s failed: %w", m.Id, err)
+			slog.Error("Failed to apply migration", "id", m.Id, "error", err)
+			return ran, err
+		}
+
+		_, err := r.SystemDB.Collection(migrationsCollection).UpdateOne(ctx,
+			bson.D{{Key: "id", Value: m.Id}},
+			bson.D{{Key: "$set", Value: applied{Id: m.Id, AppliedAt: time.Now().UTC()}}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			slog.Error("Failed to record migration", "id", m.Id, "error", err)
+			return ran, err
+		}
+		slog.Info("Migration applied successfully", "id", m.Id)
+		ran = append(ran, m.Id)
+	}
+	return ran, nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[string]time.Time, error) {
+	cursor, err := r.SystemDB.Collection(migrationsCollection).Find(ctx, bson.D{})
+	if err != nil {
+		slog.Error("Failed to find applied migrations", "error", err)
+		return nil, err
+	}
+
+	var records []applied
+	if err := cursor.All(ctx, &records); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode applied migrations", "error", err)
+		return nil, err
+	}
+
+	done := map[string]time.Time{}
+	for _, record := range records {
+		done[record.Id] = record.AppliedAt
+	}
+	return done, nil
+}
+
+func pending(all []Migration, done map[string]time.Time) []Migration {
+	var todo []Migration
+	for _, m := range all {
+		if _, ok := done[m.Id]; !ok {
+			todo = append(todo, m)
+		}
+	}
+	return todo
+}
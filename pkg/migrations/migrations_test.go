@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPending(t *testing.T) {
+	all := []Migration{{Id: "0001_a"}, {Id: "0002_b"}, {Id: "0003_c"}}
+	todo := pending(all, map[string]time.Time{"0002_b": time.Now()})
+
+	ids := []string{}
+	for _, m := range todo {
+		ids = append(ids, m.Id)
+	}
+	assert.Equal(t, []string{"0001_a", "0003_c"}, ids)
+	assert.Empty(t, pending(all, map[string]time.Time{"0001_a": {}, "0002_b": {}, "0003_c": {}}))
+}
+
+func TestMigrationIds(t *testing.T) {
+	ids := map[string]bool{}
+	for _, m := range All {
+		assert.False(t, ids[m.Id], "duplicate migration %s", m.Id)
+		ids[m.Id] = true
+		assert.NotNil(t, m.Up, m.Id)
+	}
+	assert.True(t, sort.SliceIsSorted(All, func(i, j int) bool { return All[i].Id < All[j].Id }))
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+type APIKey struct {
+	Id          string     `bson:"id" json:"id"`
+	Name        string     `bson:"name" json:"name"`
+	Prefix      string     `bson:"prefix" json:"prefix"`
+	Hash        string     `bson:"hash" json:"-"`
+	Collections []string   `bson:"collections" json:"collections"`
+	Operations  []string   `bson:"operations" json:"operations"`
+	ExpiresAt   *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+}
+
+type CreateAPIKey struct {
+	Name        string     `json:"name"`
+	Collections []string   `json:"collections"`
+	Operations  []string   `json:"operations"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type IssuedAPIKey struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
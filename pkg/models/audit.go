@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EventContentCreated    = "content.created"
+	EventContentUpdated    = "content.updated"
+	EventContentDeleted    = "content.deleted"
+	EventClassDeleted      = "class.deleted"
+	EventClassMoved        = "class.moved"
+	EventCollectionDeleted = "collection.deleted"
+)
+
+type AuditEntry struct {
+	Id         string         `bson:"id" json:"id"`
+	Action     string         `bson:"action" json:"action"`
+	Principal  string         `bson:"principal" json:"principal"`
+	RequestId  string         `bson:"request_id" json:"request_id"`
+	SourceIP   string         `bson:"source_ip" json:"source_ip"`
+	Timestamp  time.Time      `bson:"timestamp" json:"timestamp"`
+	Collection string         `bson:"collection" json:"collection"`
+	ContentId  string         `bson:"content_id,omitempty" json:"content_id,omitempty"`
+	Class      string         `bson:"class,omitempty" json:"class,omitempty"`
+	Ids        []string       `bson:"ids,omitempty" json:"ids,omitempty"`
+	Before     map[string]any `bson:"before,omitempty" json:"before,omitempty"`
+	After      map[string]any `bson:"after,omitempty" json:"after,omitempty"`
+}
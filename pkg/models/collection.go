@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// CollectionInfo is what is registered about a collection, to tell people
+// what it is for and who looks after it.
+type CollectionInfo struct {
+	Name        string    `bson:"name" json:"name"`
+	DisplayName string    `bson:"display_name" json:"display_name"`
+	Description string    `bson:"description" json:"description"`
+	Owner       string    `bson:"owner" json:"owner"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// CollectionStats describes the items of a collection. Collections that were
+// created by writing to them without being registered have no Info.
+type CollectionStats struct {
+	Name        string          `json:"name"`
+	Registered  bool            `json:"registered"`
+	Info        *CollectionInfo `json:"info,omitempty"`
+	Count       int64           `json:"count"`
+	DataSize    int64           `json:"data_size"`
+	StorageSize int64           `json:"storage_size"`
+	Classes     int             `json:"classes"`
+	Public      int64           `json:"public"`
+	Private     int64           `json:"private"`
+	PublicRatio float64         `json:"public_ratio"`
+	// LastModified is when an item was last created, updated or deleted. It
+	// is nil for a collection that has never had items.
+	LastModified *time.Time `json:"last_modified,omitempty"`
+	// ClassSummaries is only filled in for the stats of one collection.
+	ClassSummaries []ClassSummary `json:"class_summaries,omitempty"`
+}
@@ -15,6 +15,12 @@ type ReadContent struct {
 	CreatorId   string    `bson:"creator_id" json:"creator_id" validate:"required,uuid"`
 	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at" validate:"required"`
 	CreatedAt   time.Time `bson:"created_at" json:"created_at" validate:"required"`
+	// Fields are the custom fields of the item, checked against the schema
+	// of its class when it has one.
+	Fields map[string]any `bson:"fields,omitempty" json:"fields,omitempty"`
+	// SchemaVersion is the version of the schema the fields were last
+	// checked against, or 0 if the class had none.
+	SchemaVersion int `bson:"schema_version,omitempty" json:"schema_version,omitempty"`
 }
 
 type Content struct {
@@ -28,6 +34,12 @@ type Content struct {
 	CreatorId   string    `bson:"creator_id" json:"creator_id,omitempty" validate:"required,uuid"`
 	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at,omitempty" validate:"required"`
 	CreatedAt   time.Time `bson:"created_at" json:"created_at,omitempty" validate:"required"`
+	// Fields are the custom fields of the item, checked against the schema
+	// of its class when it has one.
+	Fields map[string]any `bson:"fields,omitempty" json:"fields,omitempty"`
+	// SchemaVersion is the version of the schema the fields were last
+	// checked against, or 0 if the class had none.
+	SchemaVersion int `bson:"schema_version,omitempty" json:"schema_version,omitempty"`
 }
 
 type UpdateContent struct {
@@ -40,6 +52,8 @@ type UpdateContent struct {
 	CreatorId   *string    `bson:"creator_id" json:"creator_id,omitempty" validate:"uuid"`
 	UpdatedAt   *time.Time `bson:"updated_at" json:"updated_at,omitempty"`
 	CreatedAt   *time.Time `bson:"created_at" json:"created_at,omitempty"`
+	// Fields replaces every custom field of the item when it is set.
+	Fields map[string]any `bson:"fields" json:"fields,omitempty"`
 }
 
 type JsonResponse struct {
@@ -47,3 +61,78 @@ type JsonResponse struct {
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
 }
+
+type SyncChange struct {
+	Id      string   `json:"id"`
+	Deleted bool     `json:"deleted"`
+	Content *Content `json:"content,omitempty"`
+}
+
+type SyncResponse struct {
+	Changes   []SyncChange `json:"changes"`
+	NextToken string       `json:"next_token"`
+	HasMore   bool         `json:"has_more"`
+}
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	Id      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun   bool `json:"dry_run"`
+	Lines    int  `json:"lines"`
+	Created  int  `json:"created"`
+	Replaced int  `json:"replaced"`
+	Skipped  int  `json:"skipped"`
+	Failed   int  `json:"failed"`
+	// Errors lists the failed lines, up to a limit. Truncated is set when
+	// there were more.
+	Errors    []ImportError `json:"errors"`
+	Truncated bool          `json:"truncated"`
+}
+
+// BulkUpdate is one item of a bulk update: the fields to change on the item
+// with Id.
+type BulkUpdate struct {
+	Id string `json:"id"`
+	UpdateContent
+}
+
+type BulkItemResult struct {
+	Index  int    `json:"index"`
+	Id     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkReport struct {
+	Atomic    bool             `json:"atomic"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// ClassSummary describes one of the classes of a collection.
+type ClassSummary struct {
+	Class     string    `bson:"_id" json:"class"`
+	Count     int64     `bson:"count" json:"count"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ClassMove asks for the items of a class, or only those with Ids, to be
+// moved to the class To.
+type ClassMove struct {
+	To  string   `json:"to"`
+	Ids []string `json:"ids,omitempty"`
+}
+
+// ClassMoveResult lists the items that were moved, and the requested ids
+// that were not in the class.
+type ClassMoveResult struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Moved   []string `json:"moved"`
+	Missing []string `json:"missing,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// ContentSchema is one version of the JSON Schema that the custom fields of
+// the items of a class must match. Registering a schema again adds a version
+// rather than replacing it.
+type ContentSchema struct {
+	Collection string         `json:"collection"`
+	Class      string         `json:"class"`
+	Version    int            `json:"version"`
+	Schema     map[string]any `json:"schema"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// SchemaReport tells which items of a class do not match a version of its
+// schema, so they can be migrated to it.
+type SchemaReport struct {
+	Collection string `json:"collection"`
+	Class      string `json:"class"`
+	Version    int    `json:"version"`
+	Checked    int    `json:"checked"`
+	Matching   int    `json:"matching"`
+	Failing    int    `json:"failing"`
+	// Outdated counts the items last checked against an earlier version, or
+	// written before the class had a schema, whether they match or not.
+	Outdated int `json:"outdated"`
+	// Items lists the failing items, up to a limit. Truncated is set when
+	// there were more.
+	Items     []SchemaReportItem `json:"items"`
+	Truncated bool               `json:"truncated"`
+}
+
+type SchemaReportItem struct {
+	Id            string   `json:"id"`
+	SchemaVersion int      `json:"schema_version"`
+	Errors        []string `json:"errors"`
+}
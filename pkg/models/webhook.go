@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+var WebhookEvents = []string{
+	EventContentCreated,
+	EventContentUpdated,
+	EventContentDeleted,
+	EventClassDeleted,
+	EventClassMoved,
+	EventCollectionDeleted,
+}
+
+type Webhook struct {
+	Id                  string     `bson:"id" json:"id"`
+	URL                 string     `bson:"url" json:"url"`
+	Secret              string     `bson:"secret" json:"-"`
+	Events              []string   `bson:"events" json:"events"`
+	Collection          string     `bson:"collection" json:"collection,omitempty"`
+	Class               string     `bson:"class" json:"class,omitempty"`
+	Active              bool       `bson:"active" json:"active"`
+	ConsecutiveFailures int        `bson:"consecutive_failures" json:"consecutive_failures"`
+	DisabledAt          *time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	UpdatedAt           time.Time  `bson:"updated_at" json:"updated_at"`
+	CreatedAt           time.Time  `bson:"created_at" json:"created_at"`
+}
+
+type CreateWebhook struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	Events     []string `json:"events"`
+	Collection string   `json:"collection,omitempty"`
+	Class      string   `json:"class,omitempty"`
+}
+
+type UpdateWebhook struct {
+	URL        *string   `bson:"url,omitempty" json:"url,omitempty"`
+	Secret     *string   `bson:"secret,omitempty" json:"secret,omitempty"`
+	Events     *[]string `bson:"events,omitempty" json:"events,omitempty"`
+	Collection *string   `bson:"collection,omitempty" json:"collection,omitempty"`
+	Class      *string   `bson:"class,omitempty" json:"class,omitempty"`
+	Active     *bool     `bson:"active,omitempty" json:"active,omitempty"`
+}
+
+type WebhookPayload struct {
+	Id         string `json:"id"`
+	Event      string `json:"event"`
+	Collection string `json:"collection"`
+	ContentId  string `json:"content_id,omitempty"`
+	Class      string `json:"class,omitempty"`
+	// PreviousClass is the class the items of a class.moved event left.
+	PreviousClass string    `json:"previous_class,omitempty"`
+	Ids           []string  `json:"ids,omitempty"`
+	Content       *Content  `json:"content,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type WebhookDelivery struct {
+	Id             string     `bson:"id" json:"id"`
+	WebhookId      string     `bson:"webhook_id" json:"webhook_id"`
+	EventId        string     `bson:"event_id" json:"event_id"`
+	Event          string     `bson:"event" json:"event"`
+	Payload        string     `bson:"payload" json:"payload"`
+	Status         string     `bson:"status" json:"status"`
+	Attempts       int        `bson:"attempts" json:"attempts"`
+	ResponseStatus int        `bson:"response_status,omitempty" json:"response_status,omitempty"`
+	LastError      string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	RedeliveryOf   string     `bson:"redelivery_of,omitempty" json:"redelivery_of,omitempty"`
+	NextAttemptAt  *time.Time `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `bson:"last_attempt_at,omitempty" json:"last_attempt_at,omitempty"`
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updated_at"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,319 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value any
+	// hasValue tells a null value from a missing one.
+	hasValue bool
+}
+
+func parseOperations(data []byte) ([]operation, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected an array of operations", ErrInvalidPatch)
+	}
+
+	ops := make([]operation, 0, len(list))
+	for i, item := range list {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: operation %d is not an object", ErrInvalidPatch, i)
+		}
+		var op operation
+		var okOp, okPath, okFrom bool
+		op.Op, okOp = fields["op"].(string)
+		op.Path, okPath = fields["path"].(string)
+		op.Value, op.hasValue = fields["value"]
+
+		switch {
+		case !okOp:
+			return nil, fmt.Errorf("%w: operation %d has no op", ErrInvalidPatch, i)
+		case !okPath:
+			return nil, fmt.Errorf("%w: operation %d has no path", ErrInvalidPatch, i)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if !op.hasValue {
+				return nil, fmt.Errorf("%w: %s operation %d has no value", ErrInvalidPatch, op.Op, i)
+			}
+		case "move", "copy":
+			if op.From, okFrom = fields["from"].(string); !okFrom {
+				return nil, fmt.Errorf("%w: %s operation %d has no from", ErrInvalidPatch, op.Op, i)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// Apply applies a JSON Patch to doc. Operations are applied in order, and
+// if any of them fails, including a test, the error is returned and doc is
+// left as it was.
+func Apply(doc []byte, jsonPatch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := parseOperations(jsonPatch)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalidPatch, i, err.Error())
+		}
+
+		switch op.Op {
+		case "add":
+			target, err = add(target, path, op.Value)
+		case "remove":
+			target, _, err = remove(target, path)
+		case "replace":
+			if _, err = get(target, path); err == nil {
+				target, _, err = remove(target, path)
+			}
+			if err == nil {
+				target, err = add(target, path, op.Value)
+			}
+		case "move":
+			var from pointer
+			if from, err = parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalidPatch, i, err.Error())
+			}
+			if from.isPrefixOf(path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: operation %d moves a value into itself", ErrInvalidPatch, i)
+			}
+			var value any
+			if target, value, err = remove(target, from); err == nil {
+				target, err = add(target, path, value)
+			}
+		case "copy":
+			var from pointer
+			if from, err = parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalidPatch, i, err.Error())
+			}
+			var value any
+			if value, err = get(target, from); err == nil {
+				target, err = add(target, path, deepCopy(value))
+			}
+		case "test":
+			var value any
+			if value, err = get(target, path); err == nil && !equal(value, op.Value) {
+				return nil, fmt.Errorf("%w: value at %q does not match", ErrTestFailed, op.Path)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %s", ErrTestFailed, i, err.Error())
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %s", ErrCannotApply, i, err.Error())
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// pointer is a parsed JSON Pointer (RFC 6901). The empty pointer refers to
+// the whole document.
+type pointer []string
+
+func parsePointer(s string) (pointer, error) {
+	if s == "" {
+		return pointer{}, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("pointer %q does not start with /", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(token, "~0", ""), "~1", ""), "~") {
+			return nil, fmt.Errorf("pointer %q has an invalid escape", s)
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func (p pointer) isPrefixOf(other pointer) bool {
+	if len(p) > len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p pointer) String() string {
+	var b strings.Builder
+	for _, token := range p {
+		b.WriteString("/" + strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// index parses an array index. With end set, "-" refers to the position past
+// the last element.
+func index(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	limit := length
+	if end {
+		limit++
+	}
+	if err != nil || i >= limit {
+		return 0, fmt.Errorf("array index %s out of range", token)
+	}
+	return i, nil
+}
+
+func get(doc any, p pointer) (any, error) {
+	for n, token := range p {
+		switch v := doc.(type) {
+		case map[string]any:
+			value, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", p[:n+1])
+			}
+			doc = value
+		case []any:
+			i, err := index(token, len(v), false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", p[:n+1], err.Error())
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("%s does not exist", p[:n+1])
+		}
+	}
+	return doc, nil
+}
+
+// add returns doc with value added at p, which must be in an existing object
+// or array.
+func add(doc any, p pointer, value any) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, p[:len(p)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := p[len(p)-1]
+
+	switch v := parent.(type) {
+	case map[string]any:
+		v[last] = value
+		return doc, nil
+	case []any:
+		i, err := index(last, len(v), true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", p, err.Error())
+		}
+		v = append(v, nil)
+		copy(v[i+1:], v[i:])
+		v[i] = value
+		return set(doc, p[:len(p)-1], v)
+	default:
+		return nil, fmt.Errorf("%s is not in an object or array", p)
+	}
+}
+
+// remove returns doc without the value at p, and that value.
+func remove(doc any, p pointer) (any, any, error) {
+	if len(p) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := get(doc, p[:len(p)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := p[len(p)-1]
+
+	switch v := parent.(type) {
+	case map[string]any:
+		value, ok := v[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s does not exist", p)
+		}
+		delete(v, last)
+		return doc, value, nil
+	case []any:
+		i, err := index(last, len(v), false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", p, err.Error())
+		}
+		value := v[i]
+		doc, err = set(doc, p[:len(p)-1], append(v[:i:i], v[i+1:]...))
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("%s does not exist", p)
+	}
+}
+
+// set replaces the value at p, which exists, since arrays change length by
+// being replaced.
+func set(doc any, p pointer, value any) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, p[:len(p)-1])
+	if err != nil {
+		return nil, err
+	}
+	switch v := parent.(type) {
+	case map[string]any:
+		v[p[len(p)-1]] = value
+	case []any:
+		i, err := index(p[len(p)-1], len(v), false)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = value
+	}
+	return doc, nil
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, value := range v {
+			result[key] = deepCopy(value)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, value := range v {
+			result[i] = deepCopy(value)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,119 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON documents.
+//
+// Both work on the generic form of a document, so they can change any field,
+// including clearing one, without knowing the type it will be decoded into.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned for patch documents that are malformed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed is returned when a JSON Patch test operation does not
+	// match the document, so that the patch is not applied.
+	ErrTestFailed = errors.New("test operation failed")
+	// ErrCannotApply is returned when an operation refers to a location the
+	// document does not have.
+	ErrCannotApply = errors.New("patch cannot be applied")
+)
+
+// decode parses one JSON value, keeping numbers as json.Number so that they
+// are written back exactly as they were.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("body must have only a single JSON value")
+	}
+	return v, nil
+}
+
+// Merge applies a JSON Merge Patch to doc. Members of the patch replace
+// those of doc, objects are merged recursively, and null removes a member.
+func Merge(doc []byte, mergePatch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(mergePatch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target any, p any) any {
+	members, ok := p.(map[string]any)
+	if !ok {
+		return p
+	}
+	result, ok := target.(map[string]any)
+	if !ok {
+		result = map[string]any{}
+	}
+	for key, value := range members {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = merge(result[key], value)
+		}
+	}
+	return result
+}
+
+// equal compares JSON values the way a test operation does: numbers by
+// value and objects regardless of member order.
+func equal(a any, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okX := new(big.Rat).SetString(a.String())
+		y, okY := new(big.Rat).SetString(b.String())
+		return okX && okY && x.Cmp(y) == 0
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,102 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	// The examples of RFC 7396, appendix A.
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"views":12345678901234567890}`, `{"title":"x"}`, `{"views":12345678901234567890,"title":"x"}`},
+	}
+
+	for _, tt := range tests {
+		out, err := Merge([]byte(tt.doc), []byte(tt.patch))
+		assert.NoError(t, err, tt.patch)
+		assert.JSONEq(t, tt.want, string(out), tt.patch)
+	}
+
+	_, err := Merge([]byte(`{}`), []byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	// Mostly the examples of RFC 6902, appendix A.
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"Add Member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"Add Element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"Remove Member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"Remove Element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"Move Member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"Move Element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"Test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"Add Nested", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"Escaped Pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"copy","from":"/~1","path":"/x"}]`, `{"/":9,"~1":10,"x":9}`},
+		{"Append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"Null Value", `{"title":"x"}`, `[{"op":"replace","path":"/title","value":null}]`, `{"title":null}`},
+		{"Whole Document", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`},
+		{"Copy Is Independent", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(out))
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		errs := []struct {
+			doc   string
+			patch string
+			want  error
+		}{
+			{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed},
+			{`{"baz":"qux"}`, `[{"op":"test","path":"/missing","value":"bar"}]`, ErrTestFailed},
+			{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrCannotApply},
+			{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`, ErrCannotApply},
+			{`{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/01"}]`, ErrCannotApply},
+			{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"qux"}]`, ErrCannotApply},
+			{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ErrCannotApply},
+			{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ErrInvalidPatch},
+			{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, ErrInvalidPatch},
+			{`{"foo":"bar"}`, `[{"op":"frobnicate","path":"/baz"}]`, ErrInvalidPatch},
+			{`{"foo":"bar"}`, `[{"op":"copy","path":"/baz"}]`, ErrInvalidPatch},
+			{`{"foo":"bar"}`, `[{"op":"remove","path":"baz"}]`, ErrInvalidPatch},
+			{`{"foo":"bar"}`, `[{"op":"remove","path":"/~2"}]`, ErrInvalidPatch},
+			{`{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`, ErrInvalidPatch},
+		}
+		for _, tt := range errs {
+			_, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.want, tt.patch)
+		}
+	})
+}
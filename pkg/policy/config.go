@@ -0,0 +1,86 @@
+// Package policy decides which collections may exist, so that a typo in a
+// URL or a reserved name does not quietly become a new collection.
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	// Patterns are the globs, in path.Match syntax, that collection names
+	// must match. With none, any valid name is allowed.
+	Patterns []string
+	// Reserved are globs of names no collection may have, on top of the
+	// system.* names MongoDB keeps for itself.
+	Reserved []string
+	// RequireRegistration keeps writes from creating collections that an
+	// admin has not registered.
+	RequireRegistration bool
+	// MaxCollections caps how many collections may exist or be registered.
+	// Zero means no limit.
+	MaxCollections int
+}
+
+var DefaultConfig = Config{}
+
+func ConfigFromEnv() (Config, error) {
+	slog.Debug("Loading collection policy environment variables...")
+	cfg := DefaultConfig
+
+	globs := map[string]*[]string{
+		"YAN_CMS_COLLECTION_PATTERNS":  &cfg.Patterns,
+		"YAN_CMS_RESERVED_COLLECTIONS": &cfg.Reserved,
+	}
+	for env, target := range globs {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		patterns, err := parsePatterns(value)
+		if err != nil {
+			slog.Error("Invalid collection pattern", "env", env, "error", err)
+			return Config{}, err
+		}
+		*target = patterns
+	}
+
+	cfg.RequireRegistration = os.Getenv("YAN_CMS_REQUIRE_COLLECTION_REGISTRATION") == "true"
+
+	if value := os.Getenv("YAN_CMS_MAX_COLLECTIONS"); value != "" {
+		max, err := strconv.Atoi(value)
+		if err != nil || max < 0 {
+			err := fmt.Errorf("invalid count %q in YAN_CMS_MAX_COLLECTIONS", value)
+			slog.Error("Invalid collection limit", "error", err)
+			return Config{}, err
+		}
+		cfg.MaxCollections = max
+	}
+
+	slog.Info("Collection policy configured",
+		"patterns", cfg.Patterns,
+		"reserved", cfg.Reserved,
+		"require_registration", cfg.RequireRegistration,
+		"max_collections", cfg.MaxCollections,
+	)
+	return cfg, nil
+}
+
+func parsePatterns(value string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid collection pattern %q", pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
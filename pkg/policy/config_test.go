@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("YAN_CMS_COLLECTION_PATTERNS", "")
+		t.Setenv("YAN_CMS_RESERVED_COLLECTIONS", "")
+		t.Setenv("YAN_CMS_REQUIRE_COLLECTION_REGISTRATION", "")
+		t.Setenv("YAN_CMS_MAX_COLLECTIONS", "")
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultConfig, cfg)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		t.Setenv("YAN_CMS_COLLECTION_PATTERNS", "lessons, course-*,")
+		t.Setenv("YAN_CMS_RESERVED_COLLECTIONS", "admin")
+		t.Setenv("YAN_CMS_REQUIRE_COLLECTION_REGISTRATION", "true")
+		t.Setenv("YAN_CMS_MAX_COLLECTIONS", "20")
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"lessons", "course-*"}, cfg.Patterns)
+		assert.Equal(t, []string{"admin"}, cfg.Reserved)
+		assert.True(t, cfg.RequireRegistration)
+		assert.Equal(t, 20, cfg.MaxCollections)
+	})
+
+	t.Run("Invalid Pattern", func(t *testing.T) {
+		t.Setenv("YAN_CMS_COLLECTION_PATTERNS", "course-[")
+		_, err := ConfigFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		t.Setenv("YAN_CMS_COLLECTION_PATTERNS", "")
+		t.Setenv("YAN_CMS_MAX_COLLECTIONS", "-1")
+		_, err := ConfigFromEnv()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,27 @@
+package policy
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Middleware checks the {collection} route parameter against the policy, so
+// it has to run inside the routed handler chain.
+func Middleware(p *Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			coll := chi.URLParam(r, "collection")
+			if err := p.Check(coll, auth.Operation(r.Method)); err != nil {
+				slog.Error("Collection policy check failed", "collection", coll, "error", err)
+				utils.Error(w, r, err, Status(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/repositories/collections"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoCatalog looks collections up in the content database and the registry
+// in the system database. Without a system database nothing is registered.
+type MongoCatalog struct {
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+}
+
+func (m *MongoCatalog) Exists(name string) (bool, error) {
+	names, err := m.DB.ListCollectionNames(context.TODO(), bson.D{{Key: "name", Value: name}})
+	if err != nil {
+		return false, err
+	}
+	return len(names) > 0, nil
+}
+
+func (m *MongoCatalog) Registered(name string) (bool, error) {
+	if m.SystemDB == nil {
+		return false, nil
+	}
+	repo := collections.CollectionRepository{DB: m.SystemDB}
+	_, err := repo.Get(name)
+	if errors.Is(err, collections.ErrCollectionNotRegistered) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (m *MongoCatalog) Count() (int, error) {
+	seen, err := m.existing()
+	if err != nil {
+		return 0, err
+	}
+	if m.SystemDB != nil {
+		registry := collections.CollectionRepository{DB: m.SystemDB}
+		reserved, err := registry.Reserved(time.Now().UTC())
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range reserved {
+			seen[name] = true
+		}
+	}
+	return len(seen), nil
+}
+
+// Reserve holds a place for name in the system database, which lets only
+// one name take each place. Without a system database it only counts.
+func (m *MongoCatalog) Reserve(name string, limit int) (bool, error) {
+	seen, err := m.existing()
+	if err != nil {
+		return false, err
+	}
+	if seen[name] {
+		return true, nil
+	}
+	if m.SystemDB == nil {
+		return len(seen) < limit, nil
+	}
+
+	existing := make([]string, 0, len(seen))
+	for other := range seen {
+		existing = append(existing, other)
+	}
+	registry := collections.CollectionRepository{DB: m.SystemDB}
+	return registry.Reserve(name, existing, limit, time.Now().UTC())
+}
+
+// existing returns the names of the collections in the content database and
+// in the registry.
+func (m *MongoCatalog) existing() (map[string]bool, error) {
+	repo := repositories.ContentRepository{DB: m.DB}
+	names, err := repo.ListCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	if m.SystemDB != nil {
+		registry := collections.CollectionRepository{DB: m.SystemDB}
+		registered, err := registry.List()
+		if err != nil {
+			return nil, err
+		}
+		for name := range registered {
+			seen[name] = true
+		}
+	}
+	return seen, nil
+}
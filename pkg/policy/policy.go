@@ -0,0 +1,213 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+)
+
+// maxNameLength keeps names well inside MongoDB's limit on namespaces, which
+// also counts the database name.
+const maxNameLength = 120
+
+var (
+	ErrInvalidName        = errors.New("invalid collection name")
+	ErrReservedName       = errors.New("collection name is reserved")
+	ErrNotRegistered      = errors.New("collection is not registered")
+	ErrTooManyCollections = errors.New("too many collections")
+)
+
+var (
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]([A-Za-z0-9_.-]*[A-Za-z0-9_-])?$`)
+	// builtinReserved are the names MongoDB keeps for itself.
+	builtinReserved = []string{"system.*"}
+)
+
+// CheckName reports whether a new collection may be called name, without
+// looking at which collections exist.
+func (c Config) CheckName(name string) error {
+	if err := c.validate(name); err != nil {
+		return err
+	}
+	if len(c.Patterns) > 0 && !matchesAny(c.Patterns, name) {
+		return fmt.Errorf("%w %q, it must match one of %s", ErrInvalidName, name, strings.Join(c.Patterns, ", "))
+	}
+	return nil
+}
+
+// validate reports whether name is one that no collection may have, whatever
+// the patterns allow.
+func (c Config) validate(name string) error {
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w %q, it must not be longer than %d characters", ErrInvalidName, name, maxNameLength)
+	}
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w %q, it may only hold letters, digits, '_', '-' and '.', and must not start or end with '.'", ErrInvalidName, name)
+	}
+	if matchesAny(builtinReserved, name) || matchesAny(c.Reserved, name) {
+		return fmt.Errorf("%w: %s", ErrReservedName, name)
+	}
+	return nil
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Catalog tells the policy which collections exist and which are registered.
+type Catalog interface {
+	Exists(name string) (bool, error)
+	Registered(name string) (bool, error)
+	// Count returns how many collections exist, are registered or are
+	// reserved, counting each name once.
+	Count() (int, error)
+	// Reserve claims a place for name among limit collections, so that
+	// writes creating collections at the same time cannot together go past
+	// the limit. It reports false when there is none left.
+	Reserve(name string, limit int) (bool, error)
+}
+
+// knownTTL is how long a collection seen to exist is taken to still exist,
+// without looking it up again.
+const knownTTL = time.Minute
+
+type Policy struct {
+	Config  Config
+	Catalog Catalog
+
+	// known holds when each collection was last seen to exist, so that
+	// writes to it do not each look it up.
+	mu    sync.Mutex
+	known map[string]time.Time
+}
+
+func New(cfg Config, catalog Catalog) *Policy {
+	return &Policy{Config: cfg, Catalog: catalog, known: map[string]time.Time{}}
+}
+
+// Check reports whether op may be done on the collection name. Reads and
+// deletes only need a name that is not reserved, so collections made before
+// the patterns changed can still be read and cleaned up. Writes to a
+// collection that does not exist yet would create it, so they are also held
+// to the patterns, registration and the limit on collections. A nil policy
+// allows everything.
+func (p *Policy) Check(name string, op string) error {
+	if p == nil {
+		return nil
+	}
+	if op == auth.OpWrite {
+		return p.CheckWrite(name)
+	}
+	return p.Config.validate(name)
+}
+
+// CheckWrite reports whether items may be written to the collection name.
+func (p *Policy) CheckWrite(name string) error {
+	if p == nil {
+		return nil
+	}
+	return p.checkNew(name, p.Config.RequireRegistration)
+}
+
+// CheckRegister reports whether the collection name may be registered.
+func (p *Policy) CheckRegister(name string) error {
+	if p == nil {
+		return nil
+	}
+	return p.checkNew(name, false)
+}
+
+// checkNew reports whether name is allowed and, when no collection has it
+// yet, whether one may be made with it.
+func (p *Policy) checkNew(name string, requireRegistration bool) error {
+	if err := p.Config.validate(name); err != nil {
+		return err
+	}
+	if len(p.Config.Patterns) == 0 && !requireRegistration && p.Config.MaxCollections == 0 {
+		return nil
+	}
+
+	if p.knows(name) {
+		return nil
+	}
+	exists, err := p.Catalog.Exists(name)
+	if err != nil || exists {
+		if exists {
+			p.remember(name)
+		}
+		return err
+	}
+	if err := p.Config.CheckName(name); err != nil {
+		return err
+	}
+	if !requireRegistration && p.Config.MaxCollections == 0 {
+		return nil
+	}
+	registered, err := p.Catalog.Registered(name)
+	if err != nil || registered {
+		return err
+	}
+	if requireRegistration {
+		return fmt.Errorf("%w: %s, an admin has to register it before items are written to it", ErrNotRegistered, name)
+	}
+	return p.checkLimit(name)
+}
+
+// checkLimit reserves a place under the limit for one more collection,
+// name.
+func (p *Policy) checkLimit(name string) error {
+	reserved, err := p.Catalog.Reserve(name, p.Config.MaxCollections)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		return fmt.Errorf("%w, %s would be past the limit of %d", ErrTooManyCollections, name, p.Config.MaxCollections)
+	}
+	return nil
+}
+
+func (p *Policy) knows(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seen, ok := p.known[name]
+	if ok && time.Since(seen) >= knownTTL {
+		delete(p.known, name)
+		return false
+	}
+	return ok
+}
+
+func (p *Policy) remember(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.known == nil {
+		p.known = map[string]time.Time{}
+	}
+	p.known[name] = time.Now()
+}
+
+// Status returns the HTTP status for an error from the policy.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrReservedName):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotRegistered):
+		return http.StatusNotFound
+	case errors.Is(err, ErrTooManyCollections):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCatalog struct {
+	existing   map[string]bool
+	registered map[string]bool
+	reserved   map[string]bool
+	lookups    int
+}
+
+func (f *fakeCatalog) Exists(name string) (bool, error) {
+	f.lookups++
+	return f.existing[name], nil
+}
+
+func (f *fakeCatalog) Registered(name string) (bool, error) {
+	return f.registered[name], nil
+}
+
+func (f *fakeCatalog) Count() (int, error) {
+	seen := map[string]bool{}
+	for name := range f.existing {
+		seen[name] = true
+	}
+	for name := range f.registered {
+		seen[name] = true
+	}
+	for name := range f.reserved {
+		seen[name] = true
+	}
+	return len(seen), nil
+}
+
+func (f *fakeCatalog) Reserve(name string, limit int) (bool, error) {
+	if f.reserved[name] {
+		return true, nil
+	}
+	count, _ := f.Count()
+	if count >= limit {
+		return false, nil
+	}
+	f.reserved[name] = true
+	return true, nil
+}
+
+func newCatalog() *fakeCatalog {
+	return &fakeCatalog{
+		existing:   map[string]bool{"lessons": true, "legacy_notes": true},
+		registered: map[string]bool{"lessons": true, "course-go": true},
+		reserved:   map[string]bool{},
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	cfg := Config{Patterns: []string{"lessons", "course-*"}, Reserved: []string{"course-internal"}}
+
+	assert.NoError(t, cfg.CheckName("lessons"))
+	assert.NoError(t, cfg.CheckName("course-go"))
+	assert.ErrorIs(t, cfg.CheckName("lesons"), ErrInvalidName)
+	assert.ErrorIs(t, cfg.CheckName("course-internal"), ErrReservedName)
+	assert.ErrorIs(t, DefaultConfig.CheckName("system.users"), ErrReservedName)
+	assert.ErrorIs(t, DefaultConfig.CheckName(".lessons"), ErrInvalidName)
+	assert.ErrorIs(t, DefaultConfig.CheckName("lessons."), ErrInvalidName)
+	assert.ErrorIs(t, DefaultConfig.CheckName("les$sons"), ErrInvalidName)
+	assert.ErrorIs(t, DefaultConfig.CheckName(""), ErrInvalidName)
+	assert.ErrorIs(t, DefaultConfig.CheckName(strings.Repeat("a", maxNameLength+1)), ErrInvalidName)
+	assert.NoError(t, DefaultConfig.CheckName("course.v2"))
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("Nil Policy", func(t *testing.T) {
+		var p *Policy
+		assert.NoError(t, p.Check("system.users", auth.OpWrite))
+	})
+
+	t.Run("Reads Existing Collections Outside Patterns", func(t *testing.T) {
+		p := New(Config{Patterns: []string{"lessons"}}, newCatalog())
+		assert.NoError(t, p.Check("legacy_notes", auth.OpRead))
+		assert.NoError(t, p.Check("legacy_notes", auth.OpDelete))
+		assert.NoError(t, p.Check("legacy_notes", auth.OpWrite))
+		assert.ErrorIs(t, p.Check("system.users", auth.OpRead), ErrReservedName)
+	})
+
+	t.Run("Writes New Collections Within Patterns", func(t *testing.T) {
+		p := New(Config{Patterns: []string{"lessons", "course-*"}}, newCatalog())
+		assert.NoError(t, p.Check("course-rust", auth.OpWrite))
+		assert.ErrorIs(t, p.Check("lesons", auth.OpWrite), ErrInvalidName)
+	})
+
+	t.Run("Requires Registration", func(t *testing.T) {
+		p := New(Config{RequireRegistration: true}, newCatalog())
+		assert.NoError(t, p.Check("lessons", auth.OpWrite))
+		assert.NoError(t, p.Check("legacy_notes", auth.OpWrite))
+		assert.NoError(t, p.Check("course-go", auth.OpWrite))
+		assert.ErrorIs(t, p.Check("course-rust", auth.OpWrite), ErrNotRegistered)
+		assert.NoError(t, p.CheckRegister("course-rust"))
+	})
+
+	t.Run("Max Collections", func(t *testing.T) {
+		p := New(Config{MaxCollections: 3}, newCatalog())
+		assert.NoError(t, p.Check("lessons", auth.OpWrite))
+		assert.NoError(t, p.Check("course-go", auth.OpWrite))
+		assert.ErrorIs(t, p.Check("course-rust", auth.OpWrite), ErrTooManyCollections)
+		assert.ErrorIs(t, p.CheckRegister("course-rust"), ErrTooManyCollections)
+		assert.NoError(t, p.CheckRegister("course-go"))
+
+		p.Config.MaxCollections = 4
+		assert.NoError(t, p.Check("course-rust", auth.OpWrite))
+		assert.NoError(t, p.Check("course-rust", auth.OpWrite))
+		assert.ErrorIs(t, p.Check("course-zig", auth.OpWrite), ErrTooManyCollections)
+	})
+
+	t.Run("Remembers Existing Collections", func(t *testing.T) {
+		catalog := newCatalog()
+		p := New(Config{MaxCollections: 3}, catalog)
+		assert.NoError(t, p.Check("lessons", auth.OpWrite))
+		assert.NoError(t, p.Check("lessons", auth.OpWrite))
+		assert.Equal(t, 1, catalog.lookups)
+
+		p.known["lessons"] = time.Now().Add(-knownTTL)
+		assert.NoError(t, p.Check("lessons", auth.OpWrite))
+		assert.Equal(t, 2, catalog.lookups)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	p := New(Config{RequireRegistration: true, MaxCollections: 3}, newCatalog())
+	router := chi.NewRouter()
+	router.Group(func(router chi.Router) {
+		router.Use(Middleware(p))
+		router.Get("/contents/{collection}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		router.Post("/contents/{collection}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+
+	serve := func(method string, coll string) int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, "/contents/"+coll, nil))
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, serve("POST", "lessons"))
+	assert.Equal(t, http.StatusOK, serve("GET", "course-rust"))
+	assert.Equal(t, http.StatusNotFound, serve("POST", "course-rust"))
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "system.users"))
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "les$sons"))
+
+	p.Config.RequireRegistration = false
+	assert.Equal(t, http.StatusConflict, serve("POST", "course-rust"))
+}
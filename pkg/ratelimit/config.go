@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+type Config struct {
+	Read   Limit
+	Write  Limit
+	Delete Limit
+	Shared bool
+}
+
+var DefaultConfig = Config{
+	Read:   Limit{Requests: 600, Per: time.Minute},
+	Write:  Limit{Requests: 120, Per: time.Minute},
+	Delete: Limit{Requests: 30, Per: time.Minute},
+}
+
+func ParseLimit(s string) (Limit, error) {
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, per, found := strings.Cut(s, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<duration>", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", s)
+	}
+
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid duration in rate limit %q", s)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+func ConfigFromEnv() (Config, error) {
+	slog.Debug("Loading rate limit environment variables...")
+	cfg := DefaultConfig
+
+	limits := map[string]*Limit{
+		"YAN_CMS_RATE_LIMIT_READ":   &cfg.Read,
+		"YAN_CMS_RATE_LIMIT_WRITE":  &cfg.Write,
+		"YAN_CMS_RATE_LIMIT_DELETE": &cfg.Delete,
+	}
+	for env, limit := range limits {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		parsed, err := ParseLimit(value)
+		if err != nil {
+			slog.Error("Invalid rate limit", "env", env, "error", err)
+			return Config{}, err
+		}
+		*limit = parsed
+	}
+
+	cfg.Shared = os.Getenv("YAN_CMS_RATE_LIMIT_SHARED") == "true"
+
+	slog.Info("Rate limits configured", "read", cfg.Read, "write", cfg.Write, "delete", cfg.Delete, "shared", cfg.Shared)
+	return cfg, nil
+}
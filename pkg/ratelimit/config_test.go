@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		Case     string
+		Input    string
+		Expected Limit
+		Error    bool
+	}{
+		{"Per Minute", "100/1m", Limit{Requests: 100, Per: time.Minute}, false},
+		{"Per Second", "5/1s", Limit{Requests: 5, Per: time.Second}, false},
+		{"Disabled", "off", Limit{}, false},
+		{"Missing Duration", "100", Limit{}, true},
+		{"Invalid Count", "abc/1m", Limit{}, true},
+		{"Invalid Duration", "100/soon", Limit{}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Case, func(t *testing.T) {
+			limit, err := ParseLimit(tc.Input)
+			if tc.Error {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, limit)
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultConfig, cfg)
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		os.Setenv("YAN_CMS_RATE_LIMIT_DELETE", "1/1h")
+		os.Setenv("YAN_CMS_RATE_LIMIT_SHARED", "true")
+		defer os.Unsetenv("YAN_CMS_RATE_LIMIT_DELETE")
+		defer os.Unsetenv("YAN_CMS_RATE_LIMIT_SHARED")
+
+		cfg, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, Limit{Requests: 1, Per: time.Hour}, cfg.Delete)
+		assert.Equal(t, DefaultConfig.Read, cfg.Read)
+		assert.True(t, cfg.Shared)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		os.Setenv("YAN_CMS_RATE_LIMIT_READ", "lots")
+		defer os.Unsetenv("YAN_CMS_RATE_LIMIT_READ")
+
+		_, err := ConfigFromEnv()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/utils"
+)
+
+func Middleware(cfg Config, store Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op := auth.Operation(r.Method)
+			limit := cfg.LimitFor(op)
+			if !limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := op + ":" + ClientKey(r)
+			result, err := store.Take(key, limit, time.Now())
+			if err != nil {
+				slog.Error("Rate limiter unavailable, allowing request", "key", key, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Per)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				err := errors.New("rate limit exceeded")
+				slog.Warn("Rate limit exceeded", "key", key)
+				utils.Error(w, r, err, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitFor returns the limit that applies to an operation.
+func (c Config) LimitFor(op string) Limit {
+	switch op {
+	case auth.OpWrite:
+		return c.Write
+	case auth.OpDelete:
+		return c.Delete
+	default:
+		return c.Read
+	}
+}
+
+func ClientKey(r *http.Request) string {
+	return Key(auth.FromContext(r.Context()), utils.ClientIP(r))
+}
+
+// Key identifies a client by its principal, or by its address when it is
+// anonymous, for callers other than HTTP handlers.
+func Key(principal *auth.Principal, ip string) string {
+	if principal.Kind != auth.KindAnonymous {
+		return principal.String()
+	}
+	return "ip:" + ip
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
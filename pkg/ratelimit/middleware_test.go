@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	cfg := Config{
+		Read:   Limit{Requests: 2, Per: time.Minute},
+		Delete: Limit{Requests: 1, Per: time.Minute},
+	}
+	handler := Middleware(cfg, NewMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method string, remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/contents/lessons", nil)
+		req.RemoteAddr = remoteAddr
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Sets Headers", func(t *testing.T) {
+		rr := serve("GET", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "2;w=60", rr.Header().Get("RateLimit-Policy"))
+	})
+
+	t.Run("Rejects When Exhausted", func(t *testing.T) {
+		serve("GET", "10.0.0.1:1234", nil)
+		rr := serve("GET", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("Separate Limits Per Operation", func(t *testing.T) {
+		rr := serve("DELETE", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = serve("DELETE", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	})
+
+	t.Run("Unlimited Operation", func(t *testing.T) {
+		rr := serve("POST", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Keyed By Principal", func(t *testing.T) {
+		key := &auth.Principal{Kind: auth.KindAPIKey, Id: "build-pipeline"}
+		rr := serve("GET", "10.0.0.1:1234", key)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Keyed By IP", func(t *testing.T) {
+		rr := serve("GET", "10.0.0.2:1234", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
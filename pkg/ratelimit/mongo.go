@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collection = "rate_limits"
+
+type MongoStore struct {
+	DB *mongo.Database
+}
+
+type bucketDocument struct {
+	Key     string    `bson:"key"`
+	Tokens  float64   `bson:"tokens"`
+	Allowed bool      `bson:"allowed"`
+	Updated time.Time `bson:"updated_at"`
+}
+
+func (m *MongoStore) EnsureIndexes(ttl time.Duration) error {
+	coll := m.DB.Collection(collection)
+	_, err := coll.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err == nil {
+		err = utils.EnsureTTLIndex(coll, "updated_at", ttl)
+	}
+	if err != nil {
+		slog.Error("Failed to create rate limit indexes", "error", err)
+	}
+	return err
+}
+
+// Take refills and takes from the bucket in a single pipeline update, so
+// concurrent replicas never read and write a stale token count.
+func (m *MongoStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	capacity := float64(limit.Requests)
+	elapsedSeconds := bson.D{{Key: "$divide", Value: bson.A{
+		bson.D{{Key: "$subtract", Value: bson.A{now, bson.D{{Key: "$ifNull", Value: bson.A{"$updated_at", now}}}}}},
+		1000,
+	}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "key", Value: key},
+			{Key: "tokens", Value: bson.D{{Key: "$min", Value: bson.A{
+				capacity,
+				bson.D{{Key: "$add", Value: bson.A{
+					bson.D{{Key: "$ifNull", Value: bson.A{"$tokens", capacity}}},
+					bson.D{{Key: "$multiply", Value: bson.A{elapsedSeconds, limit.rate()}}},
+				}}},
+			}}}},
+			{Key: "updated_at", Value: now},
+		}}},
+		{{Key: "$set", Value: bson.D{
+			{Key: "allowed", Value: bson.D{{Key: "$gte", Value: bson.A{"$tokens", 1}}}},
+		}}},
+		{{Key: "$set", Value: bson.D{
+			{Key: "tokens", Value: bson.D{{Key: "$cond", Value: bson.A{
+				"$allowed",
+				bson.D{{Key: "$subtract", Value: bson.A{"$tokens", 1}}},
+				"$tokens",
+			}}}},
+		}}},
+	}
+
+	var doc bucketDocument
+	err := m.DB.Collection(collection).FindOneAndUpdate(
+		context.TODO(),
+		bson.D{{Key: "key", Value: key}},
+		pipeline,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		slog.Error("Failed to update rate limit bucket", "key", key, "error", err)
+		return Result{}, err
+	}
+
+	return newResult(limit, doc.Tokens, doc.Allowed), nil
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.rate()
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// per is the refill period of the limit the bucket was last taken
+	// from, after which it is full again.
+	per time.Duration
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (m *MemoryStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	capacity := float64(limit.Requests)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*limit.rate())
+	b.last = now
+	b.per = limit.Per
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return newResult(limit, b.tokens, allowed), nil
+}
+
+// sweep drops buckets that have been idle long enough to refill completely,
+// since a fresh bucket behaves the same way.
+func (m *MemoryStore) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if now.Sub(b.last) > b.per {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+	now := time.Now()
+
+	t.Run("Drains Bucket", func(t *testing.T) {
+		store := NewMemoryStore()
+		for i := 2; i >= 0; i-- {
+			result, err := store.Take("client", limit, now)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, i, result.Remaining)
+		}
+
+		result, err := store.Take("client", limit, now)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, time.Second, result.RetryAfter)
+		assert.Equal(t, 3*time.Second, result.Reset)
+	})
+
+	t.Run("Refills Over Time", func(t *testing.T) {
+		store := NewMemoryStore()
+		for i := 0; i < 3; i++ {
+			store.Take("client", limit, now)
+		}
+
+		result, _ := store.Take("client", limit, now.Add(time.Second))
+		assert.True(t, result.Allowed)
+
+		result, _ = store.Take("client", limit, now.Add(time.Second))
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("Keys Are Independent", func(t *testing.T) {
+		store := NewMemoryStore()
+		for i := 0; i < 3; i++ {
+			store.Take("first", limit, now)
+		}
+
+		result, _ := store.Take("second", limit, now)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("Sweeps Idle Buckets", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.Take("idle", limit, now)
+		store.Take("active", limit, now.Add(2*time.Minute))
+
+		assert.NotContains(t, store.buckets, "idle")
+		assert.Contains(t, store.buckets, "active")
+	})
+
+	t.Run("Sweeps By Each Bucket's Limit", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.Take("hourly", Limit{Requests: 3, Per: time.Hour}, now)
+		store.Take("active", limit, now.Add(2*time.Minute))
+
+		assert.Contains(t, store.buckets, "hourly")
+	})
+}
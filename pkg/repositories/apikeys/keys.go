@@ -0,0 +1,157 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes indexes keys by hash, which every authenticated request looks
+// up, and by id.
+func (r *APIKeyRepository) EnsureIndexes() error {
+	_, err := r.DB.Collection(collection).Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		slog.Error("Failed to create api key indexes", "error", err)
+	}
+	return err
+}
+
+func (r *APIKeyRepository) CreateKey(key *models.APIKey) (string, error) {
+	slog.Debug("CreateKey called", "id", key.Id, "name", key.Name)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	_, err := r.DB.Collection(collection).InsertOne(context.TODO(), key)
+	if err != nil {
+		slog.Error("Failed to insert api key", "id", key.Id, "error", err)
+		return "", err
+	}
+
+	slog.Info("API key created successfully", "id", key.Id, "name", key.Name)
+	return key.Id, nil
+}
+
+func (r *APIKeyRepository) GetKey(id string) (*models.APIKey, error) {
+	slog.Debug("GetKey called", "id", id)
+	return r.findKey(bson.D{{Key: "id", Value: id}})
+}
+
+func (r *APIKeyRepository) GetKeyByHash(hash string) (*models.APIKey, error) {
+	slog.Debug("GetKeyByHash called")
+	return r.findKey(bson.D{{Key: "hash", Value: hash}})
+}
+
+func (r *APIKeyRepository) findKey(filter bson.D) (*models.APIKey, error) {
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	var key models.APIKey
+	err := r.DB.Collection(collection).FindOne(context.TODO(), filter).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("api key not found")
+			slog.Error("API key not found", "error", err)
+			return nil, err
+		}
+		slog.Error("Failed to find api key", "error", err)
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) ListKeys() ([]models.APIKey, error) {
+	slog.Debug("ListKeys called")
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	results, err := r.DB.Collection(collection).Find(context.TODO(), bson.D{})
+	if err != nil {
+		slog.Error("Failed to list api keys", "error", err)
+		return nil, err
+	}
+
+	var keys []models.APIKey
+	err = results.All(context.TODO(), &keys)
+	if err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode api keys", "error", err)
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return []models.APIKey{}, nil
+	}
+
+	slog.Info("API keys retrieved successfully", "count", len(keys))
+	return keys, nil
+}
+
+func (r *APIKeyRepository) RevokeKey(id string) (string, error) {
+	slog.Debug("RevokeKey called", "id", id)
+	now := time.Now().UTC()
+	return r.updateKey(id, bson.D{
+		{Key: "revoked_at", Value: now},
+		{Key: "updated_at", Value: now},
+	})
+}
+
+func (r *APIKeyRepository) RotateKey(id string, prefix string, hash string) (string, error) {
+	slog.Debug("RotateKey called", "id", id)
+	return r.updateKey(id, bson.D{
+		{Key: "prefix", Value: prefix},
+		{Key: "hash", Value: hash},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	})
+}
+
+func (r *APIKeyRepository) TouchKey(id string, at time.Time) error {
+	_, err := r.updateKey(id, bson.D{{Key: "last_used_at", Value: at}})
+	return err
+}
+
+func (r *APIKeyRepository) updateKey(id string, set bson.D) (string, error) {
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	result, err := r.DB.Collection(collection).UpdateOne(
+		context.TODO(),
+		bson.D{{Key: "id", Value: id}},
+		bson.D{{Key: "$set", Value: set}},
+	)
+	if err != nil {
+		slog.Error("Failed to update api key", "id", id, "error", err)
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		err := errors.New("api key not found")
+		slog.Error("API key not found", "id", id, "error", err)
+		return "", err
+	}
+
+	slog.Info("API key updated successfully", "id", id)
+	return id, nil
+}
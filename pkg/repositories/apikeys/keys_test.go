@@ -0,0 +1,102 @@
+package apikeys
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := APIKeyRepository{
+		DB: client.Database("cms-tests"),
+	}
+
+	assert.NoError(t, repo.EnsureIndexes())
+
+	defer func() {
+		if err := repo.DB.Collection(collection).Drop(context.TODO()); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	key := &models.APIKey{
+		Id:          uuid.New().String(),
+		Name:        "build-pipeline",
+		Prefix:      "yan_abcdefgh",
+		Hash:        uuid.New().String(),
+		Collections: []string{"lessons"},
+		Operations:  []string{"read", "write"},
+		UpdatedAt:   time.Now().UTC(),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	t.Run("Create And Get", func(t *testing.T) {
+		id, err := repo.CreateKey(key)
+		assert.NoError(t, err)
+		assert.Equal(t, key.Id, id)
+
+		stored, err := repo.GetKeyByHash(key.Hash)
+		assert.NoError(t, err)
+		assert.Equal(t, key.Name, stored.Name)
+		assert.Equal(t, key.Collections, stored.Collections)
+		assert.Equal(t, key.Operations, stored.Operations)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		keys, err := repo.ListKeys()
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+
+	t.Run("Touch", func(t *testing.T) {
+		assert.NoError(t, repo.TouchKey(key.Id, time.Now().UTC()))
+
+		stored, err := repo.GetKey(key.Id)
+		assert.NoError(t, err)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		newHash := uuid.New().String()
+		_, err := repo.RotateKey(key.Id, "yan_ijklmnop", newHash)
+		assert.NoError(t, err)
+
+		_, err = repo.GetKeyByHash(key.Hash)
+		assert.EqualError(t, err, "api key not found")
+
+		stored, err := repo.GetKeyByHash(newHash)
+		assert.NoError(t, err)
+		assert.Equal(t, "yan_ijklmnop", stored.Prefix)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		_, err := repo.RevokeKey(key.Id)
+		assert.NoError(t, err)
+
+		stored, err := repo.GetKey(key.Id)
+		assert.NoError(t, err)
+		assert.NotNil(t, stored.RevokedAt)
+	})
+
+	t.Run("Non-Existent Key", func(t *testing.T) {
+		_, err := repo.RevokeKey(uuid.New().String())
+		assert.EqualError(t, err, "api key not found")
+	})
+}
@@ -0,0 +1,11 @@
+package apikeys
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const collection = "api_keys"
+
+type APIKeyRepository struct {
+	DB *mongo.Database
+}
@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Filter struct {
+	Action     string
+	Principal  string
+	RequestId  string
+	Collection string
+	ContentId  string
+	Class      string
+	From       *time.Time
+	To         *time.Time
+	Limit      int64
+	Offset     int64
+}
+
+func (f *Filter) query() bson.D {
+	query := bson.D{}
+	fields := []struct {
+		key   string
+		value string
+	}{
+		{"action", f.Action},
+		{"principal", f.Principal},
+		{"request_id", f.RequestId},
+		{"collection", f.Collection},
+		{"content_id", f.ContentId},
+		{"class", f.Class},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			query = append(query, bson.E{Key: field.key, Value: field.value})
+		}
+	}
+
+	timestamp := bson.D{}
+	if f.From != nil {
+		timestamp = append(timestamp, bson.E{Key: "$gte", Value: *f.From})
+	}
+	if f.To != nil {
+		timestamp = append(timestamp, bson.E{Key: "$lt", Value: *f.To})
+	}
+	if len(timestamp) > 0 {
+		query = append(query, bson.E{Key: "timestamp", Value: timestamp})
+	}
+
+	return query
+}
+
+func (r *AuditRepository) EnsureIndexes() error {
+	_, err := r.DB.Collection(collection).Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "collection", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "principal", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		slog.Error("Failed to create audit indexes", "error", err)
+	}
+	return err
+}
+
+func (r *AuditRepository) CreateEntry(entry *models.AuditEntry) (string, error) {
+	slog.Debug("CreateEntry called", "action", entry.Action, "collection", entry.Collection)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	_, err := r.DB.Collection(collection).InsertOne(context.TODO(), entry)
+	if err != nil {
+		slog.Error("Failed to insert audit entry", "action", entry.Action, "error", err)
+		return "", err
+	}
+
+	slog.Info("Audit entry recorded", "id", entry.Id, "action", entry.Action)
+	return entry.Id, nil
+}
+
+func (r *AuditRepository) ListEntries(filter *Filter) ([]models.AuditEntry, error) {
+	slog.Debug("ListEntries called", "filter", filter)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(filter.Offset).
+		SetLimit(filter.Limit)
+
+	results, err := r.DB.Collection(collection).Find(context.TODO(), filter.query(), opts)
+	if err != nil {
+		slog.Error("Failed to find audit entries", "error", err)
+		return nil, err
+	}
+
+	var entries []models.AuditEntry
+	err = results.All(context.TODO(), &entries)
+	if err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode audit entries", "error", err)
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return []models.AuditEntry{}, nil
+	}
+
+	slog.Info("Audit entries retrieved successfully", "count", len(entries))
+	return entries, nil
+}
+
+func (r *AuditRepository) ExportEntries(filter *Filter, fn func(*models.AuditEntry) error) error {
+	slog.Debug("ExportEntries called", "filter", filter)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := r.DB.Collection(collection).Find(context.TODO(), filter.query(), opts)
+	if err != nil {
+		slog.Error("Failed to find audit entries", "error", err)
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			slog.Error("Failed to decode audit entry", "error", err)
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
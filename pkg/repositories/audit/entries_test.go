@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEntries(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := AuditRepository{
+		DB: client.Database("cms-tests"),
+	}
+	testsCollection := uuid.New().String()
+
+	defer func() {
+		if _, err := repo.DB.Collection(collection).DeleteMany(context.TODO(), map[string]string{"collection": testsCollection}); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	start := time.Now().UTC().Add(-time.Minute)
+	entries := []models.AuditEntry{
+		{Id: uuid.New().String(), Action: models.EventContentCreated, Principal: "anonymous", Collection: testsCollection, ContentId: "a", Timestamp: start},
+		{Id: uuid.New().String(), Action: models.EventContentUpdated, Principal: "api_key:1", Collection: testsCollection, ContentId: "a", Timestamp: start.Add(time.Second)},
+		{Id: uuid.New().String(), Action: models.EventContentDeleted, Principal: "api_key:1", Collection: testsCollection, ContentId: "a", Timestamp: start.Add(2 * time.Second)},
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		for i := range entries {
+			id, err := repo.CreateEntry(&entries[i])
+			assert.NoError(t, err)
+			assert.Equal(t, entries[i].Id, id)
+		}
+	})
+
+	t.Run("List Newest First", func(t *testing.T) {
+		results, err := repo.ListEntries(&Filter{Collection: testsCollection, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		assert.Equal(t, models.EventContentDeleted, results[0].Action)
+	})
+
+	t.Run("Filter By Principal", func(t *testing.T) {
+		results, err := repo.ListEntries(&Filter{Collection: testsCollection, Principal: "api_key:1", Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+	})
+
+	t.Run("Filter By Time", func(t *testing.T) {
+		from := start.Add(time.Second)
+		results, err := repo.ListEntries(&Filter{Collection: testsCollection, From: &from, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+	})
+
+	t.Run("Export Oldest First", func(t *testing.T) {
+		var actions []string
+		err := repo.ExportEntries(&Filter{Collection: testsCollection}, func(entry *models.AuditEntry) error {
+			actions = append(actions, entry.Action)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{models.EventContentCreated, models.EventContentUpdated, models.EventContentDeleted}, actions)
+	})
+}
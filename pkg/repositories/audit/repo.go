@@ -0,0 +1,11 @@
+package audit
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const collection = "audit_log"
+
+type AuditRepository struct {
+	DB *mongo.Database
+}
@@ -0,0 +1,141 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrCollectionNotRegistered = errors.New("collection is not registered")
+
+func (r *CollectionRepository) EnsureIndexes() error {
+	_, err := r.DB.Collection(collectionsCollection).Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		slog.Error("Failed to create collection registry indexes", "error", err)
+		return err
+	}
+	return r.ensureReservationIndexes()
+}
+
+// Register stores info about a collection, replacing what was registered
+// before but keeping when it was first registered. It returns the info as
+// stored.
+func (r *CollectionRepository) Register(info *models.CollectionInfo) (*models.CollectionInfo, error) {
+	slog.Debug("Register called", "collection", info.Name)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	var registered models.CollectionInfo
+	err := r.DB.Collection(collectionsCollection).FindOneAndUpdate(
+		context.TODO(),
+		bson.D{{Key: "name", Value: info.Name}},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "display_name", Value: info.DisplayName},
+				{Key: "description", Value: info.Description},
+				{Key: "owner", Value: info.Owner},
+				{Key: "updated_at", Value: info.UpdatedAt},
+			}},
+			{Key: "$setOnInsert", Value: bson.D{{Key: "created_at", Value: info.CreatedAt}}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&registered)
+	if err != nil {
+		slog.Error("Failed to register collection", "collection", info.Name, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Collection registered successfully", "collection", info.Name)
+	return &registered, nil
+}
+
+func (r *CollectionRepository) Get(name string) (*models.CollectionInfo, error) {
+	slog.Debug("Get called", "collection", name)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	var info models.CollectionInfo
+	err := r.DB.Collection(collectionsCollection).FindOne(
+		context.TODO(),
+		bson.D{{Key: "name", Value: name}},
+	).Decode(&info)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			slog.Info("Collection not registered", "collection", name)
+			return nil, ErrCollectionNotRegistered
+		}
+		slog.Error("Failed to find collection info", "collection", name, "error", err)
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// List returns every registered collection by name.
+func (r *CollectionRepository) List() (map[string]*models.CollectionInfo, error) {
+	slog.Debug("List called")
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	results, err := r.DB.Collection(collectionsCollection).Find(context.TODO(), bson.D{})
+	if err != nil {
+		slog.Error("Failed to find registered collections", "error", err)
+		return nil, err
+	}
+
+	var infos []models.CollectionInfo
+	if err := results.All(context.TODO(), &infos); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode registered collections", "error", err)
+		return nil, err
+	}
+
+	registered := make(map[string]*models.CollectionInfo, len(infos))
+	for i := range infos {
+		registered[infos[i].Name] = &infos[i]
+	}
+	return registered, nil
+}
+
+// Unregister forgets a collection. Its items are left alone.
+func (r *CollectionRepository) Unregister(name string) error {
+	slog.Debug("Unregister called", "collection", name)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return err
+	}
+
+	result, err := r.DB.Collection(collectionsCollection).DeleteOne(
+		context.TODO(),
+		bson.D{{Key: "name", Value: name}},
+	)
+	if err != nil {
+		slog.Error("Failed to unregister collection", "collection", name, "error", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCollectionNotRegistered
+	}
+
+	slog.Info("Collection unregistered successfully", "collection", name)
+	return nil
+}
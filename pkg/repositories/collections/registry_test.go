@@ -0,0 +1,126 @@
+package collections
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := CollectionRepository{
+		DB: client.Database("cms-tests"),
+	}
+	name := uuid.New().String()
+	created := time.Now().UTC().Truncate(time.Millisecond)
+
+	defer repo.Unregister(name)
+
+	t.Run("Register", func(t *testing.T) {
+		info, err := repo.Register(&models.CollectionInfo{Name: name, DisplayName: "Courses", UpdatedAt: created, CreatedAt: created})
+		assert.NoError(t, err)
+		assert.Equal(t, "Courses", info.DisplayName)
+
+		later := created.Add(time.Minute)
+		info, err = repo.Register(&models.CollectionInfo{Name: name, DisplayName: "Courses", Owner: "learning", UpdatedAt: later, CreatedAt: later})
+		assert.NoError(t, err)
+		assert.Equal(t, "learning", info.Owner)
+		assert.Equal(t, created, info.CreatedAt)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		registered, err := repo.List()
+		assert.NoError(t, err)
+		assert.Contains(t, registered, name)
+	})
+
+	t.Run("Unregister", func(t *testing.T) {
+		assert.NoError(t, repo.Unregister(name))
+		_, err := repo.Get(name)
+		assert.ErrorIs(t, err, ErrCollectionNotRegistered)
+		assert.ErrorIs(t, repo.Unregister(name), ErrCollectionNotRegistered)
+	})
+}
+
+func TestReservations(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := CollectionRepository{
+		DB: client.Database(uuid.New().String()),
+	}
+	defer repo.DB.Drop(context.TODO())
+	assert.NoError(t, repo.EnsureIndexes())
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	t.Run("Takes Free Places", func(t *testing.T) {
+		existing := []string{"courses"}
+		reserved, err := repo.Reserve("lessons", existing, 3, now)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+
+		reserved, err = repo.Reserve("quizzes", existing, 3, now)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+
+		reserved, err = repo.Reserve("notes", existing, 3, now)
+		assert.NoError(t, err)
+		assert.False(t, reserved)
+
+		reserved, err = repo.Reserve("lessons", existing, 3, now)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+
+		names, err := repo.Reserved(now)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"lessons", "quizzes"}, names)
+	})
+
+	t.Run("Gives Up Places Of Created Collections", func(t *testing.T) {
+		// lessons took the first place, which it no longer needs once it
+		// exists, so notes takes that place.
+		reserved, err := repo.Reserve("notes", []string{"courses", "lessons"}, 3, now)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+
+		names, err := repo.Reserved(now)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"quizzes", "notes"}, names)
+	})
+
+	t.Run("Frees Expired Places", func(t *testing.T) {
+		later := now.Add(ReservationTTL)
+		names, err := repo.Reserved(later)
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+
+		reserved, err := repo.Reserve("videos", []string{"courses"}, 3, later)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+	})
+}
@@ -0,0 +1,13 @@
+package collections
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const collectionsCollection = "collections"
+
+// CollectionRepository keeps the registry of content collections in the
+// system database.
+type CollectionRepository struct {
+	DB *mongo.Database
+}
@@ -0,0 +1,133 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const reservationsCollection = "collection_reservations"
+
+// ReservationTTL is how long a reservation is kept. It only has to last
+// until the first write has created the collection, after which the
+// collection counts itself.
+const ReservationTTL = time.Minute
+
+// reservation holds one of the places under the limit on collections for a
+// name that has no collection yet. The unique index on slot lets only one
+// name take each place.
+type reservation struct {
+	Name      string    `bson:"_id"`
+	Slot      int       `bson:"slot"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (r *CollectionRepository) ensureReservationIndexes() error {
+	coll := r.DB.Collection(reservationsCollection)
+	_, err := coll.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "slot", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err == nil {
+		err = utils.EnsureTTLIndex(coll, "created_at", ReservationTTL)
+	}
+	if err != nil {
+		slog.Error("Failed to create collection reservation indexes", "error", err)
+	}
+	return err
+}
+
+// Reserve takes a place for name among the limit places that the existing
+// collections leave free. A name that holds a place keeps it, and the
+// places of names that have since been created are given up, as those now
+// count themselves. The places are numbered from zero, so one that is freed
+// is taken again. It reports false when every place is taken.
+func (r *CollectionRepository) Reserve(name string, existing []string, limit int, now time.Time) (bool, error) {
+	slog.Debug("Reserve called", "collection", name, "existing", len(existing), "limit", limit)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return false, err
+	}
+
+	// The TTL monitor only runs once a minute, so places it has yet to free
+	// are freed here.
+	coll := r.DB.Collection(reservationsCollection)
+	released := bson.A{bson.D{{Key: "created_at", Value: bson.D{{Key: "$lte", Value: now.Add(-ReservationTTL)}}}}}
+	if len(existing) > 0 {
+		released = append(released, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: existing}}}})
+	}
+	_, err := coll.DeleteMany(context.TODO(), bson.D{{Key: "$or", Value: released}})
+	if err != nil {
+		slog.Error("Failed to delete collection reservations", "error", err)
+		return false, err
+	}
+
+	held, err := coll.CountDocuments(context.TODO(), bson.D{{Key: "_id", Value: name}}, options.Count().SetLimit(1))
+	if err != nil {
+		slog.Error("Failed to find collection reservation", "collection", name, "error", err)
+		return false, err
+	}
+	if held > 0 {
+		return true, nil
+	}
+
+	for slot := 0; slot < limit-len(existing); slot++ {
+		_, err := coll.UpdateOne(
+			context.TODO(),
+			bson.D{{Key: "_id", Value: name}},
+			bson.D{{Key: "$setOnInsert", Value: bson.D{{Key: "slot", Value: slot}, {Key: "created_at", Value: now}}}},
+			options.Update().SetUpsert(true),
+		)
+		if mongo.IsDuplicateKeyError(err) {
+			slog.Debug("Collection slot taken, trying the next", "collection", name, "slot", slot)
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to reserve collection", "collection", name, "error", err)
+			return false, err
+		}
+		slog.Info("Collection reserved", "collection", name, "slot", slot)
+		return true, nil
+	}
+	return false, nil
+}
+
+// Reserved returns the names that hold a place.
+func (r *CollectionRepository) Reserved(now time.Time) ([]string, error) {
+	slog.Debug("Reserved called")
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	cursor, err := r.DB.Collection(reservationsCollection).Find(
+		context.TODO(),
+		bson.D{{Key: "created_at", Value: bson.D{{Key: "$gt", Value: now.Add(-ReservationTTL)}}}},
+	)
+	if err != nil {
+		slog.Error("Failed to find collection reservations", "error", err)
+		return nil, err
+	}
+
+	var reservations []reservation
+	if err := cursor.All(context.TODO(), &reservations); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode collection reservations", "error", err)
+		return nil, err
+	}
+
+	names := make([]string, len(reservations))
+	for i := range reservations {
+		names[i] = reservations[i].Name
+	}
+	return names, nil
+}
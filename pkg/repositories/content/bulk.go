@@ -0,0 +1,371 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkResult is the outcome of one item of a bulk write. Before is the item
+// as it was and After as it was written. Err is set when it was not written.
+type BulkResult struct {
+	Id     string
+	Before *models.Content
+	After  *models.Content
+	Err    error
+}
+
+// bulkOp is the write for one item of a bulk write, and the write that
+// reverts it when an atomic bulk write cannot use a transaction.
+type bulkOp struct {
+	index int
+	write mongo.WriteModel
+	undo  mongo.WriteModel
+}
+
+// BulkCreate inserts contents in one bulk write. Their ids are expected to be
+// new, so unlike CreateContent it does not look them up first. In atomic
+// mode nothing is written unless every item can be.
+func (r *ContentRepository) BulkCreate(coll string, contents []models.Content, atomic bool) ([]BulkResult, error) {
+	slog.Debug("BulkCreate called", "collection", coll, "count", len(contents), "atomic", atomic)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	validateFields := r.FieldValidator(coll)
+	results := make([]BulkResult, len(contents))
+	var ops []bulkOp
+	for i := range contents {
+		content := &contents[i]
+		results[i] = BulkResult{Id: content.Id, After: content}
+		if err := ValidateContent(content); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := validateFields(content); err != nil {
+			results[i].Err = err
+			continue
+		}
+		ops = append(ops, bulkOp{
+			index: i,
+			write: mongo.NewInsertOneModel().SetDocument(content),
+			undo:  mongo.NewDeleteOneModel().SetFilter(bson.D{{Key: "id", Value: content.Id}}),
+		})
+	}
+
+	if err := r.writeBulk(coll, ops, results, atomic, false); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// BulkUpdate changes the fields set in each update, like UpdateContent, in
+// one bulk write. Items that do not exist fail with ErrContentNotFound.
+func (r *ContentRepository) BulkUpdate(coll string, updates []models.BulkUpdate, atomic bool) ([]BulkResult, error) {
+	slog.Debug("BulkUpdate called", "collection", coll, "count", len(updates), "atomic", atomic)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	ids := make([]string, len(updates))
+	for i := range updates {
+		ids[i] = updates[i].Id
+	}
+	current, err := r.findContents(coll, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	validateFields := r.FieldValidator(coll)
+	results := make([]BulkResult, len(updates))
+	seen := map[string]int{}
+	var ops []bulkOp
+	for i := range updates {
+		update := &updates[i]
+		results[i] = BulkResult{Id: update.Id}
+		if first, ok := seen[update.Id]; ok {
+			results[i].Err = fmt.Errorf("%w, first used by item %d", ErrDuplicateId, first)
+			continue
+		}
+		seen[update.Id] = i
+
+		before, ok := current[update.Id]
+		if !ok {
+			results[i].Err = ErrContentNotFound
+			continue
+		}
+		after := applyUpdate(*before, &update.UpdateContent)
+		after.UpdatedAt = now
+		results[i].Before, results[i].After = before, &after
+		if err := ValidateContent(&after); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := validateFields(&after); err != nil {
+			results[i].Err = err
+			continue
+		}
+		ops = append(ops, bulkOp{
+			index: i,
+			write: mongo.NewReplaceOneModel().SetFilter(bson.D{{Key: "id", Value: update.Id}}).SetReplacement(&after),
+			undo:  mongo.NewReplaceOneModel().SetFilter(bson.D{{Key: "id", Value: update.Id}}).SetReplacement(before),
+		})
+	}
+
+	if err := r.writeBulk(coll, ops, results, atomic, false); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// BulkDelete deletes the items with ids in one bulk write. Like
+// DeleteContent, ids that do not exist are not an error, and their results
+// have no Before.
+func (r *ContentRepository) BulkDelete(coll string, ids []string, atomic bool) ([]BulkResult, error) {
+	slog.Debug("BulkDelete called", "collection", coll, "count", len(ids), "atomic", atomic)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	current, err := r.findContents(coll, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(ids))
+	var ops []bulkOp
+	for i, id := range ids {
+		results[i] = BulkResult{Id: id}
+		before, ok := current[id]
+		if !ok {
+			continue
+		}
+		// A repeated id is only deleted once.
+		delete(current, id)
+		results[i].Before = before
+		ops = append(ops, bulkOp{
+			index: i,
+			write: mongo.NewDeleteOneModel().SetFilter(bson.D{{Key: "id", Value: id}}),
+			undo:  mongo.NewInsertOneModel().SetDocument(before),
+		})
+	}
+
+	if err := r.writeBulk(coll, ops, results, atomic, true); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// writeBulk runs the writes of ops and records the items that fail in
+// results. In atomic mode, a single failure means nothing is written, and
+// the items that did not fail themselves get ErrBulkAborted. Only errors
+// that say nothing about which items were written are returned.
+func (r *ContentRepository) writeBulk(coll string, ops []bulkOp, results []BulkResult, atomic bool, deleted bool) error {
+	if atomic && failedResults(results) > 0 {
+		abortResults(results)
+		slog.Info("Bulk write aborted before writing", "collection", coll)
+		return nil
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, len(ops))
+	for i, op := range ops {
+		writes[i] = op.write
+	}
+
+	var err error
+	if atomic {
+		err = r.atomically(func(ctx context.Context) error {
+			_, err := r.DB.Collection(coll).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(true))
+			return err
+		}, func(err error) {
+			// Ordered writes stop at the first failure, so only the items
+			// before it were written.
+			written := len(ops)
+			var bulkErr mongo.BulkWriteException
+			if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+				written = bulkErr.WriteErrors[0].Index
+			}
+			if written == 0 {
+				return
+			}
+			undo := make([]mongo.WriteModel, written)
+			for i := range undo {
+				undo[i] = ops[i].undo
+			}
+			if _, err := r.DB.Collection(coll).BulkWrite(context.TODO(), undo, options.BulkWrite().SetOrdered(false)); err != nil {
+				slog.Error("Failed to undo bulk write", "collection", coll, "error", err)
+			}
+		})
+	} else {
+		_, err = r.DB.Collection(coll).BulkWrite(context.TODO(), writes, options.BulkWrite().SetOrdered(false))
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		for _, writeErr := range bulkErr.WriteErrors {
+			results[ops[writeErr.Index].index].Err = bulkWriteError(writeErr)
+		}
+		if atomic {
+			abortResults(results)
+		}
+	} else if err != nil {
+		slog.Error("Failed to write bulk", "collection", coll, "error", err)
+		return err
+	}
+
+	var ids []string
+	var classes []string
+	for _, op := range ops {
+		result := &results[op.index]
+		if result.Err != nil {
+			continue
+		}
+		ids = append(ids, result.Id)
+		if result.Before != nil {
+			classes = append(classes, result.Before.Class)
+		}
+		if result.After != nil {
+			classes = append(classes, result.After.Class)
+		}
+	}
+	slog.Info("Bulk write completed", "collection", coll, "written", len(ids), "failed", failedResults(results))
+	if len(ids) > 0 {
+		r.Cache.Invalidate(coll, ids, classes...)
+		return r.RecordChanges(coll, ids, deleted)
+	}
+	return nil
+}
+
+// atomically runs write in a transaction. Standalone servers do not support
+// transactions, so there it runs write on its own, and calls undo to revert
+// what was written if it fails. That leaves a window in which other readers
+// can see part of the write.
+func (r *ContentRepository) atomically(write func(ctx context.Context) error, undo func(err error)) error {
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.TODO())
+
+	_, err = session.WithTransaction(context.TODO(), func(ctx mongo.SessionContext) (any, error) {
+		return nil, write(ctx)
+	})
+	if !transactionsUnsupported(err) {
+		return err
+	}
+
+	slog.Warn("Transactions are not supported, writing without one", "error", err)
+	if err := write(context.TODO()); err != nil {
+		undo(err)
+		return err
+	}
+	return nil
+}
+
+func transactionsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	// IllegalOperation is returned by standalone servers.
+	return serverErr.HasErrorCode(20) || serverErr.HasErrorMessage("Transaction numbers are only allowed")
+}
+
+func bulkWriteError(err mongo.BulkWriteError) error {
+	if mongo.IsDuplicateKeyError(err.WriteError) {
+		return ErrContentExists
+	}
+	return errors.New(err.Message)
+}
+
+func failedResults(results []BulkResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func abortResults(results []BulkResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = ErrBulkAborted
+		}
+	}
+}
+
+// findContents looks up the items with ids, in batches like ExistingIds.
+func (r *ContentRepository) findContents(coll string, ids []string) (map[string]*models.Content, error) {
+	found := map[string]*models.Content{}
+	for start := 0; start < len(ids); start += existingIdsBatch {
+		batch := ids[start:min(start+existingIdsBatch, len(ids))]
+		cursor, err := r.DB.Collection(coll).Find(
+			context.TODO(),
+			bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: batch}}}},
+		)
+		if err != nil {
+			slog.Error("Failed to find contents", "collection", coll, "error", err)
+			return nil, err
+		}
+
+		var contents []models.Content
+		if err := cursor.All(context.TODO(), &contents); err != nil {
+			err := fmt.Errorf("failed to decode results: %s", err.Error())
+			slog.Error("Failed to decode contents", "collection", coll, "error", err)
+			return nil, err
+		}
+		for i := range contents {
+			found[contents[i].Id] = &contents[i]
+		}
+	}
+	return found, nil
+}
+
+// applyUpdate returns content with the fields set in update changed, the way
+// UpdateContent changes them.
+func applyUpdate(content models.Content, update *models.UpdateContent) models.Content {
+	if update.Class != nil {
+		content.Class = *update.Class
+	}
+	if update.Title != nil {
+		content.Title = *update.Title
+	}
+	if update.Description != nil {
+		content.Description = *update.Description
+	}
+	if update.Body != nil {
+		content.Body = *update.Body
+	}
+	if update.Views != nil {
+		content.Views = *update.Views
+	}
+	if update.CreatorId != nil {
+		content.CreatorId = *update.CreatorId
+	}
+	if update.IsPublic != nil {
+		content.IsPublic = *update.IsPublic
+	}
+	if update.Fields != nil {
+		content.Fields = update.Fields
+	}
+	return content
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulk(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{DB: client.Database("content")}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+	}()
+
+	newContent := func(title string) models.Content {
+		now := time.Now().UTC()
+		return models.Content{
+			Id:        uuid.New().String(),
+			Class:     "lessons",
+			Title:     title,
+			CreatorId: uuid.New().String(),
+			UpdatedAt: now,
+			CreatedAt: now,
+		}
+	}
+
+	first, second := newContent("One"), newContent("Two")
+	results, err := repo.BulkCreate(testsCollection, []models.Content{first, second}, false)
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+
+	t.Run("Atomic Create Writes Nothing On Failure", func(t *testing.T) {
+		invalid := newContent("Invalid")
+		invalid.CreatorId = "nobody"
+		valid := newContent("Three")
+		results, err := repo.BulkCreate(testsCollection, []models.Content{valid, invalid}, true)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, ErrBulkAborted)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, results[1].Err, &validationErr)
+
+		_, err = repo.GetContent(testsCollection, valid.Id)
+		assert.ErrorIs(t, err, ErrContentNotFound)
+	})
+
+	t.Run("Best Effort Update", func(t *testing.T) {
+		title := "First"
+		results, err := repo.BulkUpdate(testsCollection, []models.BulkUpdate{
+			{Id: first.Id, UpdateContent: models.UpdateContent{Title: &title}},
+			{Id: uuid.New().String(), UpdateContent: models.UpdateContent{Title: &title}},
+		}, false)
+		assert.NoError(t, err)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "One", results[0].Before.Title)
+		assert.ErrorIs(t, results[1].Err, ErrContentNotFound)
+
+		found, err := repo.GetContent(testsCollection, first.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "First", found.Title)
+	})
+
+	t.Run("Atomic Delete", func(t *testing.T) {
+		results, err := repo.BulkDelete(testsCollection, []string{first.Id, second.Id, uuid.New().String()}, true)
+		assert.NoError(t, err)
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+		}
+		assert.Nil(t, results[2].Before)
+
+		contents, err := repo.GetCollection(testsCollection)
+		assert.NoError(t, err)
+		assert.Empty(t, contents)
+	})
+}
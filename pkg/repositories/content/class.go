@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// classItem is the part of an item that moving it between classes needs,
+// so that classes can be changed without loading their items. Fields are
+// only loaded when the new class has a schema to check them against.
+type classItem struct {
+	Id            string         `bson:"id"`
+	UpdatedAt     time.Time      `bson:"updated_at"`
+	SchemaVersion int            `bson:"schema_version"`
+	Fields        map[string]any `bson:"fields"`
+}
+
+// SummarizeClasses returns the classes of a collection in sorted order, with
+// how many items each has and when the last of them was updated.
+func (r *ContentRepository) SummarizeClasses(coll string) ([]models.ClassSummary, error) {
+	slog.Debug("SummarizeClasses called", "collection", coll)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	cursor, err := r.DB.Collection(coll).Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$class"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "updated_at", Value: bson.D{{Key: "$max", Value: "$updated_at"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		slog.Error("Failed to summarize classes", "collection", coll, "error", err)
+		return nil, err
+	}
+
+	summaries := []models.ClassSummary{}
+	if err := cursor.All(context.TODO(), &summaries); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode class summaries", "collection", coll, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Classes summarized successfully", "collection", coll, "count", len(summaries))
+	return summaries, nil
+}
+
+// RenameClass moves every item of a class to a class that has no items yet,
+// failing with ErrClassExists if it has. It returns the ids of the items.
+func (r *ContentRepository) RenameClass(coll string, from string, to string) ([]string, error) {
+	slog.Debug("RenameClass called", "collection", coll, "from", from, "to", to)
+	return r.moveClass(coll, from, to, nil, true)
+}
+
+// MergeClass moves every item of a class to another class, which may
+// already have items. It returns the ids of the items moved.
+func (r *ContentRepository) MergeClass(coll string, from string, to string) ([]string, error) {
+	slog.Debug("MergeClass called", "collection", coll, "from", from, "to", to)
+	return r.moveClass(coll, from, to, nil, false)
+}
+
+// MoveToClass moves the items of a class with ids to another class. Ids that
+// are not in the class are left alone, and missing from the ids returned.
+func (r *ContentRepository) MoveToClass(coll string, from string, to string, ids []string) ([]string, error) {
+	slog.Debug("MoveToClass called", "collection", coll, "from", from, "to", to, "count", len(ids))
+	return r.moveClass(coll, from, to, ids, false)
+}
+
+// moveClass changes the class of the items of from, or only those with ids
+// when they are given, in updates of a batch of items at a time. All of them
+// are moved or none are, as with atomic bulk writes. If the new class has a
+// schema, the custom fields of every item must match its latest version, or
+// none are moved and a SchemaMismatchError lists those that do not.
+func (r *ContentRepository) moveClass(coll string, from string, to string, ids []string, exclusive bool) ([]string, error) {
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	target, err := r.latestSchema(coll, to)
+	if err != nil {
+		return nil, err
+	}
+	version := 0
+	if target != nil {
+		version = target.version
+	}
+
+	now := time.Now().UTC()
+	var moved []classItem
+	err = r.atomically(func(ctx context.Context) error {
+		moved = nil
+		if exclusive {
+			taken, err := r.DB.Collection(coll).CountDocuments(ctx, bson.D{{Key: "class", Value: to}}, options.Count().SetLimit(1))
+			if err != nil {
+				return err
+			}
+			if taken > 0 {
+				return ErrClassExists
+			}
+		}
+
+		items, err := r.findClassItems(ctx, coll, from, ids, target != nil)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 && ids == nil {
+			return ErrClassNotFound
+		}
+		if target != nil {
+			if err := checkMove(coll, to, target, items); err != nil {
+				return err
+			}
+		}
+
+		for start := 0; start < len(items); start += existingIdsBatch {
+			batch := items[start:min(start+existingIdsBatch, len(items))]
+			_, err := r.DB.Collection(coll).UpdateMany(
+				ctx,
+				bson.D{{Key: "class", Value: from}, {Key: "id", Value: bson.D{{Key: "$in", Value: classItemIds(batch)}}}},
+				bson.D{{Key: "$set", Value: bson.D{{Key: "class", Value: to}, {Key: "schema_version", Value: version}, {Key: "updated_at", Value: now}}}},
+			)
+			if err != nil {
+				return err
+			}
+			moved = append(moved, batch...)
+		}
+		return nil
+	}, func(err error) {
+		if len(moved) == 0 {
+			return
+		}
+		undo := make([]mongo.WriteModel, len(moved))
+		for i, item := range moved {
+			undo[i] = mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{Key: "id", Value: item.Id}, {Key: "class", Value: to}}).
+				SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "class", Value: from}, {Key: "schema_version", Value: item.SchemaVersion}, {Key: "updated_at", Value: item.UpdatedAt}}}})
+		}
+		if _, err := r.DB.Collection(coll).BulkWrite(context.TODO(), undo, options.BulkWrite().SetOrdered(false)); err != nil {
+			slog.Error("Failed to undo class move", "collection", coll, "from", from, "to", to, "error", err)
+		}
+	})
+	if err != nil {
+		var mismatch *SchemaMismatchError
+		if !errors.Is(err, ErrClassExists) && !errors.Is(err, ErrClassNotFound) && !errors.As(err, &mismatch) {
+			slog.Error("Failed to move class contents", "collection", coll, "from", from, "to", to, "error", err)
+		}
+		return nil, err
+	}
+
+	movedIds := classItemIds(moved)
+	slog.Info("Class contents moved successfully", "collection", coll, "from", from, "to", to, "count", len(movedIds))
+	if len(movedIds) > 0 {
+		r.Cache.Invalidate(coll, movedIds, from, to)
+		if err := r.RecordChanges(coll, movedIds, false); err != nil {
+			return nil, err
+		}
+	}
+	return movedIds, nil
+}
+
+// findClassItems looks up the items of a class, or only those with ids when
+// they are given, without loading more of them than their ids, times and
+// schema versions, and their custom fields if withFields is set.
+func (r *ContentRepository) findClassItems(ctx context.Context, coll string, class string, ids []string, withFields bool) ([]classItem, error) {
+	filter := bson.D{{Key: "class", Value: class}}
+	if ids != nil {
+		filter = append(filter, bson.E{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}})
+	}
+	projection := bson.D{{Key: "id", Value: 1}, {Key: "updated_at", Value: 1}, {Key: "schema_version", Value: 1}}
+	if withFields {
+		projection = append(projection, bson.E{Key: "fields", Value: 1})
+	}
+	cursor, err := r.DB.Collection(coll).Find(
+		ctx,
+		filter,
+		options.Find().SetProjection(projection),
+	)
+	if err != nil {
+		slog.Error("Failed to find class contents", "collection", coll, "class", class, "error", err)
+		return nil, err
+	}
+
+	items := []classItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode class contents", "collection", coll, "class", class, "error", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// checkMove checks the custom fields of items against the schema of the
+// class they are moved to, and reports those that do not match.
+func checkMove(coll string, to string, target *classSchema, items []classItem) error {
+	report := &models.SchemaReport{
+		Collection: coll,
+		Class:      to,
+		Version:    target.version,
+		Items:      []models.SchemaReportItem{},
+	}
+	for _, item := range items {
+		report.Checked++
+		if addViolations(report, item.Id, item.SchemaVersion, target.schema.Validate(item.Fields)) {
+			report.Matching++
+		}
+	}
+	if report.Failing > 0 {
+		slog.Info("Class move rejected by schema", "collection", coll, "class", to, "version", target.version, "failing", report.Failing)
+		return &SchemaMismatchError{Report: report}
+	}
+	return nil
+}
+
+func classItemIds(items []classItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.Id
+	}
+	return ids
+}
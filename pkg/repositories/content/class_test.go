@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClasses(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{DB: client.Database("content")}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+	}()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var ids []string
+	for i, class := range []string{"drafts", "drafts", "published"} {
+		id := uuid.New().String()
+		ids = append(ids, id)
+		_, err := repo.CreateContent(testsCollection, &models.Content{
+			Id:        id,
+			Class:     class,
+			Title:     "Title",
+			CreatorId: uuid.New().String(),
+			UpdatedAt: now.Add(time.Duration(i) * time.Second),
+			CreatedAt: now,
+		})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Summarize Classes", func(t *testing.T) {
+		classes, err := repo.SummarizeClasses(testsCollection)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.ClassSummary{
+			{Class: "drafts", Count: 2, UpdatedAt: now.Add(time.Second)},
+			{Class: "published", Count: 1, UpdatedAt: now.Add(2 * time.Second)},
+		}, classes)
+	})
+
+	t.Run("Rename Onto Existing Class", func(t *testing.T) {
+		_, err := repo.RenameClass(testsCollection, "drafts", "published")
+		assert.ErrorIs(t, err, ErrClassExists)
+	})
+
+	t.Run("Move Items", func(t *testing.T) {
+		moved, err := repo.MoveToClass(testsCollection, "drafts", "review", []string{ids[0], ids[2]})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{ids[0]}, moved)
+
+		content, err := repo.GetContent(testsCollection, ids[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "review", content.Class)
+	})
+
+	t.Run("Merge And Rename", func(t *testing.T) {
+		moved, err := repo.MergeClass(testsCollection, "drafts", "review")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{ids[1]}, moved)
+
+		moved, err = repo.RenameClass(testsCollection, "review", "ready")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{ids[0], ids[1]}, moved)
+
+		contents, err := repo.GetClass(testsCollection, "ready")
+		assert.NoError(t, err)
+		assert.Len(t, contents, 2)
+	})
+
+	t.Run("Non-Existent Class", func(t *testing.T) {
+		_, err := repo.MergeClass(testsCollection, "drafts", "ready")
+		assert.ErrorIs(t, err, ErrClassNotFound)
+	})
+}
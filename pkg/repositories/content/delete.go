@@ -4,38 +4,58 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/YanSystems/cms/pkg/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (r *ContentRepository) DeleteContent(coll string, id string) (string, error) {
-	slog.Debug("DeleteContent called", "collection", coll, "id", id)
+	if _, err := r.RemoveContent(coll, id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
 
-	_, err := r.DB.Collection(coll).DeleteOne(
+// RemoveContent deletes an item like DeleteContent, and returns it as it was
+// when it was deleted, or nil when there was no item with the id.
+func (r *ContentRepository) RemoveContent(coll string, id string) (*models.Content, error) {
+	slog.Debug("RemoveContent called", "collection", coll, "id", id)
+
+	var deleted models.Content
+	err := r.DB.Collection(coll).FindOneAndDelete(
 		context.TODO(),
 		bson.D{{Key: "id", Value: id}},
-	)
+	).Decode(&deleted)
 
+	if err == mongo.ErrNoDocuments {
+		slog.Info("No content to delete", "collection", coll, "id", id)
+		return nil, nil
+	}
 	if err != nil {
 		slog.Error("Failed to delete content", "collection", coll, "id", id, "error", err)
-		return "", err
+		return nil, err
 	}
 
 	slog.Info("Content deleted successfully", "collection", coll, "id", id)
-	return id, nil
+	r.Cache.Invalidate(coll, []string{id}, deleted.Class)
+	if err := r.RecordChanges(coll, []string{id}, true); err != nil {
+		return nil, err
+	}
+	return &deleted, nil
 }
 
 func (r *ContentRepository) DeleteClass(coll string, class string) ([]string, error) {
 	slog.Debug("DeleteClass called", "collection", coll, "class", class)
 
-	contents, err := r.GetClass(coll, class)
+	items, err := r.findClassItems(context.TODO(), coll, class, nil, false)
 	if err != nil {
 		slog.Error("Failed to get class contents", "collection", coll, "class", class, "error", err)
 		return nil, err
 	}
 
 	var ids []string
-	for _, content := range contents {
-		ids = append(ids, content.Id)
+	for _, item := range items {
+		ids = append(ids, item.Id)
 	}
 	slog.Debug("Class contents to be deleted", "collection", coll, "class", class, "ids", ids)
 
@@ -50,13 +70,17 @@ func (r *ContentRepository) DeleteClass(coll string, class string) ([]string, er
 	}
 
 	slog.Info("Class contents deleted successfully", "collection", coll, "class", class)
+	r.Cache.Invalidate(coll, ids, class)
+	if err := r.RecordChanges(coll, ids, true); err != nil {
+		return nil, err
+	}
 	return ids, nil
 }
 
 func (r *ContentRepository) DeleteCollection(coll string) ([]string, error) {
 	slog.Debug("DeleteCollection called", "collection", coll)
 
-	contents, err := r.GetCollection(coll)
+	contents, err := r.findCollection(coll)
 	if err != nil {
 		slog.Error("Failed to get collection contents", "collection", coll, "error", err)
 		return nil, err
@@ -76,5 +100,6 @@ func (r *ContentRepository) DeleteCollection(coll string) ([]string, error) {
 	}
 
 	slog.Info("Collection dropped successfully", "collection", coll)
+	r.Cache.InvalidateCollection(coll)
 	return ids, nil
 }
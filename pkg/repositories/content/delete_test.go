@@ -66,6 +66,24 @@ func TestDeleteContent(t *testing.T) {
 		id, err := repo.DeleteContent(testsCollection, nonExistentId)
 		assert.NoError(t, err)
 		assert.Equal(t, nonExistentId, id)
+
+		removed, err := repo.RemoveContent(testsCollection, nonExistentId)
+		assert.NoError(t, err)
+		assert.Nil(t, removed)
+	})
+
+	t.Run("Removed Content", func(t *testing.T) {
+		content := *initialContent
+		content.Id = uuid.New().String()
+		_, err := repo.CreateContent(testsCollection, &content)
+		assert.NoError(t, err)
+
+		removed, err := repo.RemoveContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+		if assert.NotNil(t, removed) {
+			assert.Equal(t, content.Id, removed.Id)
+			assert.Equal(t, content.Title, removed.Title)
+		}
 	})
 
 	t.Run("Invalid Content ID", func(t *testing.T) {
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+var (
+	ErrContentNotFound = errors.New("content not found")
+	ErrContentExists   = errors.New("content with this ID already exists")
+	ErrContentChanged  = errors.New("content was changed by another request")
+	ErrDuplicateId     = errors.New("duplicate id")
+	ErrClassNotFound   = errors.New("class not found")
+	ErrClassExists     = errors.New("class already exists")
+	// ErrInvalidListOptions is the error of listings asked to filter or sort
+	// by something they cannot.
+	ErrInvalidListOptions = errors.New("invalid list options")
+	// ErrBulkAborted is the error of the items of an atomic bulk write that
+	// were not written because another item failed.
+	ErrBulkAborted = errors.New("not written because another item failed")
+)
+
+// ValidationError reports a content field that failed validation.
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("field %s is not valid: %v", e.Field, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaMismatchError is the error of moving items to a class whose schema
+// some of them do not match. None of the items are moved, and Report lists
+// those that do not match.
+type SchemaMismatchError struct {
+	Report *models.SchemaReport
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("%d items do not match version %d of the schema of class %s", e.Report.Failing, e.Report.Version, e.Report.Class)
+}
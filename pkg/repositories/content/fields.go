@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/repositories/schemas"
+	"github.com/YanSystems/cms/pkg/schema"
+)
+
+// maxReportItems is how many failing items a schema report lists.
+const maxReportItems = 1000
+
+// fieldName is what the name of a custom field must look like, so that it
+// can be used in filters and sorts. Names of nested fields are joined by dots.
+var fieldName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// classSchema is the compiled schema of a class, or nil if it has none.
+type classSchema struct {
+	version int
+	schema  *schema.Schema
+}
+
+// ValidateFields checks the custom fields of content against the latest
+// schema of its class, and sets its SchemaVersion to the version they
+// match, or 0 if the class has no schema.
+func (r *ContentRepository) ValidateFields(coll string, content *models.Content) error {
+	return r.FieldValidator(coll)(content)
+}
+
+// FieldValidator returns a function that validates like ValidateFields. It
+// looks up the schema of each class once, for writes of many items.
+func (r *ContentRepository) FieldValidator(coll string) func(*models.Content) error {
+	classes := map[string]*classSchema{}
+	return func(content *models.Content) error {
+		if err := checkFieldNames(content.Fields, "fields"); err != nil {
+			slog.Error("Custom field validation failed", "contentID", content.Id, "error", err)
+			return err
+		}
+
+		s, ok := classes[content.Class]
+		if !ok {
+			var err error
+			if s, err = r.latestSchema(coll, content.Class); err != nil {
+				return err
+			}
+			classes[content.Class] = s
+		}
+		if s == nil {
+			content.SchemaVersion = 0
+			return nil
+		}
+
+		if violations := s.schema.Validate(content.Fields); len(violations) > 0 {
+			err := fieldsError(violations[0])
+			slog.Error("Custom field validation failed", "contentID", content.Id, "version", s.version, "error", err)
+			return err
+		}
+		content.SchemaVersion = s.version
+		return nil
+	}
+}
+
+// latestSchema returns the compiled latest schema of a class. Without a
+// system database there are no schemas.
+func (r *ContentRepository) latestSchema(coll string, class string) (*classSchema, error) {
+	if r.SystemDB == nil {
+		return nil, nil
+	}
+	repo := schemas.SchemaRepository{DB: r.SystemDB}
+	latest, err := repo.Latest(coll, class)
+	if errors.Is(err, schemas.ErrSchemaNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := schema.Compile(latest.Schema)
+	if err != nil {
+		slog.Error("Stored schema does not compile", "collection", coll, "class", class, "version", latest.Version, "error", err)
+		return nil, err
+	}
+	return &classSchema{version: latest.Version, schema: compiled}, nil
+}
+
+// CheckSchema reports which items of a class do not match a schema, which
+// is meant to be a version of the schema of that class. It does not change
+// the items.
+func (r *ContentRepository) CheckSchema(ctx context.Context, coll string, class string, s *models.ContentSchema) (*models.SchemaReport, error) {
+	slog.Debug("CheckSchema called", "collection", coll, "class", class, "version", s.Version)
+	compiled, err := schema.Compile(s.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SchemaReport{
+		Collection: coll,
+		Class:      class,
+		Version:    s.Version,
+		Items:      []models.SchemaReportItem{},
+	}
+	err = r.EachContent(ctx, coll, class, func(content *models.Content) error {
+		report.Checked++
+		if content.SchemaVersion < s.Version {
+			report.Outdated++
+		}
+		if addViolations(report, content.Id, content.SchemaVersion, compiled.Validate(content.Fields)) {
+			report.Matching++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Schema checked successfully", "collection", coll, "class", class, "version", s.Version, "checked", report.Checked, "failing", report.Failing)
+	return report, nil
+}
+
+// addViolations lists an item in report with its violations, up to
+// maxReportItems, and reports whether it had none.
+func addViolations(report *models.SchemaReport, id string, version int, violations []schema.Violation) bool {
+	if len(violations) == 0 {
+		return true
+	}
+
+	report.Failing++
+	if len(report.Items) == maxReportItems {
+		report.Truncated = true
+		return false
+	}
+	item := models.SchemaReportItem{Id: id, SchemaVersion: version}
+	for _, violation := range violations {
+		item.Errors = append(item.Errors, violation.Error())
+	}
+	report.Items = append(report.Items, item)
+	return false
+}
+
+// checkFieldNames rejects names that MongoDB would store but not let
+// filters reach, such as names with dots or leading dollar signs.
+func checkFieldNames(fields map[string]any, path string) error {
+	for name, value := range fields {
+		if strings.Contains(name, ".") || !fieldName.MatchString(name) {
+			return &ValidationError{Field: path, Err: fmt.Errorf("%q is not a valid field name", name)}
+		}
+		if nested, ok := value.(map[string]any); ok {
+			if err := checkFieldNames(nested, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fieldsError(violation schema.Violation) error {
+	field := "fields"
+	if violation.Path != "" {
+		field += "." + violation.Path
+	}
+	return &ValidationError{Field: field, Err: errors.New(violation.Message)}
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/repositories/schemas"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFields(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{
+		DB:       client.Database("content"),
+		SystemDB: client.Database("cms-tests"),
+	}
+	schemaRepo := schemas.SchemaRepository{DB: repo.SystemDB}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+		schemaRepo.Delete(testsCollection, "lessons")
+	}()
+
+	now := time.Now().UTC()
+	newContent := func(fields map[string]any) *models.Content {
+		return &models.Content{
+			Id:        uuid.New().String(),
+			Class:     "lessons",
+			CreatorId: uuid.New().String(),
+			UpdatedAt: now,
+			CreatedAt: now,
+			Fields:    fields,
+		}
+	}
+
+	// Written before the class had a schema
+	_, err = repo.CreateContent(testsCollection, newContent(map[string]any{"difficulty": "expert"}))
+	assert.NoError(t, err)
+
+	first, err := schemaRepo.Create(&models.ContentSchema{
+		Collection: testsCollection,
+		Class:      "lessons",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []any{"difficulty"},
+			"properties": map[string]any{
+				"difficulty": map[string]any{"enum": []any{"easy", "medium", "hard"}},
+				"duration":   map[string]any{"type": "integer", "minimum": 1},
+			},
+		},
+		CreatedAt: now,
+	})
+	assert.NoError(t, err)
+
+	t.Run("Create", func(t *testing.T) {
+		for i, difficulty := range []string{"easy", "hard"} {
+			content := newContent(map[string]any{"difficulty": difficulty, "duration": 30 * (i + 1)})
+			_, err := repo.CreateContent(testsCollection, content)
+			assert.NoError(t, err)
+			assert.Equal(t, first.Version, content.SchemaVersion)
+		}
+
+		_, err := repo.CreateContent(testsCollection, newContent(map[string]any{"difficulty": "easy", "duration": 0}))
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "fields.duration", validationErr.Field)
+
+		_, err = repo.CreateContent(testsCollection, newContent(map[string]any{"difficulty": "easy", "$where": 1}))
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		content := newContent(map[string]any{"difficulty": "medium"})
+		_, err := repo.CreateContent(testsCollection, content)
+		assert.NoError(t, err)
+
+		_, err = repo.UpdateContent(testsCollection, content.Id, &models.UpdateContent{Fields: map[string]any{"duration": 10}})
+		assert.Error(t, err)
+
+		_, err = repo.UpdateContent(testsCollection, content.Id, &models.UpdateContent{Fields: map[string]any{"difficulty": "easy", "duration": 10}})
+		assert.NoError(t, err)
+		updated, err := repo.GetContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "easy", updated.Fields["difficulty"])
+		assert.Equal(t, first.Version, updated.SchemaVersion)
+	})
+
+	t.Run("Filter And Sort", func(t *testing.T) {
+		contents, total, err := repo.ListContent(testsCollection, ListOptions{
+			Fields:     map[string]any{"difficulty": "easy"},
+			SortBy:     "fields.duration",
+			Descending: true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, contents, 2)
+		assert.EqualValues(t, 30, contents[0].Fields["duration"])
+
+		_, _, err = repo.ListContent(testsCollection, ListOptions{Fields: map[string]any{"$where": "1"}})
+		assert.Error(t, err)
+		_, _, err = repo.ListContent(testsCollection, ListOptions{Fields: map[string]any{"tags": []any{"go"}}})
+		assert.Error(t, err)
+		_, _, err = repo.ListContent(testsCollection, ListOptions{SortBy: "fields.$natural"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		report, err := repo.CheckSchema(context.TODO(), testsCollection, "lessons", first)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, report.Checked)
+		assert.Equal(t, 3, report.Matching)
+		assert.Equal(t, 1, report.Failing)
+		assert.Equal(t, 1, report.Outdated)
+		assert.Len(t, report.Items, 1)
+		assert.Equal(t, 0, report.Items[0].SchemaVersion)
+		assert.Equal(t, []string{`difficulty must be one of "easy", "medium", "hard"`}, report.Items[0].Errors)
+	})
+
+	t.Run("Move Checks The New Class", func(t *testing.T) {
+		valid := newContent(map[string]any{"difficulty": "hard"})
+		invalid := newContent(map[string]any{"difficulty": "expert"})
+		for _, content := range []*models.Content{valid, invalid} {
+			content.Class = "drafts"
+			_, err := repo.CreateContent(testsCollection, content)
+			assert.NoError(t, err)
+		}
+
+		_, err := repo.MergeClass(testsCollection, "drafts", "lessons")
+		var mismatch *SchemaMismatchError
+		assert.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, 2, mismatch.Report.Checked)
+		assert.Equal(t, 1, mismatch.Report.Failing)
+		assert.Equal(t, invalid.Id, mismatch.Report.Items[0].Id)
+		content, err := repo.GetContent(testsCollection, valid.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "drafts", content.Class)
+
+		moved, err := repo.MoveToClass(testsCollection, "drafts", "lessons", []string{valid.Id})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{valid.Id}, moved)
+		content, err = repo.GetContent(testsCollection, valid.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "lessons", content.Class)
+		assert.Equal(t, first.Version, content.SchemaVersion)
+	})
+}
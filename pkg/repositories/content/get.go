@@ -13,6 +13,80 @@ import (
 
 func (r *ContentRepository) GetContent(coll string, id string) (*models.ReadContent, error) {
 	slog.Debug("GetContent called", "collection", coll, "id", id)
+	if content, ok := r.Cache.Content(coll, id); ok {
+		slog.Debug("Content served from cache", "collection", coll, "id", id)
+		return content, nil
+	}
+
+	generation := r.Cache.Generation(coll)
+	content, err := r.findContent(coll, id)
+	if err != nil {
+		return nil, err
+	}
+	r.Cache.PutContent(coll, id, generation, content)
+	return content, nil
+}
+
+// GetContents reads the items with ids from the database, in batches of
+// one query each rather than one query per item. Ids that are not found are
+// left out.
+func (r *ContentRepository) GetContents(coll string, ids []string) ([]models.Content, error) {
+	slog.Debug("GetContents called", "collection", coll, "count", len(ids))
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	found, err := r.findContents(coll, ids)
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]models.Content, 0, len(found))
+	for _, id := range ids {
+		if content, ok := found[id]; ok {
+			contents = append(contents, *content)
+			delete(found, id)
+		}
+	}
+	return contents, nil
+}
+
+func (r *ContentRepository) GetCollection(coll string) ([]models.Content, error) {
+	slog.Debug("GetCollection called", "collection", coll)
+	if contents, ok := r.Cache.Collection(coll); ok {
+		slog.Debug("Collection served from cache", "collection", coll)
+		return contents, nil
+	}
+
+	generation := r.Cache.Generation(coll)
+	contents, err := r.findCollection(coll)
+	if err != nil {
+		return nil, err
+	}
+	r.Cache.PutCollection(coll, generation, contents)
+	return contents, nil
+}
+
+func (r *ContentRepository) GetClass(coll string, class string) ([]models.Content, error) {
+	slog.Debug("GetClass called", "collection", coll, "class", class)
+	if contents, ok := r.Cache.Class(coll, class); ok {
+		slog.Debug("Class served from cache", "collection", coll, "class", class)
+		return contents, nil
+	}
+
+	generation := r.Cache.Generation(coll)
+	contents, err := r.findClass(coll, class)
+	if err != nil {
+		return nil, err
+	}
+	r.Cache.PutClass(coll, class, generation, contents)
+	return contents, nil
+}
+
+// findContent, findCollection and findClass always read from the database,
+// for writes that must not act on a cached copy.
+func (r *ContentRepository) findContent(coll string, id string) (*models.ReadContent, error) {
 	if r.DB == nil {
 		err := errors.New("database connection is nil")
 		slog.Error("Database connection is nil", "error", err)
@@ -28,9 +102,8 @@ func (r *ContentRepository) GetContent(coll string, id string) (*models.ReadCont
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			err := errors.New("content not found")
-			slog.Error("Content not found", "collection", coll, "id", id, "error", err)
-			return nil, err
+			slog.Error("Content not found", "collection", coll, "id", id, "error", ErrContentNotFound)
+			return nil, ErrContentNotFound
 		}
 		slog.Error("Failed to find content", "collection", coll, "id", id, "error", err)
 		return nil, err
@@ -40,9 +113,7 @@ func (r *ContentRepository) GetContent(coll string, id string) (*models.ReadCont
 	return &content, nil
 }
 
-func (r *ContentRepository) GetCollection(coll string) ([]models.Content, error) {
-	slog.Debug("GetCollection called", "collection", coll)
-
+func (r *ContentRepository) findCollection(coll string) ([]models.Content, error) {
 	results, err := r.DB.Collection(coll).Find(
 		context.TODO(),
 		bson.D{},
@@ -69,9 +140,7 @@ func (r *ContentRepository) GetCollection(coll string) ([]models.Content, error)
 	return contents, nil
 }
 
-func (r *ContentRepository) GetClass(coll string, class string) ([]models.Content, error) {
-	slog.Debug("GetClass called", "collection", coll, "class", class)
-
+func (r *ContentRepository) findClass(coll string, class string) ([]models.Content, error) {
 	results, err := r.DB.Collection(coll).Find(
 		context.TODO(),
 		bson.D{{Key: "class", Value: class}},
@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SortFields are the content fields a listing may be ordered by. Custom
+// fields can be used too, as "fields." followed by their name.
+var SortFields = []string{"created_at", "updated_at", "title", "views", "class"}
+
+type ListOptions struct {
+	Class         string
+	IsPublic      *bool
+	CreatorId     string
+	TitleContains string
+	SortBy        string
+	Descending    bool
+	Limit         int64
+	Offset        int64
+	// Fields limits the listing to items whose custom fields, by name, equal
+	// the given strings, numbers, booleans or nulls.
+	Fields map[string]any
+}
+
+// ListContent returns one page of a collection matching opts together with
+// the total number of matching items.
+func (r *ContentRepository) ListContent(coll string, opts ListOptions) ([]models.Content, int64, error) {
+	slog.Debug("ListContent called", "collection", coll, "options", opts)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, 0, err
+	}
+
+	filter := bson.D{}
+	if opts.Class != "" {
+		filter = append(filter, bson.E{Key: "class", Value: opts.Class})
+	}
+	if opts.IsPublic != nil {
+		filter = append(filter, bson.E{Key: "is_public", Value: *opts.IsPublic})
+	}
+	if opts.CreatorId != "" {
+		filter = append(filter, bson.E{Key: "creator_id", Value: opts.CreatorId})
+	}
+	if opts.TitleContains != "" {
+		filter = append(filter, bson.E{Key: "title", Value: bson.D{
+			{Key: "$regex", Value: regexp.QuoteMeta(opts.TitleContains)},
+			{Key: "$options", Value: "i"},
+		}})
+	}
+	fieldFilter, err := customFieldFilter(opts.Fields)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter = append(filter, fieldFilter...)
+
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if !isSortField(sortBy) {
+		return nil, 0, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListOptions, sortBy)
+	}
+	direction := 1
+	if opts.Descending {
+		direction = -1
+	}
+
+	total, err := r.DB.Collection(coll).CountDocuments(context.TODO(), filter)
+	if err != nil {
+		slog.Error("Failed to count contents", "collection", coll, "error", err)
+		return nil, 0, err
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: sortBy, Value: direction}, {Key: "id", Value: 1}}).
+		SetSkip(opts.Offset)
+	if opts.Limit > 0 {
+		findOpts.SetLimit(opts.Limit)
+	}
+
+	cursor, err := r.DB.Collection(coll).Find(context.TODO(), filter, findOpts)
+	if err != nil {
+		slog.Error("Failed to list contents", "collection", coll, "error", err)
+		return nil, 0, err
+	}
+
+	contents := []models.Content{}
+	if err := cursor.All(context.TODO(), &contents); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode results", "collection", coll, "error", err)
+		return nil, 0, err
+	}
+
+	slog.Info("Contents listed successfully", "collection", coll, "count", len(contents), "total", total)
+	return contents, total, nil
+}
+
+// EachContent calls fn for every item of a collection, or of one class when
+// class is set, oldest first. Items are decoded one at a time, so it suits
+// reads that are too large to hold in memory. Iteration stops at the first
+// error from fn, which is returned.
+func (r *ContentRepository) EachContent(ctx context.Context, coll string, class string, fn func(*models.Content) error) error {
+	slog.Debug("EachContent called", "collection", coll, "class", class)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return err
+	}
+
+	filter := bson.D{}
+	if class != "" {
+		filter = append(filter, bson.E{Key: "class", Value: class})
+	}
+
+	cursor, err := r.DB.Collection(coll).Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}}))
+	if err != nil {
+		slog.Error("Failed to find contents", "collection", coll, "error", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var content models.Content
+		if err := cursor.Decode(&content); err != nil {
+			err := fmt.Errorf("failed to decode results: %s", err.Error())
+			slog.Error("Failed to decode content", "collection", coll, "error", err)
+			return err
+		}
+		if err := fn(&content); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		slog.Error("Failed to iterate contents", "collection", coll, "error", err)
+		return err
+	}
+
+	slog.Info("Contents iterated successfully", "collection", coll, "class", class, "count", count)
+	return nil
+}
+
+// ListCollections returns the names of every content collection in sorted
+// order.
+func (r *ContentRepository) ListCollections() ([]string, error) {
+	slog.Debug("ListCollections called")
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	names, err := r.DB.ListCollectionNames(context.TODO(), bson.D{})
+	if err != nil {
+		slog.Error("Failed to list collections", "error", err)
+		return nil, err
+	}
+
+	collections := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, "system.") {
+			continue
+		}
+		collections = append(collections, name)
+	}
+	sort.Strings(collections)
+
+	slog.Info("Collections listed successfully", "count", len(collections))
+	return collections, nil
+}
+
+// ListClasses returns the distinct classes used in a collection in sorted
+// order.
+func (r *ContentRepository) ListClasses(coll string) ([]string, error) {
+	slog.Debug("ListClasses called", "collection", coll)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	values, err := r.DB.Collection(coll).Distinct(context.TODO(), "class", bson.D{})
+	if err != nil {
+		slog.Error("Failed to list classes", "collection", coll, "error", err)
+		return nil, err
+	}
+
+	classes := []string{}
+	for _, v := range values {
+		if class, ok := v.(string); ok {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+
+	slog.Info("Classes listed successfully", "collection", coll, "count", len(classes))
+	return classes, nil
+}
+
+func isSortField(field string) bool {
+	if name, ok := strings.CutPrefix(field, "fields."); ok {
+		return fieldName.MatchString(name)
+	}
+	for _, f := range SortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// customFieldFilter matches the custom fields in fields by equality, in
+// name order so that the same filter always reads the same.
+func customFieldFilter(fields map[string]any) (bson.D, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if !fieldName.MatchString(name) {
+			return nil, fmt.Errorf("%w: cannot filter by field %q", ErrInvalidListOptions, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	filter := bson.D{}
+	for _, name := range names {
+		value := fields[name]
+		switch value.(type) {
+		case nil, string, bool, int, int32, int64, float64:
+		default:
+			return nil, fmt.Errorf("%w: cannot filter field %q by a %T", ErrInvalidListOptions, name, value)
+		}
+		filter = append(filter, bson.E{Key: "fields." + name, Value: value})
+	}
+	return filter, nil
+}
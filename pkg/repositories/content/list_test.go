@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListContent(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{DB: client.Database("content")}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+	}()
+
+	now := time.Now().UTC()
+	for i, title := range []string{"Intro to Go", "Advanced Go", "Intro to Mongo"} {
+		class := "lessons"
+		if i == 2 {
+			class = "quizzes"
+		}
+		_, err := repo.CreateContent(testsCollection, &models.Content{
+			Id:        uuid.New().String(),
+			Class:     class,
+			Title:     title,
+			Views:     i,
+			IsPublic:  i != 1,
+			CreatorId: uuid.New().String(),
+			UpdatedAt: now,
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Filter By Class", func(t *testing.T) {
+		contents, total, err := repo.ListContent(testsCollection, ListOptions{Class: "lessons"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, contents, 2)
+	})
+
+	t.Run("Filter By Title And Visibility", func(t *testing.T) {
+		public := true
+		contents, total, err := repo.ListContent(testsCollection, ListOptions{TitleContains: "intro", IsPublic: &public})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, contents, 2)
+	})
+
+	t.Run("Sort And Paginate", func(t *testing.T) {
+		contents, total, err := repo.ListContent(testsCollection, ListOptions{SortBy: "views", Descending: true, Limit: 1, Offset: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Len(t, contents, 1)
+		assert.Equal(t, "Advanced Go", contents[0].Title)
+	})
+
+	t.Run("Unknown Sort Field", func(t *testing.T) {
+		_, _, err := repo.ListContent(testsCollection, ListOptions{SortBy: "body"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Each Content", func(t *testing.T) {
+		var titles []string
+		err := repo.EachContent(context.TODO(), testsCollection, "lessons", func(c *models.Content) error {
+			titles = append(titles, c.Title)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Intro to Go", "Advanced Go"}, titles)
+	})
+
+	t.Run("List Classes", func(t *testing.T) {
+		classes, err := repo.ListClasses(testsCollection)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"lessons", "quizzes"}, classes)
+	})
+
+	t.Run("List Collections", func(t *testing.T) {
+		collections, err := repo.ListCollections()
+		assert.NoError(t, err)
+		assert.Contains(t, collections, testsCollection)
+	})
+}
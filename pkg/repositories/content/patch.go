@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PatchContent saves content, the result of patching an item last updated
+// at previous, in place of that item. If the item has been updated since,
+// it returns ErrContentChanged so that the patch can be applied again to the
+// new version, since conditions such as JSON Patch tests were checked
+// against the old one, and drops the cached copy of the item. UpdatedAt is
+// set to the current time.
+func (r *ContentRepository) PatchContent(coll string, content *models.Content, previous time.Time) error {
+	slog.Debug("PatchContent called", "collection", coll, "contentID", content.Id)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return err
+	}
+
+	content.UpdatedAt = time.Now().UTC()
+	if err := ValidateContent(content); err != nil {
+		slog.Error("Content validation failed", "error", err)
+		return err
+	}
+	if err := r.ValidateFields(coll, content); err != nil {
+		return err
+	}
+
+	var current struct {
+		Class string `bson:"class"`
+	}
+	err := r.DB.Collection(coll).FindOneAndReplace(
+		context.TODO(),
+		bson.D{{Key: "id", Value: content.Id}, {Key: "updated_at", Value: previous}},
+		content,
+		options.FindOneAndReplace().SetProjection(bson.D{{Key: "class", Value: 1}}),
+	).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		latest, err := r.findContent(coll, content.Id)
+		if err != nil {
+			r.Cache.Invalidate(coll, []string{content.Id})
+			return err
+		}
+		// The cached copy may be what the patch was applied to, as when the
+		// item was changed through another instance, so it is dropped for
+		// the next attempt to read the new version.
+		r.Cache.Invalidate(coll, []string{content.Id}, latest.Class)
+		slog.Error("Content changed while patching", "collection", coll, "contentID", content.Id, "error", ErrContentChanged)
+		return ErrContentChanged
+	}
+	if err != nil {
+		slog.Error("Failed to patch content", "collection", coll, "contentID", content.Id, "error", err)
+		return err
+	}
+
+	slog.Info("Content patched successfully", "collection", coll, "contentID", content.Id)
+	r.Cache.Invalidate(coll, []string{content.Id}, current.Class, content.Class)
+	return r.RecordChanges(coll, []string{content.Id}, false)
+}
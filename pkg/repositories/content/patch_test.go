@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPatchContent(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Database("content").Collection(testsCollection).Drop(context.TODO()); err != nil {
+			log.Fatal(err)
+		}
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{
+		DB:    client.Database("content"),
+		Cache: cache.New(cache.DefaultConfig),
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	content := &models.Content{
+		Id:        uuid.New().String(),
+		Class:     "test-class",
+		Title:     "Initial Title",
+		CreatorId: uuid.New().String(),
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+	_, err = repo.CreateContent(testsCollection, content)
+	assert.NoError(t, err)
+
+	t.Run("Successful Patch", func(t *testing.T) {
+		before, err := repo.GetContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+
+		after := models.Content(*before)
+		after.Title = "Patched Title"
+		assert.NoError(t, repo.PatchContent(testsCollection, &after, before.UpdatedAt))
+
+		patched, err := repo.GetContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Patched Title", patched.Title)
+	})
+
+	t.Run("Changed Elsewhere", func(t *testing.T) {
+		stale, err := repo.GetContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+
+		// Written without going through the cache, as another instance would.
+		_, err = repo.DB.Collection(testsCollection).UpdateOne(
+			context.TODO(),
+			bson.D{{Key: "id", Value: content.Id}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "title", Value: "Elsewhere"}, {Key: "updated_at", Value: time.Now().UTC()}}}},
+		)
+		assert.NoError(t, err)
+
+		after := models.Content(*stale)
+		after.Title = "Lost Title"
+		err = repo.PatchContent(testsCollection, &after, stale.UpdatedAt)
+		assert.ErrorIs(t, err, ErrContentChanged)
+
+		latest, err := repo.GetContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Elsewhere", latest.Title)
+
+		after = models.Content(*latest)
+		after.Title = "Patched Again"
+		assert.NoError(t, repo.PatchContent(testsCollection, &after, latest.UpdatedAt))
+	})
+}
@@ -2,8 +2,6 @@ package repositories
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"log/slog"
 
 	"github.com/YanSystems/cms/pkg/models"
@@ -15,18 +13,20 @@ import (
 func (r *ContentRepository) CreateContent(coll string, content *models.Content) (string, error) {
 	slog.Debug("CreateContent called", "collection", coll, "contentID", content.Id)
 
-	if err := validateContent(content); err != nil {
+	if err := ValidateContent(content); err != nil {
 		slog.Error("Content validation failed", "error", err)
 		return "", err
 	}
+	if err := r.ValidateFields(coll, content); err != nil {
+		return "", err
+	}
 	slog.Info("Content validation passed", "contentID", content.Id)
 
 	var existingContent models.Content
 	err := r.DB.Collection(coll).FindOne(context.TODO(), bson.M{"id": content.Id}).Decode(&existingContent)
 	if err == nil {
-		err := errors.New("content with this ID already exists")
-		slog.Error("Content with this ID already exists", "contentID", content.Id, "error", err)
-		return "", err
+		slog.Error("Content with this ID already exists", "contentID", content.Id, "error", ErrContentExists)
+		return "", ErrContentExists
 	}
 	slog.Debug("No existing content with this ID found", "contentID", content.Id)
 
@@ -39,6 +39,10 @@ func (r *ContentRepository) CreateContent(coll string, content *models.Content)
 		return "", err
 	}
 	slog.Info("Content inserted successfully", "collection", coll, "contentID", content.Id)
+	r.Cache.Invalidate(coll, []string{content.Id}, content.Class)
+	if err := r.RecordChanges(coll, []string{content.Id}, false); err != nil {
+		return "", err
+	}
 
 	return content.Id, nil
 }
@@ -48,14 +52,16 @@ func validateUUID(fl validator.FieldLevel) bool {
 	return err == nil
 }
 
-func validateContent(content *models.Content) error {
+// ValidateContent checks content against the rules every stored item must
+// meet, so callers can check items they are not about to write.
+func ValidateContent(content *models.Content) error {
 	slog.Debug("Validating content", "contentID", content.Id)
 	validate := validator.New()
 	validate.RegisterValidation("uuid", validateUUID)
 	err := validate.Struct(content)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
-			validationError := fmt.Errorf("field %s is not valid: %v", err.StructField(), err)
+			validationError := &ValidationError{Field: err.StructField(), Err: err}
 			slog.Error("Validation error", "field", err.StructField(), "error", validationError)
 			return validationError
 		}
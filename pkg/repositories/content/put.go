@@ -13,12 +13,13 @@ func (r *ContentRepository) UpdateContent(coll string, id string, updatedContent
 	slog.Info("UpdateContent called", "collection", coll, "id", id)
 
 	// Fetch the current content from the database
-	currentContent, err := r.GetContent(coll, id)
+	currentContent, err := r.findContent(coll, id)
 	if err != nil {
 		slog.Error("Failed to get current content", "collection", coll, "id", id, "error", err)
 		return "", err
 	}
 	slog.Debug("Current content fetched", "currentContent", currentContent)
+	previousClass := currentContent.Class
 
 	// Update fields only if they are set in updatedContent
 	if updatedContent.Class != nil {
@@ -49,6 +50,18 @@ func (r *ContentRepository) UpdateContent(coll string, id string, updatedContent
 		slog.Debug("Updating IsPublic", "oldValue", currentContent.IsPublic, "newValue", *updatedContent.IsPublic)
 		currentContent.IsPublic = *updatedContent.IsPublic
 	}
+	if updatedContent.Fields != nil {
+		slog.Debug("Updating Fields", "oldValue", currentContent.Fields, "newValue", updatedContent.Fields)
+		currentContent.Fields = updatedContent.Fields
+	}
+
+	// The class or the fields may have changed, so check the fields against
+	// the schema of the class the item ends up in
+	content := models.Content(*currentContent)
+	if err := r.ValidateFields(coll, &content); err != nil {
+		return "", err
+	}
+	currentContent.SchemaVersion = content.SchemaVersion
 
 	// Always update UpdatedAt to the current time
 	currentContent.UpdatedAt = time.Now().UTC()
@@ -57,11 +70,24 @@ func (r *ContentRepository) UpdateContent(coll string, id string, updatedContent
 	// Ensure CreatedAt is never updated
 	updatedContent.CreatedAt = &currentContent.CreatedAt
 
+	// Empty fields are left out of $set, so they have to be removed
+	update := bson.D{{Key: "$set", Value: currentContent}}
+	unset := bson.D{}
+	if len(currentContent.Fields) == 0 {
+		unset = append(unset, bson.E{Key: "fields", Value: ""})
+	}
+	if currentContent.SchemaVersion == 0 {
+		unset = append(unset, bson.E{Key: "schema_version", Value: ""})
+	}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+
 	// Update the document in the database
 	_, err = r.DB.Collection(coll).UpdateOne(
 		context.TODO(),
 		bson.D{{Key: "id", Value: id}},
-		bson.D{{Key: "$set", Value: currentContent}},
+		update,
 	)
 
 	if err != nil {
@@ -70,5 +96,9 @@ func (r *ContentRepository) UpdateContent(coll string, id string, updatedContent
 	}
 
 	slog.Info("Content updated successfully", "collection", coll, "id", id)
+	r.Cache.Invalidate(coll, []string{id}, previousClass, currentContent.Class)
+	if err := r.RecordChanges(coll, []string{id}, false); err != nil {
+		return "", err
+	}
 	return id, nil
 }
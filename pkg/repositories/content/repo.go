@@ -1,9 +1,14 @@
 package repositories
 
 import (
+	"github.com/YanSystems/cms/pkg/cache"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ContentRepository struct {
-	DB *mongo.Database
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+	// Cache serves GetContent, GetCollection and GetClass when it is set.
+	// Writes through the repository keep it up to date.
+	Cache *cache.Cache
 }
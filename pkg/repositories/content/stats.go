@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CollectionStats counts the items of a collection, public and private, and
+// its classes in one aggregation, and reads its size from MongoDB's
+// statistics. A
+// collection that does not exist has zero of everything.
+func (r *ContentRepository) CollectionStats(coll string) (*models.CollectionStats, error) {
+	slog.Debug("CollectionStats called", "collection", coll)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	// Items are grouped by class first and the classes counted after, so
+	// that no stage has to hold the set of class names in one document.
+	cursor, err := r.DB.Collection(coll).Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$class"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "public", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{"$is_public", 1, 0}}}}}},
+			{Key: "updated_at", Value: bson.D{{Key: "$max", Value: "$updated_at"}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: "$count"}}},
+			{Key: "public", Value: bson.D{{Key: "$sum", Value: "$public"}}},
+			{Key: "classes", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "updated_at", Value: bson.D{{Key: "$max", Value: "$updated_at"}}},
+		}}},
+	})
+	if err != nil {
+		slog.Error("Failed to aggregate collection stats", "collection", coll, "error", err)
+		return nil, err
+	}
+
+	var counts []struct {
+		Count     int64     `bson:"count"`
+		Public    int64     `bson:"public"`
+		Classes   int       `bson:"classes"`
+		UpdatedAt time.Time `bson:"updated_at"`
+	}
+	if err := cursor.All(context.TODO(), &counts); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode collection stats", "collection", coll, "error", err)
+		return nil, err
+	}
+
+	stats := &models.CollectionStats{Name: coll}
+	var lastModified time.Time
+	if len(counts) > 0 {
+		stats.Count = counts[0].Count
+		stats.Public = counts[0].Public
+		stats.Private = counts[0].Count - counts[0].Public
+		stats.Classes = counts[0].Classes
+		stats.PublicRatio = float64(stats.Public) / float64(stats.Count)
+		lastModified = counts[0].UpdatedAt
+	}
+
+	// Deletions leave no updated_at behind, so the change log is the better
+	// record of when the collection last changed, where there is one.
+	if r.SystemDB != nil {
+		if changed, err := r.LastChanged(coll); err == nil && changed.After(lastModified) {
+			lastModified = changed
+		}
+	}
+	if !lastModified.IsZero() {
+		stats.LastModified = &lastModified
+	}
+
+	if err := r.readStorageStats(coll, stats); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Collection stats computed successfully", "collection", coll, "count", stats.Count)
+	return stats, nil
+}
+
+// readStorageStats fills in the size of the collection's documents and the
+// space they take on disk.
+func (r *ContentRepository) readStorageStats(coll string, stats *models.CollectionStats) error {
+	cursor, err := r.DB.Collection(coll).Aggregate(context.TODO(), mongo.Pipeline{
+		{{Key: "$collStats", Value: bson.D{{Key: "storageStats", Value: bson.D{}}}}},
+	})
+	if err != nil {
+		var serverErr mongo.ServerError
+		// NamespaceNotFound is returned for collections that do not exist.
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(26) {
+			return nil
+		}
+		slog.Error("Failed to read collection storage stats", "collection", coll, "error", err)
+		return err
+	}
+
+	var storage []struct {
+		StorageStats struct {
+			Size        int64 `bson:"size"`
+			StorageSize int64 `bson:"storageSize"`
+		} `bson:"storageStats"`
+	}
+	if err := cursor.All(context.TODO(), &storage); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode collection storage stats", "collection", coll, "error", err)
+		return err
+	}
+
+	// Sharded collections have a document per shard.
+	for _, shard := range storage {
+		stats.DataSize += shard.StorageStats.Size
+		stats.StorageSize += shard.StorageStats.StorageSize
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionStats(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{DB: client.Database("content")}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+	}()
+
+	t.Run("Non-Existent Collection", func(t *testing.T) {
+		stats, err := repo.CollectionStats(testsCollection)
+		assert.NoError(t, err)
+		assert.Zero(t, stats.Count)
+		assert.Nil(t, stats.LastModified)
+	})
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for i, class := range []string{"lessons", "lessons", "quizzes", "quizzes"} {
+		_, err := repo.CreateContent(testsCollection, &models.Content{
+			Id:        uuid.New().String(),
+			Class:     class,
+			Title:     "Title",
+			IsPublic:  i == 0,
+			CreatorId: uuid.New().String(),
+			UpdatedAt: now.Add(time.Duration(i) * time.Second),
+			CreatedAt: now,
+		})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Counts", func(t *testing.T) {
+		stats, err := repo.CollectionStats(testsCollection)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), stats.Count)
+		assert.Equal(t, int64(1), stats.Public)
+		assert.Equal(t, int64(3), stats.Private)
+		assert.Equal(t, 0.25, stats.PublicRatio)
+		assert.Equal(t, 2, stats.Classes)
+		assert.Equal(t, now.Add(3*time.Second), *stats.LastModified)
+		assert.Positive(t, stats.DataSize)
+	})
+}
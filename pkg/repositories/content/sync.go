@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	sequencesCollection = "sync_sequences"
+	changesCollection   = "sync_changes"
+)
+
+// Changes younger than this are held back from sync responses. Sequence
+// numbers are allocated before the change is recorded, so a concurrent write
+// with a lower number can still land shortly after a higher one.
+const syncSettleWindow = 2 * time.Second
+
+type change struct {
+	Collection string    `bson:"collection"`
+	Id         string    `bson:"id"`
+	Seq        int64     `bson:"seq"`
+	Deleted    bool      `bson:"deleted"`
+	At         time.Time `bson:"at"`
+}
+
+func (r *ContentRepository) EnsureSyncIndexes() error {
+	_, err := r.SystemDB.Collection(changesCollection).Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "collection", Value: 1}, {Key: "seq", Value: 1}}},
+	})
+	if err != nil {
+		slog.Error("Failed to create sync indexes", "error", err)
+	}
+	return err
+}
+
+// RecordChanges gives each of the ids the next sequence numbers of the
+// collection. Only the latest change per item is kept, which is all a sync
+// client needs and keeps the index the size of the collection. An error means
+// sync clients may miss the change, so callers must not treat it as written.
+func (r *ContentRepository) RecordChanges(coll string, ids []string, deleted bool) error {
+	if r.SystemDB == nil || len(ids) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	var sequence struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.SystemDB.Collection(sequencesCollection).FindOneAndUpdate(
+		context.TODO(),
+		bson.D{{Key: "collection", Value: coll}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: len(ids)}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&sequence)
+	if err != nil {
+		slog.Error("Failed to allocate change sequence", "collection", coll, "error", err)
+		return err
+	}
+
+	first := sequence.Seq - int64(len(ids)) + 1
+	writes := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		writes[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "collection", Value: coll}, {Key: "id", Value: id}}).
+			SetReplacement(change{Collection: coll, Id: id, Seq: first + int64(i), Deleted: deleted, At: now}).
+			SetUpsert(true)
+	}
+
+	_, err = r.SystemDB.Collection(changesCollection).BulkWrite(context.TODO(), writes)
+	if err != nil {
+		slog.Error("Failed to record changes", "collection", coll, "error", err)
+		return err
+	}
+	// LastChanged may have been read again between the write and now.
+	r.Cache.Invalidate(coll, nil)
+	slog.Debug("Changes recorded", "collection", coll, "count", len(ids), "seq", sequence.Seq)
+	return nil
+}
+
+func (r *ContentRepository) CurrentSequence(coll string) (int64, error) {
+	slog.Debug("CurrentSequence called", "collection", coll)
+	if r.SystemDB == nil {
+		err := errors.New("change tracking is not configured")
+		slog.Error("Change tracking is not configured", "error", err)
+		return 0, err
+	}
+
+	var sequence struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.SystemDB.Collection(sequencesCollection).FindOne(
+		context.TODO(),
+		bson.D{{Key: "collection", Value: coll}},
+	).Decode(&sequence)
+	if err != nil && err != mongo.ErrNoDocuments {
+		slog.Error("Failed to read change sequence", "collection", coll, "error", err)
+		return 0, err
+	}
+
+	return sequence.Seq, nil
+}
+
+// LastChanged returns when an item of the collection was last created,
+// updated or deleted, or the zero time when no change has been recorded.
+func (r *ContentRepository) LastChanged(coll string) (time.Time, error) {
+	slog.Debug("LastChanged called", "collection", coll)
+	if r.SystemDB == nil {
+		err := errors.New("change tracking is not configured")
+		slog.Error("Change tracking is not configured", "error", err)
+		return time.Time{}, err
+	}
+	if at, ok := r.Cache.LastChanged(coll); ok {
+		return at, nil
+	}
+
+	generation := r.Cache.Generation(coll)
+	var latest change
+	err := r.SystemDB.Collection(changesCollection).FindOne(
+		context.TODO(),
+		bson.D{{Key: "collection", Value: coll}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+	).Decode(&latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		slog.Error("Failed to read latest change", "collection", coll, "error", err)
+		return time.Time{}, err
+	}
+
+	r.Cache.PutLastChanged(coll, generation, latest.At)
+	return latest.At, nil
+}
+
+// GetChanges returns up to limit changes after since in sequence order, along
+// with the sequence number to continue from.
+func (r *ContentRepository) GetChanges(coll string, since int64, limit int64) ([]models.SyncChange, int64, bool, error) {
+	slog.Debug("GetChanges called", "collection", coll, "since", since, "limit", limit)
+	if r.SystemDB == nil {
+		err := errors.New("change tracking is not configured")
+		slog.Error("Change tracking is not configured", "error", err)
+		return nil, since, false, err
+	}
+
+	// A change that has not settled may still be joined by one with a lower
+	// sequence number, so the response stops short of the first of them.
+	// Leaving out only the unsettled changes would let the token move past
+	// the lower one before it lands.
+	var unsettled change
+	err := r.SystemDB.Collection(changesCollection).FindOne(
+		context.TODO(),
+		bson.D{
+			{Key: "collection", Value: coll},
+			{Key: "seq", Value: bson.D{{Key: "$gt", Value: since}}},
+			{Key: "at", Value: bson.D{{Key: "$gt", Value: time.Now().UTC().Add(-syncSettleWindow)}}},
+		},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	).Decode(&unsettled)
+	if err != nil && err != mongo.ErrNoDocuments {
+		slog.Error("Failed to find unsettled changes", "collection", coll, "error", err)
+		return nil, since, false, err
+	}
+
+	seq := bson.D{{Key: "$gt", Value: since}}
+	if err == nil {
+		seq = append(seq, bson.E{Key: "$lt", Value: unsettled.Seq})
+	}
+
+	results, err := r.SystemDB.Collection(changesCollection).Find(
+		context.TODO(),
+		bson.D{{Key: "collection", Value: coll}, {Key: "seq", Value: seq}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(limit+1),
+	)
+	if err != nil {
+		slog.Error("Failed to find changes", "collection", coll, "error", err)
+		return nil, since, false, err
+	}
+
+	var changes []change
+	err = results.All(context.TODO(), &changes)
+	if err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode changes", "collection", coll, "error", err)
+		return nil, since, false, err
+	}
+
+	hasMore := int64(len(changes)) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+
+	var live []string
+	for _, c := range changes {
+		if !c.Deleted {
+			live = append(live, c.Id)
+		}
+	}
+
+	contents := map[string]models.Content{}
+	if len(live) > 0 {
+		found, err := r.DB.Collection(coll).Find(context.TODO(), bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: live}}}})
+		if err != nil {
+			slog.Error("Failed to find changed contents", "collection", coll, "error", err)
+			return nil, since, false, err
+		}
+		var docs []models.Content
+		if err := found.All(context.TODO(), &docs); err != nil {
+			err := fmt.Errorf("failed to decode results: %s", err.Error())
+			slog.Error("Failed to decode changed contents", "collection", coll, "error", err)
+			return nil, since, false, err
+		}
+		for _, doc := range docs {
+			contents[doc.Id] = doc
+		}
+	}
+
+	next := since
+	syncChanges := make([]models.SyncChange, 0, len(changes))
+	for _, c := range changes {
+		next = c.Seq
+		content, ok := contents[c.Id]
+		if c.Deleted || !ok {
+			syncChanges = append(syncChanges, models.SyncChange{Id: c.Id, Deleted: true})
+			continue
+		}
+		syncChanges = append(syncChanges, models.SyncChange{Id: c.Id, Content: &content})
+	}
+
+	slog.Info("Changes retrieved successfully", "collection", coll, "count", len(syncChanges), "next", next)
+	return syncChanges, next, hasMore, nil
+}
+
+// GetSnapshot returns up to limit items of a collection in id order, starting
+// after the id after, and whether more follow. Paging by id holds up while
+// items are written, as the sync changes recorded since the snapshot began
+// cover anything a page missed.
+func (r *ContentRepository) GetSnapshot(coll string, after string, limit int64) ([]models.Content, bool, error) {
+	slog.Debug("GetSnapshot called", "collection", coll, "after", after, "limit", limit)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, false, err
+	}
+
+	filter := bson.D{}
+	if after != "" {
+		filter = append(filter, bson.E{Key: "id", Value: bson.D{{Key: "$gt", Value: after}}})
+	}
+
+	results, err := r.DB.Collection(coll).Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(limit+1),
+	)
+	if err != nil {
+		slog.Error("Failed to find snapshot contents", "collection", coll, "error", err)
+		return nil, false, err
+	}
+
+	contents := []models.Content{}
+	if err := results.All(context.TODO(), &contents); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode snapshot contents", "collection", coll, "error", err)
+		return nil, false, err
+	}
+
+	hasMore := int64(len(contents)) > limit
+	if hasMore {
+		contents = contents[:limit]
+	}
+
+	slog.Info("Snapshot page retrieved successfully", "collection", coll, "count", len(contents), "hasMore", hasMore)
+	return contents, hasMore, nil
+}
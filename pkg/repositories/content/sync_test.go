@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGetChanges(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{
+		DB:       client.Database("content"),
+		SystemDB: client.Database("cms-tests"),
+	}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+		repo.SystemDB.Collection(changesCollection).DeleteMany(context.TODO(), bson.D{{Key: "collection", Value: testsCollection}})
+		repo.SystemDB.Collection(sequencesCollection).DeleteMany(context.TODO(), bson.D{{Key: "collection", Value: testsCollection}})
+	}()
+
+	newContent := func(class string) *models.Content {
+		return &models.Content{
+			Id:        uuid.New().String(),
+			Class:     class,
+			CreatorId: uuid.New().String(),
+			UpdatedAt: time.Now(),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	first := newContent("lessons")
+	second := newContent("quizzes")
+
+	since, err := repo.CurrentSequence(testsCollection)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), since)
+
+	_, err = repo.CreateContent(testsCollection, first)
+	assert.NoError(t, err)
+	_, err = repo.CreateContent(testsCollection, second)
+	assert.NoError(t, err)
+
+	title := "updated"
+	_, err = repo.UpdateContent(testsCollection, first.Id, &models.UpdateContent{Title: &title})
+	assert.NoError(t, err)
+	_, err = repo.DeleteContent(testsCollection, second.Id)
+	assert.NoError(t, err)
+
+	time.Sleep(syncSettleWindow)
+
+	t.Run("Latest Change Per Item In Order", func(t *testing.T) {
+		changes, next, hasMore, err := repo.GetChanges(testsCollection, since, 10)
+		assert.NoError(t, err)
+		assert.False(t, hasMore)
+		assert.Equal(t, int64(4), next)
+		assert.Len(t, changes, 2)
+
+		assert.Equal(t, first.Id, changes[0].Id)
+		assert.False(t, changes[0].Deleted)
+		assert.Equal(t, "updated", changes[0].Content.Title)
+
+		assert.Equal(t, second.Id, changes[1].Id)
+		assert.True(t, changes[1].Deleted)
+		assert.Nil(t, changes[1].Content)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		changes, next, hasMore, err := repo.GetChanges(testsCollection, since, 1)
+		assert.NoError(t, err)
+		assert.True(t, hasMore)
+		assert.Len(t, changes, 1)
+
+		changes, _, hasMore, err = repo.GetChanges(testsCollection, next, 1)
+		assert.NoError(t, err)
+		assert.False(t, hasMore)
+		assert.Len(t, changes, 1)
+		assert.True(t, changes[0].Deleted)
+	})
+
+	t.Run("Nothing New", func(t *testing.T) {
+		changes, next, _, err := repo.GetChanges(testsCollection, 4, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+		assert.Equal(t, int64(4), next)
+	})
+
+	t.Run("Last Changed Includes Deletions", func(t *testing.T) {
+		at, err := repo.LastChanged(testsCollection)
+		assert.NoError(t, err)
+		assert.False(t, at.Before(first.UpdatedAt.Truncate(time.Millisecond)))
+
+		at, err = repo.LastChanged(uuid.New().String())
+		assert.NoError(t, err)
+		assert.True(t, at.IsZero())
+	})
+
+	t.Run("Not Configured", func(t *testing.T) {
+		repoNoSystemDB := ContentRepository{DB: repo.DB}
+		_, _, _, err := repoNoSystemDB.GetChanges(testsCollection, 0, 10)
+		assert.EqualError(t, err, "change tracking is not configured")
+	})
+
+	t.Run("Snapshot Pages", func(t *testing.T) {
+		third := newContent("lessons")
+		_, err := repo.CreateContent(testsCollection, third)
+		assert.NoError(t, err)
+
+		contents, hasMore, err := repo.GetSnapshot(testsCollection, "", 1)
+		assert.NoError(t, err)
+		assert.True(t, hasMore)
+		assert.Len(t, contents, 1)
+
+		rest, hasMore, err := repo.GetSnapshot(testsCollection, contents[0].Id, 1)
+		assert.NoError(t, err)
+		assert.False(t, hasMore)
+		assert.Len(t, rest, 1)
+		assert.ElementsMatch(t, []string{first.Id, third.Id}, []string{contents[0].Id, rest[0].Id})
+	})
+}
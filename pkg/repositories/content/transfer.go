@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ids are looked up in batches to keep each query well under the BSON
+// document size limit.
+const existingIdsBatch = 1000
+
+// ExistingIds reports which of ids are already used in a collection.
+func (r *ContentRepository) ExistingIds(coll string, ids []string) (map[string]bool, error) {
+	slog.Debug("ExistingIds called", "collection", coll, "count", len(ids))
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	for start := 0; start < len(ids); start += existingIdsBatch {
+		batch := ids[start:min(start+existingIdsBatch, len(ids))]
+		cursor, err := r.DB.Collection(coll).Find(
+			context.TODO(),
+			bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: batch}}}},
+			options.Find().SetProjection(bson.D{{Key: "id", Value: 1}}),
+		)
+		if err != nil {
+			slog.Error("Failed to find existing ids", "collection", coll, "error", err)
+			return nil, err
+		}
+
+		var docs []struct {
+			Id string `bson:"id"`
+		}
+		if err := cursor.All(context.TODO(), &docs); err != nil {
+			err := fmt.Errorf("failed to decode results: %s", err.Error())
+			slog.Error("Failed to decode existing ids", "collection", coll, "error", err)
+			return nil, err
+		}
+		for _, doc := range docs {
+			existing[doc.Id] = true
+		}
+	}
+
+	slog.Debug("Existing ids found", "collection", coll, "count", len(existing))
+	return existing, nil
+}
+
+// ReplaceContent overwrites an existing item with content as given,
+// timestamps included, which is what an import of a previous export needs.
+func (r *ContentRepository) ReplaceContent(coll string, content *models.Content) error {
+	slog.Debug("ReplaceContent called", "collection", coll, "contentID", content.Id)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return err
+	}
+
+	if err := ValidateContent(content); err != nil {
+		slog.Error("Content validation failed", "error", err)
+		return err
+	}
+	if err := r.ValidateFields(coll, content); err != nil {
+		return err
+	}
+
+	var previous struct {
+		Class string `bson:"class"`
+	}
+	err := r.DB.Collection(coll).FindOneAndReplace(
+		context.TODO(),
+		bson.D{{Key: "id", Value: content.Id}},
+		content,
+		options.FindOneAndReplace().SetProjection(bson.D{{Key: "class", Value: 1}}),
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		slog.Error("Content not found", "collection", coll, "contentID", content.Id, "error", ErrContentNotFound)
+		return ErrContentNotFound
+	}
+	if err != nil {
+		slog.Error("Failed to replace content", "collection", coll, "contentID", content.Id, "error", err)
+		return err
+	}
+
+	slog.Info("Content replaced successfully", "collection", coll, "contentID", content.Id)
+	r.Cache.Invalidate(coll, []string{content.Id}, previous.Class, content.Class)
+	return r.RecordChanges(coll, []string{content.Id}, false)
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransfer(t *testing.T) {
+	testsCollection := uuid.New().String()
+
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := ContentRepository{DB: client.Database("content")}
+
+	defer func() {
+		_, err := repo.DeleteCollection(testsCollection)
+		assert.NoError(t, err)
+	}()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	content := models.Content{
+		Id:        uuid.New().String(),
+		Class:     "lessons",
+		Title:     "Intro",
+		CreatorId: uuid.New().String(),
+		UpdatedAt: created,
+		CreatedAt: created,
+	}
+	_, err = repo.CreateContent(testsCollection, &content)
+	assert.NoError(t, err)
+
+	t.Run("Existing Ids", func(t *testing.T) {
+		missing := uuid.New().String()
+		existing, err := repo.ExistingIds(testsCollection, []string{content.Id, missing})
+		assert.NoError(t, err)
+		assert.True(t, existing[content.Id])
+		assert.False(t, existing[missing])
+	})
+
+	t.Run("Replace Content", func(t *testing.T) {
+		replacement := content
+		replacement.Title = "Introduction"
+		replacement.UpdatedAt = created.Add(time.Hour)
+		assert.NoError(t, repo.ReplaceContent(testsCollection, &replacement))
+
+		found, err := repo.GetContent(testsCollection, content.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Introduction", found.Title)
+		assert.Equal(t, created.Add(time.Hour), found.UpdatedAt)
+	})
+
+	t.Run("Replace Missing Content", func(t *testing.T) {
+		missing := content
+		missing.Id = uuid.New().String()
+		assert.ErrorIs(t, repo.ReplaceContent(testsCollection, &missing), ErrContentNotFound)
+	})
+}
@@ -0,0 +1,13 @@
+package schemas
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const schemasCollection = "content_schemas"
+
+// SchemaRepository keeps every version of the schemas of content classes in
+// the system database.
+type SchemaRepository struct {
+	DB *mongo.Database
+}
@@ -0,0 +1,203 @@
+package schemas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// createAttempts is how many times Create picks the next version when other
+// requests keep taking it first.
+const createAttempts = 3
+
+// schemaDocument stores the schema as JSON text, since keywords such as
+// $schema are not safe to use as field names in every MongoDB version.
+type schemaDocument struct {
+	Collection string    `bson:"collection"`
+	Class      string    `bson:"class"`
+	Version    int       `bson:"version"`
+	Schema     string    `bson:"schema"`
+	CreatedAt  time.Time `bson:"created_at"`
+}
+
+func (d *schemaDocument) toModel() (*models.ContentSchema, error) {
+	s := &models.ContentSchema{
+		Collection: d.Collection,
+		Class:      d.Class,
+		Version:    d.Version,
+		CreatedAt:  d.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(d.Schema), &s.Schema); err != nil {
+		return nil, fmt.Errorf("failed to decode schema: %s", err.Error())
+	}
+	return s, nil
+}
+
+func (r *SchemaRepository) EnsureIndexes() error {
+	_, err := r.DB.Collection(schemasCollection).Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "class", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		slog.Error("Failed to create schema indexes", "error", err)
+	}
+	return err
+}
+
+// Create stores schema as the next version of the schema of its class,
+// starting at 1, and returns it with its version set.
+func (r *SchemaRepository) Create(schema *models.ContentSchema) (*models.ContentSchema, error) {
+	slog.Debug("Create called", "collection", schema.Collection, "class", schema.Class)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	data, err := json.Marshal(schema.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		version := 1
+		latest, err := r.Latest(schema.Collection, schema.Class)
+		switch {
+		case err == nil:
+			version = latest.Version + 1
+		case !errors.Is(err, ErrSchemaNotFound):
+			return nil, err
+		}
+
+		_, err = r.DB.Collection(schemasCollection).InsertOne(context.TODO(), schemaDocument{
+			Collection: schema.Collection,
+			Class:      schema.Class,
+			Version:    version,
+			Schema:     string(data),
+			CreatedAt:  schema.CreatedAt,
+		})
+		if mongo.IsDuplicateKeyError(err) && attempt < createAttempts {
+			slog.Debug("Schema version taken, retrying", "collection", schema.Collection, "class", schema.Class, "version", version)
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to insert schema", "collection", schema.Collection, "class", schema.Class, "error", err)
+			return nil, err
+		}
+
+		slog.Info("Schema created successfully", "collection", schema.Collection, "class", schema.Class, "version", version)
+		created := *schema
+		created.Version = version
+		return &created, nil
+	}
+}
+
+// Latest returns the newest version of the schema of a class.
+func (r *SchemaRepository) Latest(coll string, class string) (*models.ContentSchema, error) {
+	slog.Debug("Latest called", "collection", coll, "class", class)
+	return r.findOne(
+		bson.D{{Key: "collection", Value: coll}, {Key: "class", Value: class}},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}),
+	)
+}
+
+// Get returns one version of the schema of a class.
+func (r *SchemaRepository) Get(coll string, class string, version int) (*models.ContentSchema, error) {
+	slog.Debug("Get called", "collection", coll, "class", class, "version", version)
+	return r.findOne(bson.D{{Key: "collection", Value: coll}, {Key: "class", Value: class}, {Key: "version", Value: version}})
+}
+
+func (r *SchemaRepository) findOne(filter bson.D, opts ...*options.FindOneOptions) (*models.ContentSchema, error) {
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	var doc schemaDocument
+	err := r.DB.Collection(schemasCollection).FindOne(context.TODO(), filter, opts...).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrSchemaNotFound
+		}
+		slog.Error("Failed to find schema", "error", err)
+		return nil, err
+	}
+	return doc.toModel()
+}
+
+// Versions returns every version of the schema of a class, oldest first.
+func (r *SchemaRepository) Versions(coll string, class string) ([]models.ContentSchema, error) {
+	slog.Debug("Versions called", "collection", coll, "class", class)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	cursor, err := r.DB.Collection(schemasCollection).Find(
+		context.TODO(),
+		bson.D{{Key: "collection", Value: coll}, {Key: "class", Value: class}},
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}),
+	)
+	if err != nil {
+		slog.Error("Failed to find schema versions", "collection", coll, "class", class, "error", err)
+		return nil, err
+	}
+
+	var docs []schemaDocument
+	if err := cursor.All(context.TODO(), &docs); err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode schema versions", "collection", coll, "class", class, "error", err)
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrSchemaNotFound
+	}
+
+	versions := make([]models.ContentSchema, len(docs))
+	for i := range docs {
+		s, err := docs[i].toModel()
+		if err != nil {
+			return nil, err
+		}
+		versions[i] = *s
+	}
+	return versions, nil
+}
+
+// Delete removes every version of the schema of a class, after which the
+// custom fields of its items are no longer checked.
+func (r *SchemaRepository) Delete(coll string, class string) error {
+	slog.Debug("Delete called", "collection", coll, "class", class)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return err
+	}
+
+	result, err := r.DB.Collection(schemasCollection).DeleteMany(
+		context.TODO(),
+		bson.D{{Key: "collection", Value: coll}, {Key: "class", Value: class}},
+	)
+	if err != nil {
+		slog.Error("Failed to delete schema", "collection", coll, "class", class, "error", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrSchemaNotFound
+	}
+
+	slog.Info("Schema deleted successfully", "collection", coll, "class", class, "versions", result.DeletedCount)
+	return nil
+}
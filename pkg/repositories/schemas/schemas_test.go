@@ -0,0 +1,87 @@
+package schemas
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemas(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := SchemaRepository{
+		DB: client.Database("cms-tests"),
+	}
+	assert.NoError(t, repo.EnsureIndexes())
+
+	coll := uuid.New().String()
+	created := time.Now().UTC().Truncate(time.Millisecond)
+
+	defer repo.Delete(coll, "lesson")
+
+	t.Run("Create", func(t *testing.T) {
+		first, err := repo.Create(&models.ContentSchema{
+			Collection: coll,
+			Class:      "lesson",
+			Schema:     map[string]any{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object"},
+			CreatedAt:  created,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, first.Version)
+
+		second, err := repo.Create(&models.ContentSchema{
+			Collection: coll,
+			Class:      "lesson",
+			Schema:     map[string]any{"type": "object", "required": []any{"duration"}},
+			CreatedAt:  created,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, second.Version)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		latest, err := repo.Latest(coll, "lesson")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, latest.Version)
+		assert.Equal(t, []any{"duration"}, latest.Schema["required"])
+
+		first, err := repo.Get(coll, "lesson", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", first.Schema["$schema"])
+		assert.Equal(t, created, first.CreatedAt)
+
+		_, err = repo.Get(coll, "lesson", 3)
+		assert.ErrorIs(t, err, ErrSchemaNotFound)
+		_, err = repo.Latest(coll, "quiz")
+		assert.ErrorIs(t, err, ErrSchemaNotFound)
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		versions, err := repo.Versions(coll, "lesson")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 2)
+		assert.Equal(t, 1, versions[0].Version)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.NoError(t, repo.Delete(coll, "lesson"))
+		_, err := repo.Versions(coll, "lesson")
+		assert.ErrorIs(t, err, ErrSchemaNotFound)
+		assert.ErrorIs(t, repo.Delete(coll, "lesson"), ErrSchemaNotFound)
+	})
+}
@@ -0,0 +1,149 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *WebhookRepository) EnsureIndexes() error {
+	_, err := r.DB.Collection(deliveriesCollection).Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		slog.Error("Failed to create webhook delivery indexes", "error", err)
+	}
+	return err
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) (string, error) {
+	slog.Debug("CreateDelivery called", "id", delivery.Id, "webhook", delivery.WebhookId)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	_, err := r.DB.Collection(deliveriesCollection).InsertOne(context.TODO(), delivery)
+	if err != nil {
+		slog.Error("Failed to insert webhook delivery", "id", delivery.Id, "error", err)
+		return "", err
+	}
+
+	return delivery.Id, nil
+}
+
+func (r *WebhookRepository) GetDelivery(webhookId string, id string) (*models.WebhookDelivery, error) {
+	slog.Debug("GetDelivery called", "webhook", webhookId, "id", id)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	var delivery models.WebhookDelivery
+	err := r.DB.Collection(deliveriesCollection).FindOne(
+		context.TODO(),
+		bson.D{{Key: "webhook_id", Value: webhookId}, {Key: "id", Value: id}},
+	).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("delivery not found")
+			slog.Error("Delivery not found", "id", id, "error", err)
+			return nil, err
+		}
+		slog.Error("Failed to find delivery", "id", id, "error", err)
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(webhookId string, limit int64) ([]models.WebhookDelivery, error) {
+	slog.Debug("ListDeliveries called", "webhook", webhookId)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	results, err := r.DB.Collection(deliveriesCollection).Find(
+		context.TODO(),
+		bson.D{{Key: "webhook_id", Value: webhookId}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		slog.Error("Failed to find deliveries", "webhook", webhookId, "error", err)
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err = results.All(context.TODO(), &deliveries)
+	if err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode deliveries", "webhook", webhookId, "error", err)
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return []models.WebhookDelivery{}, nil
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDelivery picks the next pending delivery that is due and pushes its
+// next attempt back by the lease, so other replicas polling at the same time
+// skip it while this one makes the attempt.
+func (r *WebhookRepository) ClaimDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	if r.DB == nil {
+		return nil, errors.New("database connection is nil")
+	}
+
+	var delivery models.WebhookDelivery
+	err := r.DB.Collection(deliveriesCollection).FindOneAndUpdate(
+		context.TODO(),
+		bson.D{
+			{Key: "status", Value: models.DeliveryPending},
+			{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "next_attempt_at", Value: now.Add(lease)}}}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}),
+	).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		slog.Error("Failed to claim webhook delivery", "error", err)
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	if r.DB == nil {
+		return errors.New("database connection is nil")
+	}
+
+	delivery.UpdatedAt = time.Now().UTC()
+	_, err := r.DB.Collection(deliveriesCollection).ReplaceOne(
+		context.TODO(),
+		bson.D{{Key: "id", Value: delivery.Id}},
+		delivery,
+	)
+	if err != nil {
+		slog.Error("Failed to update webhook delivery", "id", delivery.Id, "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package webhooks
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	webhooksCollection   = "webhooks"
+	deliveriesCollection = "webhook_deliveries"
+)
+
+type WebhookRepository struct {
+	DB *mongo.Database
+}
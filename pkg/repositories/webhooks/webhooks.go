@@ -0,0 +1,230 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *WebhookRepository) CreateWebhook(webhook *models.Webhook) (string, error) {
+	slog.Debug("CreateWebhook called", "id", webhook.Id, "url", webhook.URL)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	_, err := r.DB.Collection(webhooksCollection).InsertOne(context.TODO(), webhook)
+	if err != nil {
+		slog.Error("Failed to insert webhook", "id", webhook.Id, "error", err)
+		return "", err
+	}
+
+	slog.Info("Webhook created successfully", "id", webhook.Id)
+	return webhook.Id, nil
+}
+
+func (r *WebhookRepository) GetWebhook(id string) (*models.Webhook, error) {
+	slog.Debug("GetWebhook called", "id", id)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	var webhook models.Webhook
+	err := r.DB.Collection(webhooksCollection).FindOne(
+		context.TODO(),
+		bson.D{{Key: "id", Value: id}},
+	).Decode(&webhook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("webhook not found")
+			slog.Error("Webhook not found", "id", id, "error", err)
+			return nil, err
+		}
+		slog.Error("Failed to find webhook", "id", id, "error", err)
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) ListWebhooks() ([]models.Webhook, error) {
+	slog.Debug("ListWebhooks called")
+	return r.findWebhooks(bson.D{})
+}
+
+// FindSubscribers returns the active webhooks interested in an event. Events
+// without a class, such as collection drops, reach class-filtered webhooks too.
+func (r *WebhookRepository) FindSubscribers(event string, coll string, class string) ([]models.Webhook, error) {
+	slog.Debug("FindSubscribers called", "event", event, "collection", coll, "class", class)
+	filter := bson.D{
+		{Key: "active", Value: true},
+		{Key: "events", Value: event},
+		{Key: "collection", Value: bson.D{{Key: "$in", Value: bson.A{"", coll}}}},
+	}
+	if class != "" {
+		filter = append(filter, bson.E{Key: "class", Value: bson.D{{Key: "$in", Value: bson.A{"", class}}}})
+	}
+	return r.findWebhooks(filter)
+}
+
+func (r *WebhookRepository) findWebhooks(filter bson.D) ([]models.Webhook, error) {
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return nil, err
+	}
+
+	results, err := r.DB.Collection(webhooksCollection).Find(context.TODO(), filter)
+	if err != nil {
+		slog.Error("Failed to find webhooks", "error", err)
+		return nil, err
+	}
+
+	var webhooks []models.Webhook
+	err = results.All(context.TODO(), &webhooks)
+	if err != nil {
+		err := fmt.Errorf("failed to decode results: %s", err.Error())
+		slog.Error("Failed to decode webhooks", "error", err)
+		return nil, err
+	}
+
+	if len(webhooks) == 0 {
+		return []models.Webhook{}, nil
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) UpdateWebhook(id string, update *models.UpdateWebhook) (string, error) {
+	slog.Debug("UpdateWebhook called", "id", id)
+
+	set := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+	raw, err := bson.Marshal(update)
+	if err != nil {
+		slog.Error("Failed to marshal webhook update", "id", id, "error", err)
+		return "", err
+	}
+	var fields bson.D
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		slog.Error("Failed to unmarshal webhook update", "id", id, "error", err)
+		return "", err
+	}
+	set = append(set, fields...)
+
+	changes := bson.D{{Key: "$set", Value: set}}
+	if update.Active != nil && *update.Active {
+		set = append(set, bson.E{Key: "consecutive_failures", Value: 0})
+		changes = bson.D{
+			{Key: "$set", Value: set},
+			{Key: "$unset", Value: bson.D{{Key: "disabled_at", Value: ""}}},
+		}
+	}
+
+	return r.updateWebhook(id, changes)
+}
+
+func (r *WebhookRepository) DeleteWebhook(id string) (string, error) {
+	slog.Debug("DeleteWebhook called", "id", id)
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	result, err := r.DB.Collection(webhooksCollection).DeleteOne(
+		context.TODO(),
+		bson.D{{Key: "id", Value: id}},
+	)
+	if err != nil {
+		slog.Error("Failed to delete webhook", "id", id, "error", err)
+		return "", err
+	}
+	if result.DeletedCount == 0 {
+		err := errors.New("webhook not found")
+		slog.Error("Webhook not found", "id", id, "error", err)
+		return "", err
+	}
+
+	slog.Info("Webhook deleted successfully", "id", id)
+	return id, nil
+}
+
+func (r *WebhookRepository) RecordSuccess(id string) error {
+	_, err := r.updateWebhook(id, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "consecutive_failures", Value: 0},
+	}}})
+	return err
+}
+
+// RecordFailure counts a failed delivery attempt against a webhook and
+// disables it once disableAfter attempts in a row have failed.
+func (r *WebhookRepository) RecordFailure(id string, disableAfter int) (bool, error) {
+	if r.DB == nil {
+		return false, errors.New("database connection is nil")
+	}
+
+	var webhook models.Webhook
+	err := r.DB.Collection(webhooksCollection).FindOneAndUpdate(
+		context.TODO(),
+		bson.D{{Key: "id", Value: id}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "consecutive_failures", Value: 1}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&webhook)
+	if err != nil {
+		slog.Error("Failed to record webhook failure", "id", id, "error", err)
+		return false, err
+	}
+
+	if !webhook.Active || webhook.ConsecutiveFailures < disableAfter {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	_, err = r.updateWebhook(id, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "active", Value: false},
+		{Key: "disabled_at", Value: now},
+		{Key: "updated_at", Value: now},
+	}}})
+	if err != nil {
+		return false, err
+	}
+
+	slog.Warn("Webhook disabled after repeated failures", "id", id, "failures", webhook.ConsecutiveFailures)
+	return true, nil
+}
+
+func (r *WebhookRepository) updateWebhook(id string, changes bson.D) (string, error) {
+	if r.DB == nil {
+		err := errors.New("database connection is nil")
+		slog.Error("Database connection is nil", "error", err)
+		return "", err
+	}
+
+	result, err := r.DB.Collection(webhooksCollection).UpdateOne(
+		context.TODO(),
+		bson.D{{Key: "id", Value: id}},
+		changes,
+	)
+	if err != nil {
+		slog.Error("Failed to update webhook", "id", id, "error", err)
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		err := errors.New("webhook not found")
+		slog.Error("Webhook not found", "id", id, "error", err)
+		return "", err
+	}
+
+	slog.Info("Webhook updated successfully", "id", id)
+	return id, nil
+}
@@ -0,0 +1,118 @@
+package webhooks
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestWebhooks(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	repo := WebhookRepository{
+		DB: client.Database("cms-tests"),
+	}
+	testsCollection := uuid.New().String()
+
+	now := time.Now().UTC()
+	all := &models.Webhook{Id: uuid.New().String(), URL: "https://example.com/all", Secret: "s", Events: models.WebhookEvents, Collection: testsCollection, Active: true, CreatedAt: now, UpdatedAt: now}
+	class := &models.Webhook{Id: uuid.New().String(), URL: "https://example.com/class", Secret: "s", Events: []string{models.EventContentCreated}, Collection: testsCollection, Class: "lessons", Active: true, CreatedAt: now, UpdatedAt: now}
+
+	defer func() {
+		repo.DB.Collection(webhooksCollection).DeleteMany(context.TODO(), bson.D{{Key: "collection", Value: testsCollection}})
+		repo.DB.Collection(deliveriesCollection).DeleteMany(context.TODO(), bson.D{{Key: "webhook_id", Value: bson.D{{Key: "$in", Value: bson.A{all.Id, class.Id}}}}})
+	}()
+
+	t.Run("Create", func(t *testing.T) {
+		_, err := repo.CreateWebhook(all)
+		assert.NoError(t, err)
+		_, err = repo.CreateWebhook(class)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Find Subscribers", func(t *testing.T) {
+		subscribers, err := repo.FindSubscribers(models.EventContentCreated, testsCollection, "lessons")
+		assert.NoError(t, err)
+		assert.Len(t, subscribers, 2)
+
+		subscribers, err = repo.FindSubscribers(models.EventContentCreated, testsCollection, "quizzes")
+		assert.NoError(t, err)
+		assert.Len(t, subscribers, 1)
+
+		subscribers, err = repo.FindSubscribers(models.EventContentDeleted, testsCollection, "lessons")
+		assert.NoError(t, err)
+		assert.Len(t, subscribers, 1)
+	})
+
+	t.Run("Auto Disable", func(t *testing.T) {
+		disabled, err := repo.RecordFailure(class.Id, 2)
+		assert.NoError(t, err)
+		assert.False(t, disabled)
+
+		disabled, err = repo.RecordFailure(class.Id, 2)
+		assert.NoError(t, err)
+		assert.True(t, disabled)
+
+		webhook, err := repo.GetWebhook(class.Id)
+		assert.NoError(t, err)
+		assert.False(t, webhook.Active)
+		assert.NotNil(t, webhook.DisabledAt)
+	})
+
+	t.Run("Re-enable", func(t *testing.T) {
+		active := true
+		_, err := repo.UpdateWebhook(class.Id, &models.UpdateWebhook{Active: &active})
+		assert.NoError(t, err)
+
+		webhook, err := repo.GetWebhook(class.Id)
+		assert.NoError(t, err)
+		assert.True(t, webhook.Active)
+		assert.Equal(t, 0, webhook.ConsecutiveFailures)
+		assert.Nil(t, webhook.DisabledAt)
+	})
+
+	t.Run("Claim Delivery", func(t *testing.T) {
+		due := now.Add(-time.Second)
+		delivery := &models.WebhookDelivery{Id: uuid.New().String(), WebhookId: all.Id, Status: models.DeliveryPending, NextAttemptAt: &due, CreatedAt: now, UpdatedAt: now}
+		_, err := repo.CreateDelivery(delivery)
+		assert.NoError(t, err)
+
+		claimed, err := repo.ClaimDelivery(now, time.Minute)
+		assert.NoError(t, err)
+		assert.NotNil(t, claimed)
+
+		again, err := repo.ClaimDelivery(now, time.Minute)
+		assert.NoError(t, err)
+		if again != nil {
+			assert.NotEqual(t, delivery.Id, again.Id)
+		}
+
+		deliveries, err := repo.ListDeliveries(all.Id, 10)
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		_, err := repo.DeleteWebhook(all.Id)
+		assert.NoError(t, err)
+
+		_, err = repo.GetWebhook(all.Id)
+		assert.EqualError(t, err, "webhook not found")
+	})
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/policy"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps repository errors onto gRPC status codes. Errors that are
+// already statuses are passed through.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var validationErr *repositories.ValidationError
+	switch {
+	case errors.Is(err, repositories.ErrContentNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repositories.ErrContentExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.As(err, &validationErr), errors.Is(err, repositories.ErrInvalidListOptions):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, policy.ErrInvalidName), errors.Is(err, policy.ErrReservedName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, policy.ErrNotRegistered):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, policy.ErrTooManyCollections):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// authorize applies the same collection scopes and collection policy as the
+// Authorize and policy middleware.
+func (s *ContentServer) authorize(ctx context.Context, coll string, op string) error {
+	if coll == "" {
+		return status.Error(codes.InvalidArgument, "collection is required")
+	}
+
+	principal := auth.FromContext(ctx)
+	if !principal.Allows(coll, op) {
+		err := fmt.Errorf("api key is not permitted to %s collection %s", op, coll)
+		slog.Error("Authorization failed", "principal", principal.String(), "error", err)
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err := s.Policy.Check(coll, op); err != nil {
+		slog.Error("Collection policy check failed", "collection", coll, "error", err)
+		return toStatus(err)
+	}
+	return nil
+}
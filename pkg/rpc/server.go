@@ -0,0 +1,400 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	cmsv1 "github.com/YanSystems/cms/pkg/pb/cms/v1"
+	"github.com/YanSystems/cms/pkg/policy"
+	"github.com/YanSystems/cms/pkg/ratelimit"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var sortFields = map[cmsv1.SortField]string{
+	cmsv1.SortField_SORT_FIELD_UNSPECIFIED: "",
+	cmsv1.SortField_SORT_FIELD_CREATED_AT:  "created_at",
+	cmsv1.SortField_SORT_FIELD_UPDATED_AT:  "updated_at",
+	cmsv1.SortField_SORT_FIELD_TITLE:       "title",
+	cmsv1.SortField_SORT_FIELD_VIEWS:       "views",
+	cmsv1.SortField_SORT_FIELD_CLASS:       "class",
+}
+
+type ContentServer struct {
+	cmsv1.UnimplementedContentServiceServer
+
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+	Events   *events.Bus
+	Cache    *cache.Cache
+	Policy   *policy.Policy
+}
+
+// NewServer returns a gRPC server with the content service registered behind
+// the authentication interceptors, and the rate limit interceptors when limits
+// is set.
+func NewServer(content *ContentServer, keys auth.KeyStore, adminToken string, limits *ratelimit.Config, store ratelimit.Store) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{UnaryAuthInterceptor(keys, adminToken)}
+	stream := []grpc.StreamServerInterceptor{StreamAuthInterceptor(keys, adminToken)}
+	if limits != nil {
+		unary = append(unary, UnaryRateLimitInterceptor(*limits, store))
+		stream = append(stream, StreamRateLimitInterceptor(*limits, store))
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	cmsv1.RegisterContentServiceServer(server, content)
+	return server
+}
+
+func (s *ContentServer) repo() *repositories.ContentRepository {
+	return &repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+}
+
+func (s *ContentServer) publish(ctx context.Context, e events.Event) {
+	s.Events.PublishContext(ctx, peerIP(ctx), e)
+}
+
+func (s *ContentServer) CreateContent(ctx context.Context, req *cmsv1.CreateContentRequest) (*cmsv1.Content, error) {
+	slog.Debug("CreateContent RPC called", "collection", req.Collection)
+	if err := s.authorize(ctx, req.Collection, auth.OpWrite); err != nil {
+		return nil, err
+	}
+
+	c := models.Content{
+		Id:          uuid.New().String(),
+		Class:       req.Class,
+		Title:       req.Title,
+		Description: req.Description,
+		Body:        req.Body,
+		IsPublic:    req.IsPublic,
+		Views:       int(req.Views),
+		CreatorId:   req.CreatorId,
+		UpdatedAt:   time.Now().UTC(),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if req.Fields != nil {
+		c.Fields = req.Fields.AsMap()
+	}
+	if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing fields in request payload")
+	}
+
+	id, err := s.repo().CreateContent(req.Collection, &c)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	slog.Info("Content created successfully", "id", id)
+
+	s.publish(ctx, events.Event{
+		Type:       models.EventContentCreated,
+		Collection: req.Collection,
+		ContentId:  id,
+		Class:      c.Class,
+		After:      &c,
+	})
+	return toProto(&c), nil
+}
+
+func (s *ContentServer) GetContent(ctx context.Context, req *cmsv1.GetContentRequest) (*cmsv1.Content, error) {
+	slog.Debug("GetContent RPC called", "collection", req.Collection, "id", req.Id)
+	if err := s.authorize(ctx, req.Collection, auth.OpRead); err != nil {
+		return nil, err
+	}
+
+	content, err := s.repo().GetContent(req.Collection, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto((*models.Content)(content)), nil
+}
+
+func (s *ContentServer) UpdateContent(ctx context.Context, req *cmsv1.UpdateContentRequest) (*cmsv1.Content, error) {
+	slog.Debug("UpdateContent RPC called", "collection", req.Collection, "id", req.Id)
+	if err := s.authorize(ctx, req.Collection, auth.OpWrite); err != nil {
+		return nil, err
+	}
+
+	update := models.UpdateContent{
+		Class:       req.Class,
+		Title:       req.Title,
+		Description: req.Description,
+		Body:        req.Body,
+		IsPublic:    req.IsPublic,
+		CreatorId:   req.CreatorId,
+	}
+	if req.Fields != nil {
+		update.Fields = req.Fields.AsMap()
+	}
+	if req.Views != nil {
+		if *req.Views < 0 {
+			return nil, status.Error(codes.InvalidArgument, "views must not be negative")
+		}
+		views := int(*req.Views)
+		update.Views = &views
+	}
+	if req.CreatorId != nil {
+		if _, err := uuid.Parse(*req.CreatorId); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "creator_id is not a valid uuid")
+		}
+	}
+
+	repo := s.repo()
+	before, err := repo.GetContent(req.Collection, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if _, err := repo.UpdateContent(req.Collection, req.Id, &update); err != nil {
+		return nil, toStatus(err)
+	}
+	after, err := repo.GetContent(req.Collection, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	slog.Info("Content updated successfully", "id", req.Id)
+
+	s.publish(ctx, events.Event{
+		Type:       models.EventContentUpdated,
+		Collection: req.Collection,
+		ContentId:  req.Id,
+		Class:      after.Class,
+		Before:     (*models.Content)(before),
+		After:      (*models.Content)(after),
+	})
+	return toProto((*models.Content)(after)), nil
+}
+
+func (s *ContentServer) DeleteContent(ctx context.Context, req *cmsv1.DeleteContentRequest) (*cmsv1.DeleteContentResponse, error) {
+	slog.Debug("DeleteContent RPC called", "collection", req.Collection, "id", req.Id)
+	if err := s.authorize(ctx, req.Collection, auth.OpDelete); err != nil {
+		return nil, err
+	}
+
+	repo := s.repo()
+	before, err := repo.RemoveContent(req.Collection, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if before == nil {
+		return nil, toStatus(repositories.ErrContentNotFound)
+	}
+	slog.Info("Content deleted successfully", "id", req.Id)
+
+	s.publish(ctx, events.Event{
+		Type:       models.EventContentDeleted,
+		Collection: req.Collection,
+		ContentId:  req.Id,
+		Class:      before.Class,
+		Before:     before,
+	})
+	return &cmsv1.DeleteContentResponse{Id: req.Id}, nil
+}
+
+func (s *ContentServer) ListContents(ctx context.Context, req *cmsv1.ListContentsRequest) (*cmsv1.ListContentsResponse, error) {
+	slog.Debug("ListContents RPC called", "collection", req.Collection)
+	if err := s.authorize(ctx, req.Collection, auth.OpRead); err != nil {
+		return nil, err
+	}
+
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize < 0 || pageSize > maxPageSize || req.Offset < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d and offset must not be negative", maxPageSize)
+	}
+	sortBy, ok := sortFields[req.SortBy]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown sort field %s", req.SortBy)
+	}
+	if req.SortField != "" {
+		if sortBy != "" {
+			return nil, status.Error(codes.InvalidArgument, "sort_by and sort_field cannot both be set")
+		}
+		sortBy = "fields." + req.SortField
+	}
+
+	opts := repositories.ListOptions{
+		SortBy:     sortBy,
+		Descending: req.Descending,
+		Limit:      int64(pageSize),
+		Offset:     int64(req.Offset),
+	}
+	if f := req.Filter; f != nil {
+		opts.Class = f.Class
+		opts.IsPublic = f.IsPublic
+		opts.CreatorId = f.CreatorId
+		opts.TitleContains = f.TitleContains
+		if len(f.Fields) > 0 {
+			opts.Fields = make(map[string]any, len(f.Fields))
+			for name, value := range f.Fields {
+				opts.Fields[name] = value.AsInterface()
+			}
+		}
+	}
+
+	contents, total, err := s.repo().ListContent(req.Collection, opts)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &cmsv1.ListContentsResponse{
+		Contents:   make([]*cmsv1.Content, 0, len(contents)),
+		TotalCount: total,
+		HasMore:    int64(req.Offset)+int64(len(contents)) < total,
+	}
+	for i := range contents {
+		res.Contents = append(res.Contents, toProto(&contents[i]))
+	}
+	return res, nil
+}
+
+func (s *ContentServer) StreamContents(req *cmsv1.StreamContentsRequest, stream cmsv1.ContentService_StreamContentsServer) error {
+	slog.Debug("StreamContents RPC called", "collection", req.Collection, "class", req.Class)
+	ctx := stream.Context()
+	if err := s.authorize(ctx, req.Collection, auth.OpRead); err != nil {
+		return err
+	}
+
+	err := s.repo().EachContent(ctx, req.Collection, req.Class, func(c *models.Content) error {
+		return stream.Send(toProto(c))
+	})
+	return toStatus(err)
+}
+
+func (s *ContentServer) ListClasses(ctx context.Context, req *cmsv1.ListClassesRequest) (*cmsv1.ListClassesResponse, error) {
+	slog.Debug("ListClasses RPC called", "collection", req.Collection)
+	if err := s.authorize(ctx, req.Collection, auth.OpRead); err != nil {
+		return nil, err
+	}
+
+	classes, err := s.repo().ListClasses(req.Collection)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &cmsv1.ListClassesResponse{Classes: classes}, nil
+}
+
+func (s *ContentServer) DeleteClass(ctx context.Context, req *cmsv1.DeleteClassRequest) (*cmsv1.DeleteClassResponse, error) {
+	slog.Debug("DeleteClass RPC called", "collection", req.Collection, "class", req.Class)
+	if err := s.authorize(ctx, req.Collection, auth.OpDelete); err != nil {
+		return nil, err
+	}
+	if req.Class == "" {
+		return nil, status.Error(codes.InvalidArgument, "class is required")
+	}
+
+	ids, err := s.repo().DeleteClass(req.Collection, req.Class)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	slog.Info("Class deleted successfully", "class", req.Class)
+
+	s.publish(ctx, events.Event{
+		Type:       models.EventClassDeleted,
+		Collection: req.Collection,
+		Class:      req.Class,
+		Ids:        ids,
+	})
+	return &cmsv1.DeleteClassResponse{Ids: ids}, nil
+}
+
+// ListCollections lists only the collections the caller may read.
+func (s *ContentServer) ListCollections(ctx context.Context, req *cmsv1.ListCollectionsRequest) (*cmsv1.ListCollectionsResponse, error) {
+	slog.Debug("ListCollections RPC called")
+
+	names, err := s.repo().ListCollections()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	principal := auth.FromContext(ctx)
+	res := &cmsv1.ListCollectionsResponse{Collections: []string{}}
+	for _, name := range names {
+		if principal.Allows(name, auth.OpRead) {
+			res.Collections = append(res.Collections, name)
+		}
+	}
+	return res, nil
+}
+
+func (s *ContentServer) DeleteCollection(ctx context.Context, req *cmsv1.DeleteCollectionRequest) (*cmsv1.DeleteCollectionResponse, error) {
+	slog.Debug("DeleteCollection RPC called", "collection", req.Collection)
+	if err := s.authorize(ctx, req.Collection, auth.OpDelete); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.repo().DeleteCollection(req.Collection)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	slog.Info("Collection deleted successfully", "collection", req.Collection)
+
+	s.publish(ctx, events.Event{
+		Type:       models.EventCollectionDeleted,
+		Collection: req.Collection,
+		Ids:        ids,
+	})
+	return &cmsv1.DeleteCollectionResponse{Ids: ids}, nil
+}
+
+func toProto(c *models.Content) *cmsv1.Content {
+	return &cmsv1.Content{
+		Id:            c.Id,
+		Class:         c.Class,
+		Title:         c.Title,
+		Description:   c.Description,
+		Body:          c.Body,
+		IsPublic:      c.IsPublic,
+		Views:         int64(c.Views),
+		CreatorId:     c.CreatorId,
+		CreatedAt:     timestamppb.New(c.CreatedAt),
+		UpdatedAt:     timestamppb.New(c.UpdatedAt),
+		Fields:        toStruct(c.Fields),
+		SchemaVersion: int32(c.SchemaVersion),
+	}
+}
+
+// toStruct converts custom fields through JSON, since values decoded from
+// the database use types that structpb does not take, such as int32. It
+// returns nil for items without custom fields.
+func toStruct(fields map[string]any) *structpb.Struct {
+	if len(fields) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		slog.Error("Failed to encode custom fields", "error", err)
+		return nil
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		slog.Error("Failed to decode custom fields", "error", err)
+		return nil
+	}
+	s, err := structpb.NewStruct(decoded)
+	if err != nil {
+		slog.Error("Failed to convert custom fields", "error", err)
+		return nil
+	}
+	return s
+}
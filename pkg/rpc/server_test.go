@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/models"
+	cmsv1 "github.com/YanSystems/cms/pkg/pb/cms/v1"
+	"github.com/YanSystems/cms/pkg/ratelimit"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeKeyStore struct {
+	keys map[string]*models.APIKey
+}
+
+func (s *fakeKeyStore) GetKeyByHash(hash string) (*models.APIKey, error) {
+	if key, ok := s.keys[hash]; ok {
+		return key, nil
+	}
+	return nil, errors.New("api key not found")
+}
+
+func (s *fakeKeyStore) TouchKey(id string, at time.Time) error {
+	return nil
+}
+
+func newTestClient(t *testing.T, keys auth.KeyStore) cmsv1.ContentServiceClient {
+	return newLimitedTestClient(t, keys, nil, nil)
+}
+
+func newLimitedTestClient(t *testing.T, keys auth.KeyStore, limits *ratelimit.Config, store ratelimit.Store) cmsv1.ContentServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(&ContentServer{}, keys, "admin-token", limits, store)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return cmsv1.NewContentServiceClient(conn)
+}
+
+func TestAuthentication(t *testing.T) {
+	readerKey := "yan_reader"
+	keys := &fakeKeyStore{keys: map[string]*models.APIKey{
+		auth.HashKey(readerKey): {
+			Id:          "reader",
+			Collections: []string{"courses"},
+			Operations:  []string{auth.OpRead},
+		},
+	}}
+	client := newTestClient(t, keys)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+key)
+	}
+
+	t.Run("Unknown API Key", func(t *testing.T) {
+		_, err := client.GetContent(withKey("yan_unknown"), &cmsv1.GetContentRequest{Collection: "courses", Id: "a"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Operation Outside Key Scope", func(t *testing.T) {
+		_, err := client.DeleteCollection(withKey(readerKey), &cmsv1.DeleteCollectionRequest{Collection: "courses"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, "api key is not permitted to delete collection courses", status.Convert(err).Message())
+	})
+
+	t.Run("Collection Outside Key Scope On Stream", func(t *testing.T) {
+		stream, err := client.StreamContents(withKey(readerKey), &cmsv1.StreamContentsRequest{Collection: "secrets"})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Missing Collection", func(t *testing.T) {
+		_, err := client.ListClasses(withKey("admin-token"), &cmsv1.ListClassesRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Missing Fields", func(t *testing.T) {
+		_, err := client.CreateContent(context.Background(), &cmsv1.CreateContentRequest{Collection: "courses", Title: "Intro"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "missing fields in request payload", status.Convert(err).Message())
+	})
+
+	t.Run("Sort By Both Fields", func(t *testing.T) {
+		_, err := client.ListContents(withKey(readerKey), &cmsv1.ListContentsRequest{
+			Collection: "courses",
+			SortBy:     cmsv1.SortField_SORT_FIELD_TITLE,
+			SortField:  "duration",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limits := &ratelimit.Config{Read: ratelimit.Limit{Requests: 2, Per: time.Minute}}
+	client := newLimitedTestClient(t, &fakeKeyStore{}, limits, store)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer admin-token")
+
+	// A read through the HTTP API takes from the same bucket
+	store.Take(auth.OpRead+":"+auth.KindAdmin, limits.Read, time.Now())
+
+	_, err := client.ListClasses(ctx, &cmsv1.ListClassesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	var header metadata.MD
+	_, err = client.ListClasses(ctx, &cmsv1.ListClassesRequest{}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"30"}, header.Get("retry-after"))
+
+	stream, err := client.StreamContents(ctx, &cmsv1.StreamContentsRequest{Collection: "courses"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Writes have a limit of their own, here none
+	_, err = client.CreateContent(ctx, &cmsv1.CreateContentRequest{Collection: "courses", Title: "Intro"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestToStatus(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{repositories.ErrContentNotFound, codes.NotFound},
+		{repositories.ErrContentExists, codes.AlreadyExists},
+		{&repositories.ValidationError{Field: "Id", Err: errors.New("bad uuid")}, codes.InvalidArgument},
+		{fmt.Errorf("%w: cannot sort by %q", repositories.ErrInvalidListOptions, "fields.$a"), codes.InvalidArgument},
+		{context.Canceled, codes.Canceled},
+		{status.Error(codes.PermissionDenied, "denied"), codes.PermissionDenied},
+		{errors.New("connection reset"), codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.code, status.Code(toStatus(tc.err)), tc.err.Error())
+	}
+	assert.NoError(t, toStatus(nil))
+}
+
+func TestToStruct(t *testing.T) {
+	s := toStruct(map[string]any{
+		"duration": int32(45),
+		"tags":     primitive.A{"go", "intro"},
+		"meta":     map[string]interface{}{"level": int64(2)},
+	})
+	assert.Equal(t, map[string]any{
+		"duration": float64(45),
+		"tags":     []any{"go", "intro"},
+		"meta":     map[string]any{"level": float64(2)},
+	}, s.AsMap())
+	assert.Nil(t, toStruct(nil))
+}
@@ -0,0 +1,276 @@
+// Package schema validates the custom fields of content against JSON Schema
+// documents.
+//
+// It supports the subset of JSON Schema (draft 2020-12) that describes plain
+// data: types, enums and constants, string lengths, patterns and formats,
+// number ranges, arrays, objects and the allOf, anyOf, oneOf and not
+// combinators. Documents that use anything else, such as $ref, are rejected
+// when they are compiled rather than half enforced.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSchema is returned for schema documents that are malformed or use
+// keywords this package does not enforce.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+var types = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// annotations are keywords that describe a schema without constraining the
+// values it accepts.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// Schema is a compiled schema document.
+type Schema struct {
+	types    []string
+	enum     []any
+	constant any
+	hasConst bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	items       *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	properties    map[string]*Schema
+	required      []string
+	additional    *Schema
+	noAdditional  bool
+	minProperties *int
+	maxProperties *int
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+}
+
+// Compile checks a schema document for the custom fields of content, whose
+// root has to describe an object, and prepares it for validation.
+func Compile(doc map[string]any) (*Schema, error) {
+	s, err := compile(normalize(doc), "")
+	if err != nil {
+		return nil, err
+	}
+	if len(s.types) > 0 && !slices.Contains(s.types, "object") {
+		return nil, fmt.Errorf("%w: the root must be of type object", ErrInvalidSchema)
+	}
+	return s, nil
+}
+
+func compile(v any, at string) (*Schema, error) {
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, invalid(at, "a schema must be an object")
+	}
+
+	s := &Schema{}
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := doc[key]
+		path := at + "/" + key
+		var err error
+		switch key {
+		case "type":
+			s.types, err = compileTypes(value, path)
+		case "enum":
+			list, ok := value.([]any)
+			if !ok || len(list) == 0 {
+				err = invalid(path, "must be a non-empty array")
+			}
+			s.enum = list
+		case "const":
+			s.constant, s.hasConst = value, true
+		case "minLength":
+			s.minLength, err = compileCount(value, path)
+		case "maxLength":
+			s.maxLength, err = compileCount(value, path)
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				err = invalid(path, "must be a string")
+				break
+			}
+			if s.pattern, err = regexp.Compile(pattern); err != nil {
+				err = invalid(path, err.Error())
+			}
+		case "format":
+			format, ok := value.(string)
+			if !ok || formats[format] == nil {
+				err = invalid(path, "must be one of "+strings.Join(formatNames(), ", "))
+			}
+			s.format = format
+		case "minimum":
+			s.minimum, err = compileNumber(value, path)
+		case "maximum":
+			s.maximum, err = compileNumber(value, path)
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = compileNumber(value, path)
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = compileNumber(value, path)
+		case "multipleOf":
+			if s.multipleOf, err = compileNumber(value, path); err == nil && *s.multipleOf <= 0 {
+				err = invalid(path, "must be greater than 0")
+			}
+		case "items":
+			s.items, err = compile(value, path)
+		case "minItems":
+			s.minItems, err = compileCount(value, path)
+		case "maxItems":
+			s.maxItems, err = compileCount(value, path)
+		case "uniqueItems":
+			if s.uniqueItems, ok = value.(bool); !ok {
+				err = invalid(path, "must be a boolean")
+			}
+		case "properties":
+			s.properties, err = compileProperties(value, path)
+		case "required":
+			s.required, err = compileNames(value, path)
+		case "additionalProperties":
+			if allowed, ok := value.(bool); ok {
+				s.noAdditional = !allowed
+				break
+			}
+			s.additional, err = compile(value, path)
+		case "minProperties":
+			s.minProperties, err = compileCount(value, path)
+		case "maxProperties":
+			s.maxProperties, err = compileCount(value, path)
+		case "allOf":
+			s.allOf, err = compileList(value, path)
+		case "anyOf":
+			s.anyOf, err = compileList(value, path)
+		case "oneOf":
+			s.oneOf, err = compileList(value, path)
+		case "not":
+			s.not, err = compile(value, path)
+		default:
+			if !annotations[key] {
+				err = invalid(path, "unsupported keyword")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func compileTypes(v any, at string) ([]string, error) {
+	if name, ok := v.(string); ok {
+		v = []any{name}
+	}
+	list, ok := v.([]any)
+	if !ok || len(list) == 0 {
+		return nil, invalid(at, "must be a type name or an array of them")
+	}
+	names := make([]string, len(list))
+	for i, item := range list {
+		name, ok := item.(string)
+		if !ok || !types[name] {
+			return nil, invalid(at, fmt.Sprintf("unknown type %v", item))
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+func compileCount(v any, at string) (*int, error) {
+	n, ok := v.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return nil, invalid(at, "must be a non-negative integer")
+	}
+	count := int(n)
+	return &count, nil
+}
+
+func compileNumber(v any, at string) (*float64, error) {
+	n, ok := v.(float64)
+	if !ok {
+		return nil, invalid(at, "must be a number")
+	}
+	return &n, nil
+}
+
+func compileProperties(v any, at string) (map[string]*Schema, error) {
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, invalid(at, "must be an object")
+	}
+	properties := make(map[string]*Schema, len(doc))
+	for name, value := range doc {
+		property, err := compile(value, at+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+		properties[name] = property
+	}
+	return properties, nil
+}
+
+func compileNames(v any, at string) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, invalid(at, "must be an array of names")
+	}
+	names := make([]string, len(list))
+	for i, item := range list {
+		if names[i], ok = item.(string); !ok {
+			return nil, invalid(at, "must be an array of names")
+		}
+	}
+	return names, nil
+}
+
+func compileList(v any, at string) ([]*Schema, error) {
+	list, ok := v.([]any)
+	if !ok || len(list) == 0 {
+		return nil, invalid(at, "must be a non-empty array of schemas")
+	}
+	schemas := make([]*Schema, len(list))
+	for i, item := range list {
+		s, err := compile(item, fmt.Sprintf("%s/%d", at, i))
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = s
+	}
+	return schemas, nil
+}
+
+func invalid(at string, message string) error {
+	if at == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidSchema, message)
+	}
+	return fmt.Errorf("%w: %s %s", ErrInvalidSchema, at, message)
+}
@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decode(t *testing.T, doc string) map[string]any {
+	t.Helper()
+	var v map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(doc), &v))
+	return v
+}
+
+const lessonSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "Lesson",
+	"type": "object",
+	"required": ["difficulty", "duration"],
+	"additionalProperties": false,
+	"properties": {
+		"difficulty": {"enum": ["easy", "medium", "hard"]},
+		"duration": {"type": "integer", "minimum": 1, "maximum": 600},
+		"language": {"type": "string", "pattern": "^[a-z]{2}$"},
+		"published": {"type": "string", "format": "date"},
+		"rating": {"type": "number", "multipleOf": 0.5, "exclusiveMaximum": 5.5},
+		"prerequisites": {
+			"type": "array",
+			"maxItems": 3,
+			"uniqueItems": true,
+			"items": {"type": "string", "format": "uuid"}
+		},
+		"meta": {
+			"type": "object",
+			"additionalProperties": {"type": ["string", "null"], "maxLength": 5}
+		}
+	}
+}`
+
+func TestCompile(t *testing.T) {
+	_, err := Compile(decode(t, lessonSchema))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"Unsupported Keyword", `{"properties": {"next": {"$ref": "#"}}}`},
+		{"Unknown Type", `{"properties": {"duration": {"type": "int"}}}`},
+		{"Unknown Format", `{"properties": {"url": {"format": "hostname"}}}`},
+		{"Invalid Pattern", `{"properties": {"code": {"pattern": "("}}}`},
+		{"Negative Count", `{"properties": {"code": {"minLength": -1}}}`},
+		{"Empty Enum", `{"properties": {"code": {"enum": []}}}`},
+		{"Zero Multiple", `{"properties": {"rating": {"multipleOf": 0}}}`},
+		{"Root Not Object", `{"type": "string"}`},
+		{"Schema Not Object", `{"items": true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(decode(t, tt.doc))
+			assert.ErrorIs(t, err, ErrInvalidSchema)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Compile(decode(t, lessonSchema))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		fields     string
+		violations []Violation
+	}{
+		{"Valid", `{"difficulty": "easy", "duration": 45, "language": "en", "published": "2024-06-01", "rating": 4.5,
+			"prerequisites": ["0b6f4a5e-5f5b-4c3f-9d5e-3f1b2a4c6d7e"], "meta": {"level": "a1", "note": null}}`, nil},
+		{"Missing Required", `{"difficulty": "easy"}`, []Violation{{"duration", "is required"}}},
+		{"Enum", `{"difficulty": "expert", "duration": 45}`, []Violation{{"difficulty", `must be one of "easy", "medium", "hard"`}}},
+		{"Integer", `{"difficulty": "easy", "duration": 4.5}`, []Violation{{"duration", "must be of type integer"}}},
+		{"Maximum", `{"difficulty": "easy", "duration": 601}`, []Violation{{"duration", "must be at most 600"}}},
+		{"Pattern", `{"difficulty": "easy", "duration": 45, "language": "eng"}`, []Violation{{"language", "must match ^[a-z]{2}$"}}},
+		{"Format", `{"difficulty": "easy", "duration": 45, "published": "June"}`, []Violation{{"published", "must be a valid date"}}},
+		{"Multiple Of", `{"difficulty": "easy", "duration": 45, "rating": 4.2}`, []Violation{{"rating", "must be a multiple of 0.5"}}},
+		{"Exclusive Maximum", `{"difficulty": "easy", "duration": 45, "rating": 5.5}`, []Violation{{"rating", "must be less than 5.5"}}},
+		{"Items", `{"difficulty": "easy", "duration": 45, "prerequisites": ["a", "a"]}`, []Violation{
+			{"prerequisites", "must not have duplicate items"},
+			{"prerequisites[0]", "must be a valid uuid"},
+			{"prerequisites[1]", "must be a valid uuid"},
+		}},
+		{"Additional Properties", `{"difficulty": "easy", "duration": 45, "level": 1}`, []Violation{{"level", "is not allowed"}}},
+		{"Additional Properties Schema", `{"difficulty": "easy", "duration": 45, "meta": {"level": "advanced"}}`, []Violation{{"meta.level", "must be at most 5 characters long"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.violations, s.Validate(decode(t, tt.fields)))
+		})
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	s, err := Compile(decode(t, `{
+		"properties": {
+			"duration": {"anyOf": [{"type": "integer"}, {"type": "string", "pattern": "^[0-9]+m$"}]},
+			"level": {"oneOf": [{"type": "integer"}, {"type": "number", "minimum": 1}]},
+			"code": {"allOf": [{"minLength": 2}, {"maxLength": 3}], "not": {"const": "xx"}}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	assert.Empty(t, s.Validate(map[string]any{"duration": "45m", "level": 1.5, "code": "abc"}))
+	assert.Equal(t, []Violation{
+		{"code", "must be at least 2 characters long"},
+		{"duration", "must match at least one of the allowed schemas"},
+		{"level", "must match exactly one of the allowed schemas"},
+	}, s.Validate(map[string]any{"duration": "45", "level": 2, "code": "x"}))
+	assert.Equal(t, []Violation{{"code", "must not match the excluded schema"}}, s.Validate(map[string]any{"code": "xx"}))
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	s, err := Compile(decode(t, lessonSchema))
+	assert.NoError(t, err)
+
+	type document map[string]interface{}
+	type array []interface{}
+	fields := map[string]any{
+		"difficulty":    "easy",
+		"duration":      int32(45),
+		"prerequisites": array{"0b6f4a5e-5f5b-4c3f-9d5e-3f1b2a4c6d7e"},
+		"meta":          document{"level": "a1"},
+	}
+	assert.Empty(t, s.Validate(fields))
+
+	var none map[string]any
+	assert.Equal(t, []Violation{{"difficulty", "is required"}, {"duration", "is required"}}, s.Validate(none))
+}
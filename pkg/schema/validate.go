@@ -0,0 +1,367 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is one way in which a value does not match a schema. Path names
+// the field, such as "duration" or "prerequisites[0]", and is empty for the
+// value as a whole.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) Error() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + " " + v.Message
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// formats are the values of the format keyword that are checked.
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	},
+	"uuid": uuidPattern.MatchString,
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate returns every way in which value does not match the schema, or
+// nothing if it does. Value may hold maps, slices and numbers of any Go type,
+// as decoded from JSON, YAML or BSON.
+func (s *Schema) Validate(value any) []Violation {
+	return s.validate(normalize(value), "")
+}
+
+func (s *Schema) validate(v any, path string) []Violation {
+	var violations []Violation
+	fail := func(format string, args ...any) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.types) > 0 && !s.hasType(v) {
+		fail("must be of type %s", strings.Join(s.types, " or "))
+		return violations
+	}
+	if s.enum != nil && !s.inEnum(v) {
+		fail("must be one of %s", list(s.enum))
+	}
+	if s.hasConst && !reflect.DeepEqual(v, s.constant) {
+		fail("must be %s", encode(s.constant))
+	}
+
+	switch v := v.(type) {
+	case string:
+		violations = append(violations, s.validateString(v, path)...)
+	case float64:
+		violations = append(violations, s.validateNumber(v, path)...)
+	case []any:
+		violations = append(violations, s.validateArray(v, path)...)
+	case map[string]any:
+		violations = append(violations, s.validateObject(v, path)...)
+	}
+
+	for _, sub := range s.allOf {
+		violations = append(violations, sub.validate(v, path)...)
+	}
+	if s.anyOf != nil && s.matching(s.anyOf, v) == 0 {
+		fail("must match at least one of the allowed schemas")
+	}
+	if s.oneOf != nil && s.matching(s.oneOf, v) != 1 {
+		fail("must match exactly one of the allowed schemas")
+	}
+	if s.not != nil && len(s.not.validate(v, path)) == 0 {
+		fail("must not match the excluded schema")
+	}
+	return violations
+}
+
+func (s *Schema) validateString(v string, path string) []Violation {
+	var violations []Violation
+	fail := func(format string, args ...any) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(v)
+	if s.minLength != nil && length < *s.minLength {
+		fail("must be at least %d characters long", *s.minLength)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		fail("must be at most %d characters long", *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(v) {
+		fail("must match %s", s.pattern.String())
+	}
+	if s.format != "" && !formats[s.format](v) {
+		fail("must be a valid %s", s.format)
+	}
+	return violations
+}
+
+func (s *Schema) validateNumber(v float64, path string) []Violation {
+	var violations []Violation
+	fail := func(format string, args ...any) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.minimum != nil && v < *s.minimum {
+		fail("must be at least %v", *s.minimum)
+	}
+	if s.maximum != nil && v > *s.maximum {
+		fail("must be at most %v", *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && v <= *s.exclusiveMinimum {
+		fail("must be greater than %v", *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && v >= *s.exclusiveMaximum {
+		fail("must be less than %v", *s.exclusiveMaximum)
+	}
+	if s.multipleOf != nil && !isMultiple(v, *s.multipleOf) {
+		fail("must be a multiple of %v", *s.multipleOf)
+	}
+	return violations
+}
+
+func (s *Schema) validateArray(v []any, path string) []Violation {
+	var violations []Violation
+	fail := func(format string, args ...any) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.minItems != nil && len(v) < *s.minItems {
+		fail("must have at least %d items", *s.minItems)
+	}
+	if s.maxItems != nil && len(v) > *s.maxItems {
+		fail("must have at most %d items", *s.maxItems)
+	}
+	if s.uniqueItems {
+	unique:
+		for i := range v {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(v[i], v[j]) {
+					fail("must not have duplicate items")
+					break unique
+				}
+			}
+		}
+	}
+	if s.items != nil {
+		for i, item := range v {
+			violations = append(violations, s.items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return violations
+}
+
+func (s *Schema) validateObject(v map[string]any, path string) []Violation {
+	var violations []Violation
+	fail := func(format string, args ...any) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.minProperties != nil && len(v) < *s.minProperties {
+		fail("must have at least %d fields", *s.minProperties)
+	}
+	if s.maxProperties != nil && len(v) > *s.maxProperties {
+		fail("must have at most %d fields", *s.maxProperties)
+	}
+	for _, name := range s.required {
+		if _, ok := v[name]; !ok {
+			violations = append(violations, Violation{Path: join(path, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := s.properties[name]
+		switch {
+		case ok:
+			violations = append(violations, property.validate(v[name], join(path, name))...)
+		case s.noAdditional:
+			violations = append(violations, Violation{Path: join(path, name), Message: "is not allowed"})
+		case s.additional != nil:
+			violations = append(violations, s.additional.validate(v[name], join(path, name))...)
+		}
+	}
+	return violations
+}
+
+func (s *Schema) hasType(v any) bool {
+	for _, name := range s.types {
+		switch name {
+		case "null":
+			if v == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "object":
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(float64); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := v.(float64); ok && isInteger(n) {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Schema) inEnum(v any) bool {
+	for _, allowed := range s.enum {
+		if reflect.DeepEqual(v, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// matching counts the schemas v matches.
+func (s *Schema) matching(schemas []*Schema, v any) int {
+	count := 0
+	for _, sub := range schemas {
+		if len(sub.validate(v, "")) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// normalize turns maps, slices and numbers of any Go type into the types JSON
+// decodes to, so that values can be compared however they were decoded.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, bool, string, float64:
+		return v
+	case json.Number:
+		n, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return n
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = normalize(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		list := make([]any, rv.Len())
+		for i := range list {
+			list[i] = normalize(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	}
+	return v
+}
+
+func isInteger(n float64) bool {
+	return !math.IsInf(n, 0) && n == math.Trunc(n)
+}
+
+// isMultiple allows for the rounding of decimal divisors, such as 0.3 being
+// a multiple of 0.1.
+func isMultiple(n float64, divisor float64) bool {
+	quotient := n / divisor
+	return !math.IsInf(quotient, 0) && math.Abs(quotient-math.Round(quotient)) < 1e-9
+}
+
+func join(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func list(values []any) string {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		encoded[i] = encode(value)
+	}
+	return strings.Join(encoded, ", ")
+}
+
+func encode(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/docs"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type openAPIDocument struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+type openAPIOperation struct {
+	Parameters []map[string]any `json:"parameters"`
+	Responses  map[string]any   `json:"responses"`
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// TestOpenAPIMatchesRouter fails when a route is added to or removed from
+// NewRouter without updating pkg/docs/openapi.json, or the other way round.
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	api := Server{Port: "8000"}
+	router := api.NewRouter().(chi.Routes)
+
+	var routed []string
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routed = append(routed, method+" "+strings.TrimSuffix(route, "/"))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	var doc openAPIDocument
+	assert.NoError(t, json.Unmarshal(docs.Spec, &doc))
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(routed)
+	sort.Strings(documented)
+	assert.Equal(t, routed, documented)
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	var spec map[string]any
+	assert.NoError(t, json.Unmarshal(docs.Spec, &spec))
+
+	t.Run("References Resolve", func(t *testing.T) {
+		var check func(node any)
+		check = func(node any) {
+			switch n := node.(type) {
+			case map[string]any:
+				if ref, ok := n["$ref"].(string); ok {
+					assert.NotNil(t, lookup(spec, ref), "unresolved reference %s", ref)
+				}
+				for _, child := range n {
+					check(child)
+				}
+			case []any:
+				for _, child := range n {
+					check(child)
+				}
+			}
+		}
+		check(spec)
+	})
+
+	var doc openAPIDocument
+	assert.NoError(t, json.Unmarshal(docs.Spec, &doc))
+
+	for path, item := range doc.Paths {
+		var shared []map[string]any
+		if raw, ok := item["parameters"]; ok {
+			assert.NoError(t, json.Unmarshal(raw, &shared))
+		}
+
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+			var op openAPIOperation
+			assert.NoError(t, json.Unmarshal(raw, &op))
+
+			t.Run(strings.ToUpper(method)+" "+path, func(t *testing.T) {
+				hasSuccess := false
+				for status := range op.Responses {
+					hasSuccess = hasSuccess || strings.HasPrefix(status, "2")
+				}
+				assert.True(t, hasSuccess, "no success response")
+
+				var declared []string
+				for _, param := range append(shared, op.Parameters...) {
+					if ref, ok := param["$ref"].(string); ok {
+						param, _ = lookup(spec, ref).(map[string]any)
+					}
+					if param["in"] == "path" {
+						declared = append(declared, param["name"].(string))
+					}
+				}
+				var expected []string
+				for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+					expected = append(expected, match[1])
+				}
+				sort.Strings(declared)
+				sort.Strings(expected)
+				assert.Equal(t, expected, declared, "path parameters")
+			})
+		}
+	}
+}
+
+func lookup(spec map[string]any, ref string) any {
+	var node any = spec
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = m[key]
+	}
+	return node
+}
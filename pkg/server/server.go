@@ -2,37 +2,116 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/backup"
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/docs"
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/graphql"
+	"github.com/YanSystems/cms/pkg/idempotency"
+	"github.com/YanSystems/cms/pkg/policy"
+	"github.com/YanSystems/cms/pkg/ratelimit"
+	"github.com/YanSystems/cms/pkg/repositories/apikeys"
+	"github.com/YanSystems/cms/pkg/repositories/audit"
+	"github.com/YanSystems/cms/pkg/repositories/collections"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/repositories/schemas"
+	"github.com/YanSystems/cms/pkg/repositories/webhooks"
+	"github.com/YanSystems/cms/pkg/rpc"
 	"github.com/YanSystems/cms/pkg/services"
+	"github.com/YanSystems/cms/pkg/sitemap"
 	utils "github.com/YanSystems/cms/pkg/utils"
+	dispatch "github.com/YanSystems/cms/pkg/webhooks"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+)
+
+const (
+	streamBufferSize = 1000
+	// defaultCacheMaxAge is how long CDNs may serve public content before
+	// revalidating it, unless YAN_CMS_CACHE_MAX_AGE says otherwise.
+	defaultCacheMaxAge = time.Minute
 )
 
 type Server struct {
-	Port string
-	DB   *mongo.Database
+	Port       string
+	GRPCPort   string
+	DB         *mongo.Database
+	SystemDB   *mongo.Database
+	AdminToken string
+	TrustProxy bool
+	// CacheMaxAge is the max-age sent with public content.
+	CacheMaxAge time.Duration
+	// BaseURL is the public URL of the service, used for links in feeds and
+	// sitemaps.
+	BaseURL   string
+	RateLimit *ratelimit.Config
+	// Idempotency configures Idempotency-Key handling on creates, bulk
+	// writes and imports.
+	Idempotency *idempotency.Config
+	// Collections configures which collections may be created. Without it,
+	// any name is.
+	Collections *policy.Config
+	Cache       *cache.Cache
+	Sitemap     *sitemap.Generator
+	Events      *events.Bus
+	Broker      *events.Broker
+	Webhooks    *dispatch.Dispatcher
+
+	limiter ratelimit.Store
+}
+
+// rateLimitStore returns the buckets that the HTTP and gRPC rate limits
+// share, so that a client has one budget across both APIs.
+func (s *Server) rateLimitStore() ratelimit.Store {
+	if s.limiter == nil {
+		s.limiter = ratelimit.NewMemoryStore()
+		if s.RateLimit.Shared {
+			s.limiter = &ratelimit.MongoStore{DB: s.SystemDB}
+		}
+	}
+	return s.limiter
 }
 
 func (s *Server) NewRouter() http.Handler {
 	slog.Info("Setting up new router")
 	router := chi.NewRouter()
 
+	router.Use(middleware.RequestID)
+	if s.TrustProxy {
+		router.Use(middleware.RealIP)
+	}
+
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost", "https://localhost", "http://localhost:3000", "https://localhost:3000", "https://abyan.dev"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Last-Event-ID", "If-None-Match", "If-Modified-Since", "Idempotency-Key"},
+		ExposedHeaders:   []string{"ETag", "Last-Modified", "Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 	slog.Info("CORS middleware configured")
 
+	router.Use(auth.Authenticate(&apikeys.APIKeyRepository{DB: s.SystemDB}, s.AdminToken))
+	slog.Info("Authentication middleware configured")
+
+	if s.RateLimit != nil {
+		router.Use(ratelimit.Middleware(*s.RateLimit, s.rateLimitStore()))
+		slog.Info("Rate limiting middleware configured", "shared", s.RateLimit.Shared)
+	}
+
 	// Health check
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -40,22 +119,144 @@ func (s *Server) NewRouter() http.Handler {
 	})
 	slog.Info("Health check route configured")
 
-	contentService := services.ContentService{DB: s.DB}
+	// API documentation
+	router.Get("/openapi.json", docs.HandleSpec)
+	router.Get("/docs", docs.HandleDocs)
+	slog.Info("API documentation routes configured")
+
+	collectionPolicy := s.collectionPolicy()
+	contentService := services.ContentService{DB: s.DB, SystemDB: s.SystemDB, Events: s.Events, Cache: s.Cache, CacheMaxAge: s.CacheMaxAge}
+	streamService := services.StreamService{Broker: s.Broker}
+	collectionService := services.CollectionService{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache, Policy: collectionPolicy}
+	schemaService := services.SchemaService{DB: s.DB, SystemDB: s.SystemDB, Policy: collectionPolicy}
+	feedService := services.FeedService{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache, CacheMaxAge: s.CacheMaxAge, BaseURL: s.BaseURL}
+
+	// Without a configuration, Idempotency-Key headers are ignored.
+	idempotent := func(next http.Handler) http.Handler { return next }
+	if s.Idempotency != nil && s.Idempotency.Enabled() {
+		var store idempotency.Store = idempotency.NewMemoryStore()
+		if s.SystemDB != nil {
+			store = &idempotency.MongoStore{DB: s.SystemDB}
+		}
+		idempotent = idempotency.Middleware(*s.Idempotency, store)
+		slog.Info("Idempotency middleware configured", "window", s.Idempotency.Window)
+	}
+
+	// The catalog only lists the collections the caller may read, so it
+	// checks scopes itself.
+	router.Get("/contents", collectionService.HandleListCollections)
 
 	// Content services
-	router.Post("/contents/{collection}", contentService.HandleCreateContent)
-	router.Get("/contents/{collection}", contentService.HandleGetCollection)
-	router.Get("/contents/{collection}/id/{id}", contentService.HandleGetContent)
-	router.Get("/contents/{collection}/class/{class}", contentService.HandleGetClass)
-	router.Put("/contents/{collection}/id/{id}", contentService.HandleUpdateContent)
-	router.Delete("/contents/{collection}", contentService.HandleDeleteCollection)
-	router.Delete("/contents/{collection}/id/{id}", contentService.HandleDeleteContent)
-	router.Delete("/contents/{collection}/class/{class}", contentService.HandleDeleteClass)
+	router.Group(func(router chi.Router) {
+		router.Use(auth.Authorize)
+		router.Use(policy.Middleware(collectionPolicy))
+		router.With(idempotent).Post("/contents/{collection}", contentService.HandleCreateContent)
+		router.Get("/contents/{collection}", contentService.HandleGetCollection)
+		router.Get("/contents/{collection}/events", streamService.HandleStreamEvents)
+		router.Get("/contents/{collection}/stats", collectionService.HandleGetCollectionStats)
+		router.Get("/contents/{collection}/changes", contentService.HandleGetChanges)
+		router.Get("/contents/{collection}/export", contentService.HandleExportCollection)
+		router.With(idempotent).Post("/contents/{collection}/import", contentService.HandleImportCollection)
+		router.With(idempotent).Post("/contents/{collection}/bulk", contentService.HandleBulkCreateContent)
+		router.With(idempotent).Patch("/contents/{collection}/bulk", contentService.HandleBulkUpdateContent)
+		router.With(idempotent).Delete("/contents/{collection}/bulk", contentService.HandleBulkDeleteContent)
+		router.Get("/contents/{collection}/id/{id}", contentService.HandleGetContent)
+		router.Get("/contents/{collection}/classes", contentService.HandleListClasses)
+		router.Get("/contents/{collection}/class/{class}", contentService.HandleGetClass)
+		router.Get("/contents/{collection}/class/{class}/schema", schemaService.HandleGetSchema)
+		router.Post("/contents/{collection}/class/{class}/rename", contentService.HandleRenameClass)
+		router.Post("/contents/{collection}/class/{class}/merge", contentService.HandleMergeClass)
+		router.Post("/contents/{collection}/class/{class}/move", contentService.HandleMoveClassContents)
+		router.Put("/contents/{collection}/id/{id}", contentService.HandleUpdateContent)
+		router.Patch("/contents/{collection}/id/{id}", contentService.HandlePatchContent)
+		router.Delete("/contents/{collection}", contentService.HandleDeleteCollection)
+		router.Delete("/contents/{collection}/id/{id}", contentService.HandleDeleteContent)
+		router.Delete("/contents/{collection}/class/{class}", contentService.HandleDeleteClass)
+	})
 	slog.Info("Content service routes configured")
 
+	// Feeds only carry public content, but API keys are still held to their
+	// collections.
+	router.Group(func(router chi.Router) {
+		router.Use(auth.Authorize)
+		router.Use(policy.Middleware(collectionPolicy))
+		router.Get("/feeds/{collection}.atom", feedService.HandleGetAtomFeed)
+		router.Get("/feeds/{collection}.rss", feedService.HandleGetRSSFeed)
+		router.Get("/feeds/{collection}/class/{class}.atom", feedService.HandleGetAtomFeed)
+		router.Get("/feeds/{collection}/class/{class}.rss", feedService.HandleGetRSSFeed)
+	})
+	slog.Info("Feed routes configured")
+
+	// Sitemaps only list public content of the collections configured for
+	// them, for crawlers that send no credentials.
+	sitemapService := services.SitemapService{Sitemap: s.Sitemap, CacheMaxAge: s.CacheMaxAge, BaseURL: s.BaseURL}
+	router.Get("/sitemap.xml", sitemapService.HandleGetSitemapIndex)
+	router.Get("/sitemaps/{collection}/{chunk}.xml", sitemapService.HandleGetSitemap)
+	slog.Info("Sitemap routes configured")
+
+	// GraphQL checks collection scopes in its resolvers, since one request can
+	// touch several collections.
+	graphqlHandler, err := graphql.NewHandler(s.DB, s.SystemDB, s.Events, s.Broker)
+	if err != nil {
+		slog.Error("Failed to build GraphQL schema", "error", err)
+		log.Fatal(err)
+	}
+	graphqlHandler.Cache = s.Cache
+	graphqlHandler.Policy = collectionPolicy
+	router.Get("/graphql", graphqlHandler.ServeHTTP)
+	router.Post("/graphql", graphqlHandler.ServeHTTP)
+	slog.Info("GraphQL route configured")
+
+	apiKeyService := services.APIKeyService{DB: s.SystemDB}
+	auditService := services.AuditService{DB: s.SystemDB}
+	webhookService := services.WebhookService{DB: s.SystemDB, Dispatcher: s.Webhooks}
+	cacheService := services.CacheService{Cache: s.Cache}
+
+	// Admin services
+	router.Route("/admin", func(router chi.Router) {
+		router.Use(auth.RequireAdmin)
+		router.Post("/api-keys", apiKeyService.HandleCreateAPIKey)
+		router.Get("/api-keys", apiKeyService.HandleListAPIKeys)
+		router.Delete("/api-keys/{id}", apiKeyService.HandleRevokeAPIKey)
+		router.Post("/api-keys/{id}/rotate", apiKeyService.HandleRotateAPIKey)
+		router.Post("/webhooks", webhookService.HandleCreateWebhook)
+		router.Get("/webhooks", webhookService.HandleListWebhooks)
+		router.Get("/webhooks/{id}", webhookService.HandleGetWebhook)
+		router.Put("/webhooks/{id}", webhookService.HandleUpdateWebhook)
+		router.Delete("/webhooks/{id}", webhookService.HandleDeleteWebhook)
+		router.Get("/webhooks/{id}/deliveries", webhookService.HandleListDeliveries)
+		router.Post("/webhooks/{id}/deliveries/{delivery}/redeliver", webhookService.HandleRedeliver)
+		router.Put("/collections/{collection}", collectionService.HandleRegisterCollection)
+		router.Delete("/collections/{collection}", collectionService.HandleUnregisterCollection)
+		router.Put("/schemas/{collection}/{class}", schemaService.HandleRegisterSchema)
+		router.Get("/schemas/{collection}/{class}", schemaService.HandleListSchemaVersions)
+		router.Delete("/schemas/{collection}/{class}", schemaService.HandleDeleteSchema)
+		router.Get("/schemas/{collection}/{class}/report", schemaService.HandleGetSchemaReport)
+		router.Get("/cache", cacheService.HandleGetCacheStats)
+		router.Delete("/cache", cacheService.HandlePurgeCache)
+	})
+	slog.Info("Admin service routes configured")
+
+	// Audit services
+	router.Group(func(router chi.Router) {
+		router.Use(auth.RequireAdmin)
+		router.Get("/audit", auditService.HandleListAudit)
+		router.Get("/audit/export", auditService.HandleExportAudit)
+	})
+	slog.Info("Audit service routes configured")
+
 	return router
 }
 
+// collectionPolicy returns the policy for which collections may be created,
+// or nil to allow any.
+func (s *Server) collectionPolicy() *policy.Policy {
+	if s.Collections == nil {
+		return nil
+	}
+	return policy.New(*s.Collections, &policy.MongoCatalog{DB: s.DB, SystemDB: s.SystemDB})
+}
+
 func (s *Server) NewServer() *http.Server {
 	s.Port = "8000"
 
@@ -69,6 +270,57 @@ func (s *Server) NewServer() *http.Server {
 	return server
 }
 
+// NewGRPCServer serves the typed content API on its own port, alongside the
+// HTTP server.
+func (s *Server) NewGRPCServer() (*grpc.Server, net.Listener, error) {
+	s.GRPCPort = "9000"
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.GRPCPort))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content := &rpc.ContentServer{DB: s.DB, SystemDB: s.SystemDB, Events: s.Events, Cache: s.Cache, Policy: s.collectionPolicy()}
+	var store ratelimit.Store
+	if s.RateLimit != nil {
+		store = s.rateLimitStore()
+	}
+	server := rpc.NewServer(content, &apikeys.APIKeyRepository{DB: s.SystemDB}, s.AdminToken, s.RateLimit, store)
+
+	slog.Info("New gRPC server instance created", "port", s.GRPCPort)
+	return server, listener, nil
+}
+
+// EnsureIndexes creates the indexes of every system collection, including the
+// shared rate limit store and the idempotency store when they are
+// configured. It is safe to run again.
+func (s *Server) EnsureIndexes() error {
+	apiKeyRepo := apikeys.APIKeyRepository{DB: s.SystemDB}
+	auditRepo := audit.AuditRepository{DB: s.SystemDB}
+	webhookRepo := webhooks.WebhookRepository{DB: s.SystemDB}
+	collectionRepo := collections.CollectionRepository{DB: s.SystemDB}
+	schemaRepo := schemas.SchemaRepository{DB: s.SystemDB}
+	contentRepo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB}
+
+	errs := []error{
+		apiKeyRepo.EnsureIndexes(),
+		auditRepo.EnsureIndexes(),
+		webhookRepo.EnsureIndexes(),
+		collectionRepo.EnsureIndexes(),
+		schemaRepo.EnsureIndexes(),
+		contentRepo.EnsureSyncIndexes(),
+	}
+	if s.RateLimit != nil && s.RateLimit.Shared {
+		store := ratelimit.MongoStore{DB: s.SystemDB}
+		errs = append(errs, store.EnsureIndexes(max(s.RateLimit.Read.Per, s.RateLimit.Write.Per, s.RateLimit.Delete.Per)*2))
+	}
+	if s.Idempotency != nil && s.Idempotency.Enabled() {
+		store := idempotency.MongoStore{DB: s.SystemDB}
+		errs = append(errs, store.EnsureIndexes(s.Idempotency.Window))
+	}
+	return errors.Join(errs...)
+}
+
 func (s *Server) Run() {
 	client, err := utils.ConnectToDB()
 	slog.Info("Connecting to database")
@@ -88,9 +340,109 @@ func (s *Server) Run() {
 	s.DB = client.Database("content")
 	slog.Info("Database connection established", "db", "content")
 
-	slog.Info(fmt.Sprintf("The server is now live on port %s", s.Port))
+	s.SystemDB = client.Database("cms")
+	slog.Info("Database connection established", "db", "cms")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	auditService := services.AuditService{DB: s.SystemDB}
+	s.Webhooks = dispatch.NewDispatcher(s.SystemDB)
+	go s.Webhooks.Run(ctx)
+
+	s.Events = events.NewBus()
+	s.Events.Subscribe(auditService.Record)
+	s.Events.Subscribe(s.Webhooks.Handle)
+
+	cacheConfig, err := cache.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Cache = cache.New(cacheConfig)
+
+	sitemapConfig, err := sitemap.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Sitemap = sitemap.New(sitemapConfig, &repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache})
+
+	s.Broker = events.NewBroker(streamBufferSize)
+	watcher := events.ChangeStreamWatcher{DB: s.DB, Broker: s.Broker, Observers: []func(events.StreamEvent){s.Cache.HandleStreamEvent, s.Sitemap.HandleStreamEvent}}
+	if err := watcher.Start(ctx); err != nil {
+		slog.Warn("Change streams unavailable, streaming events from this instance only", "error", err)
+		s.Events.Subscribe(s.Broker.Handle)
+		s.Events.Subscribe(s.Sitemap.Handle)
+		if s.Cache != nil {
+			slog.Warn("Cached reads may miss writes made through other instances until they expire", "ttl", cacheConfig.TTL)
+		}
+	}
+	slog.Info("Event subscribers configured")
+
+	s.TrustProxy = os.Getenv("YAN_CMS_TRUST_FORWARDED_FOR") == "true"
+	s.BaseURL = os.Getenv("YAN_CMS_BASE_URL")
+	s.AdminToken = os.Getenv("YAN_CMS_ADMIN_TOKEN")
+	if s.AdminToken == "" {
+		slog.Warn("Environment variable YAN_CMS_ADMIN_TOKEN not set, admin routes are disabled")
+	}
+
+	s.CacheMaxAge = defaultCacheMaxAge
+	if value := os.Getenv("YAN_CMS_CACHE_MAX_AGE"); value != "" {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil || maxAge < 0 {
+			log.Fatalf("invalid duration %q in YAN_CMS_CACHE_MAX_AGE", value)
+		}
+		s.CacheMaxAge = maxAge
+	}
+
+	rateLimit, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.RateLimit = &rateLimit
+
+	idempotencyConfig, err := idempotency.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Idempotency = &idempotencyConfig
+
+	collectionsConfig, err := policy.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Collections = &collectionsConfig
+
+	// Idempotency keys, rate limit buckets and collection registrations rely
+	// on unique indexes, so the server must not start without them.
+	if err := s.EnsureIndexes(); err != nil {
+		slog.Error("Failed to create indexes", "error", err)
+		log.Fatal(err)
+	}
+
+	backupConfig, err := backup.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if backupConfig.Dir != "" {
+		go backup.NewScheduler(backupConfig, s.DB).Run(ctx)
+	} else {
+		slog.Warn("Environment variable YAN_CMS_BACKUP_DIR not set, scheduled backups are disabled")
+	}
+
+	grpcServer, listener, err := s.NewGRPCServer()
+	if err != nil {
+		slog.Error("Failed to listen for gRPC", "error", err)
+		log.Fatal(err)
+	}
+	go func() {
+		slog.Info(fmt.Sprintf("The gRPC server is now live on port %s", s.GRPCPort))
+		if err := grpcServer.Serve(listener); err != nil {
+			slog.Error("gRPC server encountered an error", "error", err)
+		}
+	}()
 
 	srv := s.NewServer()
+	slog.Info(fmt.Sprintf("The server is now live on port %s", s.Port))
 	err = srv.ListenAndServe()
 	if err != nil {
 		slog.Error("Server encountered an error", "error", err)
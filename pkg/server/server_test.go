@@ -72,6 +72,20 @@ func TestNewRouter(t *testing.T) {
 			t.Errorf("expected body %q but got %q", expectedBody, rr.Body.String())
 		}
 	})
+
+	t.Run("Admin Routes Require Authentication", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/admin/api-keys", nil)
+		if err != nil {
+			t.Fatalf("could not create request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d but got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
 }
 
 type MockServer struct {
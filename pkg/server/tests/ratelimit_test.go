@@ -0,0 +1,88 @@
+package apitests
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/ratelimit"
+	"github.com/YanSystems/cms/pkg/server"
+	utils "github.com/YanSystems/cms/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMongoRateLimitStore(t *testing.T) {
+	client, err := utils.ConnectToDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err := client.Disconnect(context.TODO()); err != nil {
+			panic(err)
+		}
+	}()
+
+	store := ratelimit.MongoStore{DB: client.Database("cms-tests")}
+	assert.NoError(t, store.EnsureIndexes(time.Hour))
+	// A changed window updates the expiry instead of conflicting
+	assert.NoError(t, store.EnsureIndexes(2*time.Hour))
+
+	key := uuid.New().String()
+	limit := ratelimit.Limit{Requests: 2, Per: time.Minute}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	defer func() {
+		if _, err := store.DB.Collection("rate_limits").DeleteMany(context.TODO(), bson.D{{Key: "key", Value: key}}); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	t.Run("Shared Bucket", func(t *testing.T) {
+		result, err := store.Take(key, limit, now)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1, result.Remaining)
+
+		result, err = store.Take(key, limit, now)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = store.Take(key, limit, now)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("Refills Over Time", func(t *testing.T) {
+		result, err := store.Take(key, limit, now.Add(30*time.Second))
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+}
+
+func TestRateLimitedRouter(t *testing.T) {
+	api := server.Server{
+		Port:      "8000",
+		RateLimit: &ratelimit.Config{Read: ratelimit.Limit{Requests: 1, Per: time.Minute}},
+	}
+
+	r := api.NewRouter()
+
+	req, err := http.NewRequest("GET", "/health", nil)
+	assert.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/repositories/apikeys"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type APIKeyService struct {
+	DB *mongo.Database
+}
+
+func (s *APIKeyService) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleCreateAPIKey called")
+
+	var req models.CreateAPIKey
+	err := utils.Read(w, r, &req)
+	if err != nil {
+		slog.Error("Failed to read JSON request", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	if err := validateCreateAPIKey(&req); err != nil {
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Request payload validated successfully")
+
+	key, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		slog.Error("Failed to generate api key", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	apiKey := models.APIKey{
+		Id:          uuid.New().String(),
+		Name:        req.Name,
+		Prefix:      prefix,
+		Hash:        hash,
+		Collections: req.Collections,
+		Operations:  req.Operations,
+		ExpiresAt:   req.ExpiresAt,
+		UpdatedAt:   now,
+		CreatedAt:   now,
+	}
+
+	repo := apikeys.APIKeyRepository{DB: s.DB}
+	slog.Debug("APIKeyRepository initialized")
+
+	_, err = repo.CreateKey(&apiKey)
+	if err != nil {
+		slog.Error("Failed to create api key", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("API key created successfully", "id", apiKey.Id)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully created api key",
+		Data:    models.IssuedAPIKey{Key: key, APIKey: apiKey},
+	}
+
+	utils.Write(w, r, http.StatusCreated, responsePayload)
+	slog.Info("Response sent for HandleCreateAPIKey", "status", http.StatusCreated)
+}
+
+func (s *APIKeyService) HandleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListAPIKeys called")
+
+	repo := apikeys.APIKeyRepository{DB: s.DB}
+	slog.Debug("APIKeyRepository initialized")
+
+	keys, err := repo.ListKeys()
+	if err != nil {
+		slog.Error("Failed to list api keys", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved api keys",
+		Data:    keys,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleListAPIKeys", "status", http.StatusOK)
+}
+
+func (s *APIKeyService) HandleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleRevokeAPIKey called")
+	id := chi.URLParam(r, "id")
+	slog.Debug("ID parameter extracted", "id", id)
+
+	repo := apikeys.APIKeyRepository{DB: s.DB}
+	slog.Debug("APIKeyRepository initialized")
+
+	_, err := repo.RevokeKey(id)
+	if err != nil {
+		slog.Error("Failed to revoke api key", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("API key revoked successfully", "id", id)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully revoked api key",
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleRevokeAPIKey", "status", http.StatusOK)
+}
+
+func (s *APIKeyService) HandleRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleRotateAPIKey called")
+	id := chi.URLParam(r, "id")
+	slog.Debug("ID parameter extracted", "id", id)
+
+	repo := apikeys.APIKeyRepository{DB: s.DB}
+	slog.Debug("APIKeyRepository initialized")
+
+	apiKey, err := repo.GetKey(id)
+	if err != nil {
+		slog.Error("Failed to get api key", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	if apiKey.RevokedAt != nil {
+		err := errors.New("cannot rotate a revoked api key")
+		slog.Error("Rotation of revoked api key rejected", "id", id, "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		slog.Error("Failed to generate api key", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, err = repo.RotateKey(id, prefix, hash)
+	if err != nil {
+		slog.Error("Failed to rotate api key", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("API key rotated successfully", "id", id)
+
+	apiKey.Prefix = prefix
+	apiKey.Hash = hash
+	apiKey.UpdatedAt = time.Now().UTC()
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully rotated api key",
+		Data:    models.IssuedAPIKey{Key: key, APIKey: *apiKey},
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleRotateAPIKey", "status", http.StatusOK)
+}
+
+func validateCreateAPIKey(req *models.CreateAPIKey) error {
+	if req.Name == "" {
+		return errors.New("missing fields in request payload")
+	}
+	if len(req.Collections) == 0 {
+		return errors.New("api key must be scoped to at least one collection")
+	}
+	if len(req.Operations) == 0 {
+		return errors.New("api key must be scoped to at least one operation")
+	}
+	for _, op := range req.Operations {
+		if !slices.Contains(auth.Operations, op) {
+			return fmt.Errorf("unknown operation %q", op)
+		}
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return errors.New("expiry must be in the future")
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/repositories/audit"
+	"github.com/YanSystems/cms/pkg/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditService struct {
+	DB *mongo.Database
+}
+
+func (s *AuditService) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListAudit called")
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		slog.Error("Invalid audit filter", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Debug("Audit filter parsed", "filter", filter)
+
+	repo := audit.AuditRepository{DB: s.DB}
+	slog.Debug("AuditRepository initialized")
+
+	entries, err := repo.ListEntries(filter)
+	if err != nil {
+		slog.Error("Failed to list audit entries", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved audit entries",
+		Data:    entries,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleListAudit", "status", http.StatusOK)
+}
+
+func (s *AuditService) HandleExportAudit(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleExportAudit called")
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		slog.Error("Invalid audit filter", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		err := fmt.Errorf("unsupported export format %q", format)
+		slog.Error("Invalid export format", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	repo := audit.AuditRepository{DB: s.DB}
+	slog.Debug("AuditRepository initialized")
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var write func(*models.AuditEntry) error
+	var flush func()
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write(auditCSVHeader)
+		write = func(entry *models.AuditEntry) error {
+			return writer.Write(auditCSVRecord(entry))
+		}
+		flush = writer.Flush
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		write = func(entry *models.AuditEntry) error {
+			return encoder.Encode(entry)
+		}
+		flush = func() {}
+	}
+
+	err = repo.ExportEntries(filter, write)
+	flush()
+	if err != nil {
+		// The status line has already been sent, so all we can do is log.
+		slog.Error("Failed to export audit entries", "error", err)
+		return
+	}
+
+	slog.Info("Response sent for HandleExportAudit", "status", http.StatusOK, "format", format)
+}
+
+var auditCSVHeader = []string{
+	"id", "timestamp", "action", "principal", "request_id", "source_ip",
+	"collection", "content_id", "class", "ids", "before", "after",
+}
+
+func auditCSVRecord(entry *models.AuditEntry) []string {
+	before, _ := json.Marshal(entry.Before)
+	after, _ := json.Marshal(entry.After)
+	return []string{
+		entry.Id,
+		entry.Timestamp.Format(time.RFC3339Nano),
+		entry.Action,
+		entry.Principal,
+		entry.RequestId,
+		entry.SourceIP,
+		entry.Collection,
+		entry.ContentId,
+		entry.Class,
+		strings.Join(entry.Ids, ";"),
+		string(before),
+		string(after),
+	}
+}
+
+func parseAuditFilter(r *http.Request) (*audit.Filter, error) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Action:     query.Get("action"),
+		Principal:  query.Get("principal"),
+		RequestId:  query.Get("request_id"),
+		Collection: query.Get("collection"),
+		ContentId:  query.Get("content_id"),
+		Class:      query.Get("class"),
+		Limit:      defaultAuditLimit,
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s timestamp, expected RFC 3339", param)
+		}
+		*target = &t
+	}
+
+	for param, target := range map[string]*int64{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s, expected a non-negative integer", param)
+		}
+		*target = n
+	}
+	if filter.Limit == 0 || filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	return &filter, nil
+}
+
+func (s *AuditService) Record(e events.Event) {
+	entry := models.AuditEntry{
+		Id:         e.Id,
+		Action:     e.Type,
+		Principal:  e.Principal,
+		RequestId:  e.RequestId,
+		SourceIP:   e.SourceIP,
+		Timestamp:  e.Time,
+		Collection: e.Collection,
+		ContentId:  e.ContentId,
+		Class:      e.Class,
+		Ids:        e.Ids,
+	}
+
+	switch e.Type {
+	case models.EventContentUpdated:
+		entry.Before, entry.After = diffSummaries(summarizeContent(e.Before), summarizeContent(e.After))
+	case models.EventClassDeleted, models.EventCollectionDeleted:
+		entry.Before = map[string]any{"count": len(e.Ids)}
+	case models.EventClassMoved:
+		entry.Before = map[string]any{"class": e.PreviousClass, "count": len(e.Ids)}
+		entry.After = map[string]any{"class": e.Class}
+	default:
+		entry.Before = summarizeContent(e.Before)
+		entry.After = summarizeContent(e.After)
+	}
+
+	repo := audit.AuditRepository{DB: s.DB}
+	if _, err := repo.CreateEntry(&entry); err != nil {
+		slog.Error("Failed to record audit entry", "action", entry.Action, "collection", entry.Collection, "error", err)
+	}
+}
+
+// summarizeContent describes content for the audit log. The body and custom
+// fields are reduced to digests so that entries stay small but changes to
+// them remain visible. The fields are digested as JSON, which sorts their
+// keys and reads the same whichever numeric types they were decoded into.
+func summarizeContent(c *models.Content) map[string]any {
+	if c == nil {
+		return nil
+	}
+	digest := sha256.Sum256([]byte(c.Body))
+	summary := map[string]any{
+		"class":          c.Class,
+		"title":          c.Title,
+		"description":    c.Description,
+		"body_sha256":    hex.EncodeToString(digest[:]),
+		"is_public":      c.IsPublic,
+		"views":          c.Views,
+		"creator_id":     c.CreatorId,
+		"schema_version": c.SchemaVersion,
+	}
+	if len(c.Fields) > 0 {
+		fields, err := json.Marshal(c.Fields)
+		if err != nil {
+			slog.Error("Failed to encode fields for the audit log", "contentID", c.Id, "error", err)
+		} else {
+			digest := sha256.Sum256(fields)
+			summary["fields_sha256"] = hex.EncodeToString(digest[:])
+		}
+	}
+	return summary
+}
+
+func diffSummaries(before map[string]any, after map[string]any) (map[string]any, map[string]any) {
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changedBefore[key] = before[key]
+			changedAfter[key] = value
+		}
+	}
+	return changedBefore, changedAfter
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeContent(t *testing.T) {
+	before := summarizeContent(&models.Content{Class: "lessons", Title: "Intro", Body: "v1"})
+	after := summarizeContent(&models.Content{Class: "lessons", Title: "Introduction", Body: "v2"})
+
+	assert.NotContains(t, before, "body")
+	assert.NotEqual(t, before["body_sha256"], after["body_sha256"])
+
+	changedBefore, changedAfter := diffSummaries(before, after)
+	assert.Equal(t, map[string]any{"title": "Intro", "body_sha256": before["body_sha256"]}, changedBefore)
+	assert.Equal(t, map[string]any{"title": "Introduction", "body_sha256": after["body_sha256"]}, changedAfter)
+	assert.Nil(t, summarizeContent(nil))
+
+	before = summarizeContent(&models.Content{Class: "lessons", Fields: map[string]any{"duration": int32(30)}, SchemaVersion: 1})
+	after = summarizeContent(&models.Content{Class: "lessons", Fields: map[string]any{"duration": float64(45)}, SchemaVersion: 2})
+	same := summarizeContent(&models.Content{Class: "lessons", Fields: map[string]any{"duration": float64(30)}, SchemaVersion: 1})
+	assert.NotContains(t, before, "fields")
+	assert.Equal(t, before["fields_sha256"], same["fields_sha256"])
+
+	changedBefore, changedAfter = diffSummaries(before, after)
+	assert.Equal(t, map[string]any{"fields_sha256": before["fields_sha256"], "schema_version": 1}, changedBefore)
+	assert.Equal(t, map[string]any{"fields_sha256": after["fields_sha256"], "schema_version": 2}, changedAfter)
+}
+
+func TestParseAuditFilter(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		filter, err := parseAuditFilter(httptest.NewRequest("GET", "/audit", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(defaultAuditLimit), filter.Limit)
+		assert.Nil(t, filter.From)
+	})
+
+	t.Run("All Filters", func(t *testing.T) {
+		filter, err := parseAuditFilter(httptest.NewRequest("GET", "/audit?action=content.deleted&collection=lessons&from=2024-01-01T00:00:00Z&limit=5000&offset=10", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, models.EventContentDeleted, filter.Action)
+		assert.Equal(t, "lessons", filter.Collection)
+		assert.Equal(t, 2024, filter.From.Year())
+		assert.Equal(t, int64(maxAuditLimit), filter.Limit)
+		assert.Equal(t, int64(10), filter.Offset)
+	})
+
+	t.Run("Invalid Timestamp", func(t *testing.T) {
+		_, err := parseAuditFilter(httptest.NewRequest("GET", "/audit?to=yesterday", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		_, err := parseAuditFilter(httptest.NewRequest("GET", "/audit?limit=-1", nil))
+		assert.Error(t, err)
+	})
+}
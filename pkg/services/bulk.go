@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	maxBulkBytes = 16 << 20 // 16 megabytes
+	maxBulkItems = 1000
+)
+
+var errMissingFields = errors.New("missing fields in request payload")
+
+// HandleBulkCreateContent creates every item of an array in one bulk write.
+// Items get new ids and timestamps, as with HandleCreateContent.
+func (s *ContentService) HandleBulkCreateContent(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleBulkCreateContent called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	atomic, err := parseAtomic(r)
+	if err != nil {
+		slog.Error("Invalid bulk options", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	var items []models.Content
+	if !readBulk(w, r, &items, func() int { return len(items) }) {
+		return
+	}
+	slog.Debug("Bulk request body read successfully", "count", len(items), "atomic", atomic)
+
+	// Items missing fields never reach the repository, so their results
+	// are filled in here and the rest placed around them.
+	now := time.Now().UTC()
+	results := make([]repositories.BulkResult, len(items))
+	var valid []models.Content
+	var positions []int
+	for i := range items {
+		c := &items[i]
+		c.Id = uuid.New().String()
+		c.UpdatedAt = now
+		c.CreatedAt = now
+		results[i] = repositories.BulkResult{Id: c.Id, After: c}
+		if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+			results[i].Err = errMissingFields
+			continue
+		}
+		valid = append(valid, *c)
+		positions = append(positions, i)
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	if atomic && len(valid) < len(items) {
+		for _, i := range positions {
+			results[i].Err = repositories.ErrBulkAborted
+		}
+	} else if len(valid) > 0 {
+		slog.Info("Creating contents...", "count", len(valid))
+		written, err := repo.BulkCreate(coll, valid, atomic)
+		if err != nil {
+			slog.Error("Failed to create contents", "error", err)
+			utils.Error(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		for j, result := range written {
+			results[positions[j]] = result
+		}
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			s.publish(r, events.Event{
+				Type:       models.EventContentCreated,
+				Collection: coll,
+				ContentId:  result.Id,
+				Class:      result.After.Class,
+				After:      result.After,
+			})
+		}
+	}
+
+	s.writeBulkReport(w, r, "created", atomic, results, http.StatusCreated)
+}
+
+// HandleBulkUpdateContent applies an array of partial updates, each naming
+// the item it changes, in one bulk write.
+func (s *ContentService) HandleBulkUpdateContent(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleBulkUpdateContent called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	atomic, err := parseAtomic(r)
+	if err != nil {
+		slog.Error("Invalid bulk options", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	var updates []models.BulkUpdate
+	if !readBulk(w, r, &updates, func() int { return len(updates) }) {
+		return
+	}
+	slog.Debug("Bulk request body read successfully", "count", len(updates), "atomic", atomic)
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	slog.Info("Updating contents...", "count", len(updates))
+	results, err := repo.BulkUpdate(coll, updates, atomic)
+	if err != nil {
+		slog.Error("Failed to update contents", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			s.publish(r, events.Event{
+				Type:       models.EventContentUpdated,
+				Collection: coll,
+				ContentId:  result.Id,
+				Class:      result.After.Class,
+				Before:     result.Before,
+				After:      result.After,
+			})
+		}
+	}
+
+	s.writeBulkReport(w, r, "updated", atomic, results, http.StatusOK)
+}
+
+// HandleBulkDeleteContent deletes the items whose ids are in an array in one
+// bulk write.
+func (s *ContentService) HandleBulkDeleteContent(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleBulkDeleteContent called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	atomic, err := parseAtomic(r)
+	if err != nil {
+		slog.Error("Invalid bulk options", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	var ids []string
+	if !readBulk(w, r, &ids, func() int { return len(ids) }) {
+		return
+	}
+	slog.Debug("Bulk request body read successfully", "count", len(ids), "atomic", atomic)
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	slog.Info("Deleting contents...", "count", len(ids))
+	results, err := repo.BulkDelete(coll, ids, atomic)
+	if err != nil {
+		slog.Error("Failed to delete contents", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			event := events.Event{
+				Type:       models.EventContentDeleted,
+				Collection: coll,
+				ContentId:  result.Id,
+			}
+			if result.Before != nil {
+				event.Class = result.Before.Class
+				event.Before = result.Before
+			}
+			s.publish(r, event)
+		}
+	}
+
+	s.writeBulkReport(w, r, "deleted", atomic, results, http.StatusOK)
+}
+
+func parseAtomic(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("atomic")
+	if value == "" {
+		return false, nil
+	}
+	atomic, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("invalid atomic, expected true or false")
+	}
+	return atomic, nil
+}
+
+// readBulk reads the array of a bulk request into items and checks its
+// length, responding with the error and returning false if it fails.
+func readBulk(w http.ResponseWriter, r *http.Request, items any, count func() int) bool {
+	err := utils.ReadLimit(w, r, items, maxBulkBytes)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		err := fmt.Errorf("bulk request must not be larger than %d bytes", maxBulkBytes)
+		slog.Error("Bulk request body too large", "error", err)
+		utils.Error(w, r, err, http.StatusRequestEntityTooLarge)
+		return false
+	case err != nil:
+		slog.Error("Failed to read request body", "error", err)
+		utils.Error(w, r, err)
+		return false
+	case count() == 0:
+		err := errors.New("bulk request must have at least one item")
+		slog.Error("Empty bulk request", "error", err)
+		utils.Error(w, r, err)
+		return false
+	case count() > maxBulkItems:
+		err := fmt.Errorf("bulk request must not have more than %d items", maxBulkItems)
+		slog.Error("Bulk request has too many items", "error", err)
+		utils.Error(w, r, err, http.StatusRequestEntityTooLarge)
+		return false
+	}
+	return true
+}
+
+// writeBulkReport responds with the result of every item. An atomic request
+// that wrote nothing gets a 422, while a best-effort one is a 200 however
+// many of its items failed.
+func (s *ContentService) writeBulkReport(w http.ResponseWriter, r *http.Request, action string, atomic bool, results []repositories.BulkResult, okStatus int) {
+	report := newBulkReport(atomic, results, okStatus)
+	slog.Info("Bulk write completed", "action", action, "atomic", atomic, "succeeded", report.Succeeded, "failed", report.Failed)
+
+	status := http.StatusOK
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: fmt.Sprintf("Successfully %s %d of %d items", action, report.Succeeded, len(results)),
+		Data:    report,
+	}
+	if atomic && report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+		responsePayload.Error = true
+		responsePayload.Message = "bulk write aborted, no items were " + action
+	}
+
+	utils.Write(w, r, status, responsePayload)
+	slog.Info("Response sent for bulk write", "status", status)
+}
+
+func newBulkReport(atomic bool, results []repositories.BulkResult, okStatus int) models.BulkReport {
+	report := models.BulkReport{Atomic: atomic, Results: make([]models.BulkItemResult, len(results))}
+	for i, result := range results {
+		item := models.BulkItemResult{Index: i, Id: result.Id, Status: bulkItemStatus(result.Err, okStatus)}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+		report.Results[i] = item
+	}
+	return report
+}
+
+func bulkItemStatus(err error, okStatus int) int {
+	var validationErr *repositories.ValidationError
+	switch {
+	case err == nil:
+		return okStatus
+	case errors.Is(err, repositories.ErrBulkAborted):
+		return http.StatusFailedDependency
+	case errors.Is(err, repositories.ErrContentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrContentExists):
+		return http.StatusConflict
+	case errors.Is(err, errMissingFields), errors.Is(err, repositories.ErrDuplicateId), errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
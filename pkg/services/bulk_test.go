@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBulkReport(t *testing.T) {
+	results := []repositories.BulkResult{
+		{Id: "a"},
+		{Id: "b", Err: repositories.ErrContentNotFound},
+		{Id: "c", Err: &repositories.ValidationError{Field: "CreatorId", Err: errors.New("uuid")}},
+		{Id: "d", Err: repositories.ErrBulkAborted},
+		{Id: "e", Err: repositories.ErrContentExists},
+		{Id: "f", Err: errors.New("write failed")},
+	}
+
+	report := newBulkReport(true, results, http.StatusCreated)
+	assert.True(t, report.Atomic)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 5, report.Failed)
+
+	statuses := []int{}
+	for i, item := range report.Results {
+		assert.Equal(t, i, item.Index)
+		assert.Equal(t, results[i].Id, item.Id)
+		statuses = append(statuses, item.Status)
+	}
+	assert.Equal(t, []int{
+		http.StatusCreated,
+		http.StatusNotFound,
+		http.StatusBadRequest,
+		http.StatusFailedDependency,
+		http.StatusConflict,
+		http.StatusInternalServerError,
+	}, statuses)
+	assert.Empty(t, report.Results[0].Error)
+	assert.Equal(t, "content not found", report.Results[1].Error)
+}
+
+func TestHandleBulkRequests(t *testing.T) {
+	service := ContentService{}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"Invalid Atomic", http.MethodPost, "/contents/courses/bulk?atomic=maybe", `[]`, http.StatusBadRequest},
+		{"Empty", http.MethodPost, "/contents/courses/bulk", `[]`, http.StatusBadRequest},
+		{"Not An Array", http.MethodPatch, "/contents/courses/bulk", `{"id":"a"}`, http.StatusBadRequest},
+		{"Too Many Items", http.MethodDelete, "/contents/courses/bulk", `["a"` + strings.Repeat(`,"a"`, maxBulkItems) + `]`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			switch tt.method {
+			case http.MethodPost:
+				service.HandleBulkCreateContent(rr, r)
+			case http.MethodPatch:
+				service.HandleBulkUpdateContent(rr, r)
+			case http.MethodDelete:
+				service.HandleBulkDeleteContent(rr, r)
+			}
+			assert.Equal(t, tt.status, rr.Code)
+
+			var response models.JsonResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.True(t, response.Error)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/utils"
+)
+
+type CacheService struct {
+	Cache *cache.Cache
+}
+
+func (s *CacheService) HandleGetCacheStats(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetCacheStats called")
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved cache statistics",
+		Data:    s.Cache.Stats(),
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleGetCacheStats", "status", http.StatusOK)
+}
+
+// HandlePurgeCache empties the cache of this instance only.
+func (s *CacheService) HandlePurgeCache(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandlePurgeCache called")
+
+	s.Cache.Purge()
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully purged cache",
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandlePurgeCache", "status", http.StatusOK)
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// HandleListClasses lists the classes of a collection with how many items
+// each has and when the last of them was updated.
+func (s *ContentService) HandleListClasses(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListClasses called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	slog.Info("Listing classes...")
+	classes, err := repo.SummarizeClasses(coll)
+	if err != nil {
+		slog.Error("Failed to list classes", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Classes listed successfully", "count", len(classes))
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully listed classes",
+		Data:    classes,
+	}
+
+	// Class names can give private content away, so only the client may
+	// keep the listing.
+	utils.WriteCached(w, r, responsePayload, utils.CacheOptions{Weak: true})
+	slog.Info("Response sent for HandleListClasses", "status", http.StatusOK)
+}
+
+// HandleRenameClass moves every item of a class to a class with no items.
+func (s *ContentService) HandleRenameClass(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleRenameClass called")
+	s.handleMoveClass(w, r, "renamed", func(repo *repositories.ContentRepository, coll string, from string, move models.ClassMove) ([]string, error) {
+		return repo.RenameClass(coll, from, move.To)
+	})
+}
+
+// HandleMergeClass moves every item of a class to another class, which may
+// already have items.
+func (s *ContentService) HandleMergeClass(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleMergeClass called")
+	s.handleMoveClass(w, r, "merged", func(repo *repositories.ContentRepository, coll string, from string, move models.ClassMove) ([]string, error) {
+		return repo.MergeClass(coll, from, move.To)
+	})
+}
+
+// HandleMoveClassContents moves the items of a class with the ids given to
+// another class.
+func (s *ContentService) HandleMoveClassContents(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleMoveClassContents called")
+	s.handleMoveClass(w, r, "moved", func(repo *repositories.ContentRepository, coll string, from string, move models.ClassMove) ([]string, error) {
+		if len(move.Ids) == 0 {
+			return nil, &repositories.ValidationError{Field: "ids", Err: errors.New("must list at least one item to move")}
+		}
+		if len(move.Ids) > maxBulkItems {
+			return nil, &repositories.ValidationError{Field: "ids", Err: fmt.Errorf("must not list more than %d items", maxBulkItems)}
+		}
+		return repo.MoveToClass(coll, from, move.To, move.Ids)
+	})
+}
+
+// handleMoveClass reads the class to move items to, moves them with move and
+// publishes a class.moved event for the items it moved. If the items do not
+// match the schema of the new class, it responds with the schema report.
+func (s *ContentService) handleMoveClass(w http.ResponseWriter, r *http.Request, action string, move func(repo *repositories.ContentRepository, coll string, from string, move models.ClassMove) ([]string, error)) {
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	var request models.ClassMove
+	if err := utils.Read(w, r, &request); err != nil {
+		slog.Error("Failed to read JSON request", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	if request.To == "" {
+		err := errors.New("missing to in request payload")
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	if request.To == class {
+		err := errors.New("to must be a different class")
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	slog.Info("Moving class contents...", "from", class, "to", request.To)
+	ids, err := move(&repo, coll, class, request)
+	var mismatch *repositories.SchemaMismatchError
+	if errors.As(err, &mismatch) {
+		slog.Error("Class contents do not match the new class", "error", err)
+		utils.Write(w, r, http.StatusUnprocessableEntity, models.JsonResponse{
+			Error:   true,
+			Message: err.Error(),
+			Data:    mismatch.Report,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to move class contents", "error", err)
+		utils.Error(w, r, err, classErrorStatus(err))
+		return
+	}
+	slog.Info("Class contents moved successfully", "from", class, "to", request.To, "count", len(ids))
+
+	if len(ids) > 0 {
+		s.publish(r, events.Event{
+			Type:          models.EventClassMoved,
+			Collection:    coll,
+			Class:         request.To,
+			PreviousClass: class,
+			Ids:           ids,
+		})
+	}
+
+	result := models.ClassMoveResult{From: class, To: request.To, Moved: ids, Missing: missingIds(request.Ids, ids)}
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: fmt.Sprintf("Successfully %s %d items", action, len(ids)),
+		Data:    result,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for class move", "action", action, "status", http.StatusOK)
+}
+
+func classErrorStatus(err error) int {
+	var validationErr *repositories.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrClassNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrClassExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// missingIds returns the requested ids that are not among those moved, in
+// the order they were requested.
+func missingIds(requested []string, moved []string) []string {
+	found := make(map[string]bool, len(moved))
+	for _, id := range moved {
+		found[id] = true
+	}
+	var missing []string
+	for _, id := range requested {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true
+		}
+	}
+	return missing
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleMoveClassRequests(t *testing.T) {
+	service := ContentService{}
+	router := chi.NewRouter()
+	router.Post("/contents/{collection}/class/{class}/rename", service.HandleRenameClass)
+	router.Post("/contents/{collection}/class/{class}/move", service.HandleMoveClassContents)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"Missing To", "/contents/courses/class/drafts/rename", `{}`},
+		{"Same Class", "/contents/courses/class/drafts/rename", `{"to":"drafts"}`},
+		{"Move Without Ids", "/contents/courses/class/drafts/move", `{"to":"review"}`},
+		{"Move Too Many Ids", "/contents/courses/class/drafts/move", `{"to":"review","ids":["a"` + strings.Repeat(`,"a"`, maxBulkItems) + `]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, r)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var response models.JsonResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.True(t, response.Error)
+		})
+	}
+}
+
+func TestClassErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, classErrorStatus(repositories.ErrClassNotFound))
+	assert.Equal(t, http.StatusConflict, classErrorStatus(repositories.ErrClassExists))
+	assert.Equal(t, http.StatusBadRequest, classErrorStatus(&repositories.ValidationError{Field: "ids", Err: errors.New("must list at least one item to move")}))
+	assert.Equal(t, http.StatusInternalServerError, classErrorStatus(errors.New("connection reset")))
+}
+
+func TestMissingIds(t *testing.T) {
+	assert.Equal(t, []string{"b", "d"}, missingIds([]string{"a", "b", "c", "d", "b"}, []string{"a", "c"}))
+	assert.Nil(t, missingIds(nil, []string{"a"}))
+}
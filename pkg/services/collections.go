@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/policy"
+	"github.com/YanSystems/cms/pkg/repositories/collections"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errCollectionNotFound = errors.New("collection not found")
+
+// CollectionService serves the catalog of collections and the registry of
+// what they are for.
+type CollectionService struct {
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+	Cache    *cache.Cache
+	// Policy decides which collections may be registered. Without it, any
+	// may be.
+	Policy *policy.Policy
+}
+
+// HandleListCollections lists the collections the caller may read, whether
+// they hold items or are only registered, with the stats of each.
+// ?registered=false lists only those that are not registered, which were
+// usually created by mistake, and ?registered=true only those that are.
+func (s *CollectionService) HandleListCollections(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListCollections called")
+
+	var onlyRegistered *bool
+	if value := r.URL.Query().Get("registered"); value != "" {
+		registered, err := strconv.ParseBool(value)
+		if err != nil {
+			err := errors.New("invalid registered, expected true or false")
+			slog.Error("Invalid catalog filter", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+		onlyRegistered = &registered
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	names, err := repo.ListCollections()
+	if err != nil {
+		slog.Error("Failed to list collections", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	registry, err := s.registry()
+	if err != nil {
+		slog.Error("Failed to list registered collections", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	for name := range registry {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	principal := auth.FromContext(r.Context())
+	catalog := []models.CollectionStats{}
+	for _, name := range names {
+		info := registry[name]
+		if !principal.Allows(name, auth.OpRead) {
+			continue
+		}
+		if onlyRegistered != nil && *onlyRegistered != (info != nil) {
+			continue
+		}
+
+		stats, err := repo.CollectionStats(name)
+		if err != nil {
+			slog.Error("Failed to get collection stats", "collection", name, "error", err)
+			utils.Error(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		stats.Registered, stats.Info = info != nil, info
+		catalog = append(catalog, *stats)
+	}
+	slog.Info("Collections listed successfully", "count", len(catalog))
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully listed collections",
+		Data:    catalog,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleListCollections", "status", http.StatusOK)
+}
+
+// HandleGetCollectionStats describes one collection, with a summary of each
+// of its classes.
+func (s *CollectionService) HandleGetCollectionStats(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetCollectionStats called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	stats, err := repo.CollectionStats(coll)
+	if err != nil {
+		slog.Error("Failed to get collection stats", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	info, err := s.info(coll)
+	if err != nil {
+		slog.Error("Failed to get collection info", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	if stats.Count == 0 && info == nil {
+		slog.Error("Collection not found", "collection", coll)
+		utils.Error(w, r, errCollectionNotFound, http.StatusNotFound)
+		return
+	}
+	stats.Registered, stats.Info = info != nil, info
+
+	stats.ClassSummaries, err = repo.SummarizeClasses(coll)
+	if err != nil {
+		slog.Error("Failed to summarize classes", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Collection stats retrieved successfully", "collection", coll)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved collection stats",
+		Data:    stats,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleGetCollectionStats", "status", http.StatusOK)
+}
+
+// HandleRegisterCollection registers a collection with a display name,
+// description and owner, or replaces what was registered about it.
+func (s *CollectionService) HandleRegisterCollection(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleRegisterCollection called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	if err := s.Policy.CheckRegister(coll); err != nil {
+		slog.Error("Collection policy check failed", "collection", coll, "error", err)
+		utils.Error(w, r, err, policy.Status(err))
+		return
+	}
+
+	var info models.CollectionInfo
+	if err := utils.Read(w, r, &info); err != nil {
+		slog.Error("Failed to read JSON request", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	now := time.Now().UTC()
+	info.Name = coll
+	info.UpdatedAt = now
+	info.CreatedAt = now
+
+	repo := collections.CollectionRepository{DB: s.SystemDB}
+	slog.Debug("CollectionRepository initialized")
+
+	registered, err := repo.Register(&info)
+	if err != nil {
+		slog.Error("Failed to register collection", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Collection registered successfully", "collection", coll)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully registered collection",
+		Data:    registered,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleRegisterCollection", "status", http.StatusOK)
+}
+
+// HandleUnregisterCollection removes a collection from the registry without
+// touching its items.
+func (s *CollectionService) HandleUnregisterCollection(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleUnregisterCollection called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	repo := collections.CollectionRepository{DB: s.SystemDB}
+	slog.Debug("CollectionRepository initialized")
+
+	if err := repo.Unregister(coll); err != nil {
+		slog.Error("Failed to unregister collection", "error", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, collections.ErrCollectionNotRegistered) {
+			status = http.StatusNotFound
+		}
+		utils.Error(w, r, err, status)
+		return
+	}
+	slog.Info("Collection unregistered successfully", "collection", coll)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully unregistered collection",
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleUnregisterCollection", "status", http.StatusOK)
+}
+
+// registry returns the registered collections, or none when there is no
+// system database to keep them in.
+func (s *CollectionService) registry() (map[string]*models.CollectionInfo, error) {
+	if s.SystemDB == nil {
+		return map[string]*models.CollectionInfo{}, nil
+	}
+	repo := collections.CollectionRepository{DB: s.SystemDB}
+	return repo.List()
+}
+
+// info returns what is registered about a collection, or nil if it is not
+// registered.
+func (s *CollectionService) info(coll string) (*models.CollectionInfo, error) {
+	if s.SystemDB == nil {
+		return nil, nil
+	}
+	repo := collections.CollectionRepository{DB: s.SystemDB}
+	info, err := repo.Get(coll)
+	if errors.Is(err, collections.ErrCollectionNotRegistered) {
+		return nil, nil
+	}
+	return info, err
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/policy"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleListCollectionsInvalidFilter(t *testing.T) {
+	service := CollectionService{}
+	r := httptest.NewRequest(http.MethodGet, "/contents?registered=maybe", nil)
+	rr := httptest.NewRecorder()
+	service.HandleListCollections(rr, r)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var response models.JsonResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "invalid registered, expected true or false", response.Message)
+}
+
+func TestHandleRegisterCollectionPolicy(t *testing.T) {
+	service := CollectionService{Policy: policy.New(policy.Config{Reserved: []string{"admin"}}, nil)}
+	router := chi.NewRouter()
+	router.Put("/admin/collections/{collection}", service.HandleRegisterCollection)
+
+	for _, coll := range []string{"system.users", "admin", ".lessons"} {
+		t.Run(coll, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/admin/collections/"+coll, strings.NewReader(`{"display_name":"Lessons"}`))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, r)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var response models.JsonResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.True(t, response.Error)
+		})
+	}
+}
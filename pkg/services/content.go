@@ -1,11 +1,17 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/events"
 	"github.com/YanSystems/cms/pkg/models"
 	repositories "github.com/YanSystems/cms/pkg/repositories/content"
 	"github.com/YanSystems/cms/pkg/utils"
@@ -15,7 +21,13 @@ import (
 )
 
 type ContentService struct {
-	DB *mongo.Database
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+	Events   *events.Bus
+	Cache    *cache.Cache
+	// CacheMaxAge is how long shared caches may serve public content
+	// without revalidating it.
+	CacheMaxAge time.Duration
 }
 
 func (s *ContentService) HandleCreateContent(w http.ResponseWriter, r *http.Request) {
@@ -24,10 +36,10 @@ func (s *ContentService) HandleCreateContent(w http.ResponseWriter, r *http.Requ
 	slog.Debug("Collection parameter extracted", "collection", coll)
 
 	var c models.Content
-	err := utils.ReadJSON(w, r, &c)
+	err := utils.Read(w, r, &c)
 	if err != nil {
 		slog.Error("Failed to read JSON request", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Debug("JSON request body read successfully", "content", c)
@@ -40,30 +52,38 @@ func (s *ContentService) HandleCreateContent(w http.ResponseWriter, r *http.Requ
 	if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
 		err := errors.New("missing fields in request payload")
 		slog.Error("Validation error", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Request payload validated successfully")
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
 	slog.Info("Creating content...")
 	id, err := repo.CreateContent(coll, &c)
 	if err != nil {
 		slog.Error("Failed to create content", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Content created successfully", "id", id)
 
+	s.publish(r, events.Event{
+		Type:       models.EventContentCreated,
+		Collection: coll,
+		ContentId:  id,
+		Class:      c.Class,
+		After:      &c,
+	})
+
 	responsePayload := models.JsonResponse{
 		Error:   false,
 		Message: "Successfully created content",
 		Data:    id,
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, responsePayload)
+	utils.Write(w, r, http.StatusCreated, responsePayload)
 	slog.Info("Response sent for HandleCreateContent", "status", http.StatusCreated)
 }
 
@@ -73,14 +93,14 @@ func (s *ContentService) HandleGetContent(w http.ResponseWriter, r *http.Request
 	id := chi.URLParam(r, "id")
 	slog.Debug("Collection and ID parameters extracted", "collection", coll, "id", id)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
 	slog.Info("Getting content of id " + id)
 	content, err := repo.GetContent(coll, id)
 	if err != nil {
 		slog.Error("Failed to get content", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Content retrieved successfully", "content", content)
@@ -91,23 +111,43 @@ func (s *ContentService) HandleGetContent(w http.ResponseWriter, r *http.Request
 		Data:    content,
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.WriteCached(w, r, responsePayload, utils.CacheOptions{
+		LastModified: content.UpdatedAt,
+		Public:       content.IsPublic,
+		MaxAge:       s.CacheMaxAge,
+	})
 	slog.Info("Response sent for HandleGetContent", "status", http.StatusOK)
 }
 
+// HandleGetCollection lists every item of a collection, or only those whose
+// custom fields match the fields.<name> parameters, in the order asked for
+// with sort_by and order.
 func (s *ContentService) HandleGetCollection(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("HandleGetCollection called")
 	coll := chi.URLParam(r, "collection")
 	slog.Debug("Collection parameter extracted", "collection", coll)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
-	slog.Info("Getting collection...")
-	collection, err := repo.GetCollection(coll)
+	opts, filtered, err := listingOptions(r)
+	if err != nil {
+		slog.Error("Invalid listing options", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	var collection []models.Content
+	if filtered {
+		slog.Info("Listing collection...", "options", opts)
+		collection, _, err = repo.ListContent(coll, opts)
+	} else {
+		slog.Info("Getting collection...")
+		collection, err = repo.GetCollection(coll)
+	}
 	if err != nil {
 		slog.Error("Failed to get collection", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Collection retrieved successfully", "collection", collection)
@@ -118,24 +158,40 @@ func (s *ContentService) HandleGetCollection(w http.ResponseWriter, r *http.Requ
 		Data:    collection,
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.WriteCached(w, r, responsePayload, s.listingCacheOptions(&repo, coll, collection))
 	slog.Info("Response sent for HandleGetCollection", "status", http.StatusOK)
 }
 
+// HandleGetClass lists the items of a class, filtered and ordered like
+// HandleGetCollection.
 func (s *ContentService) HandleGetClass(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("HandleGetClass called")
 	coll := chi.URLParam(r, "collection")
 	class := chi.URLParam(r, "class")
 	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
-	slog.Info("Getting class...")
-	contents, err := repo.GetClass(coll, class)
+	opts, filtered, err := listingOptions(r)
+	if err != nil {
+		slog.Error("Invalid listing options", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	var contents []models.Content
+	if filtered {
+		opts.Class = class
+		slog.Info("Listing class...", "options", opts)
+		contents, _, err = repo.ListContent(coll, opts)
+	} else {
+		slog.Info("Getting class...")
+		contents, err = repo.GetClass(coll, class)
+	}
 	if err != nil {
 		slog.Error("Failed to get class", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Class contents retrieved successfully", "contents", contents)
@@ -146,17 +202,17 @@ func (s *ContentService) HandleGetClass(w http.ResponseWriter, r *http.Request)
 		Data:    contents,
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.WriteCached(w, r, responsePayload, s.listingCacheOptions(&repo, coll, contents))
 	slog.Info("Response sent for HandleGetClass", "status", http.StatusOK)
 }
 
 func (s *ContentService) HandleUpdateContent(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("HandleUpdateContent called")
 	var c models.UpdateContent
-	err := utils.ReadJSON(w, r, &c)
+	err := utils.Read(w, r, &c)
 	if err != nil {
 		slog.Error("Failed to read JSON request", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Debug("JSON request body read successfully", "content", c)
@@ -165,25 +221,46 @@ func (s *ContentService) HandleUpdateContent(w http.ResponseWriter, r *http.Requ
 	id := chi.URLParam(r, "id")
 	slog.Debug("Collection and ID parameters extracted", "collection", coll, "id", id)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
+	before, err := repo.GetContent(coll, id)
+	if err != nil {
+		slog.Error("Failed to get content", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
 	slog.Info("Updating content of id " + id)
 	id, err = repo.UpdateContent(coll, id, &c)
 	if err != nil {
 		slog.Error("Failed to update content", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Content updated successfully", "id", id)
 
+	after, err := repo.GetContent(coll, id)
+	if err != nil {
+		slog.Error("Failed to get updated content", "error", err)
+	} else {
+		s.publish(r, events.Event{
+			Type:       models.EventContentUpdated,
+			Collection: coll,
+			ContentId:  id,
+			Class:      after.Class,
+			Before:     (*models.Content)(before),
+			After:      (*models.Content)(after),
+		})
+	}
+
 	responsePayload := models.JsonResponse{
 		Error:   false,
 		Message: "Successfully updated content",
 		Data:    id,
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.Write(w, r, http.StatusOK, responsePayload)
 	slog.Info("Response sent for HandleUpdateContent", "status", http.StatusOK)
 }
 
@@ -193,24 +270,36 @@ func (s *ContentService) HandleDeleteContent(w http.ResponseWriter, r *http.Requ
 	id := chi.URLParam(r, "id")
 	slog.Debug("Collection and ID parameters extracted", "collection", coll, "id", id)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
 	slog.Info("Deleting content of id " + id)
-	_, err := repo.DeleteContent(coll, id)
+	before, err := repo.RemoveContent(coll, id)
 	if err != nil {
 		slog.Error("Failed to delete content", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Content deleted successfully", "id", id)
 
+	// Deleting an id that does not exist succeeds, but there is nothing to
+	// tell subscribers.
+	if before != nil {
+		s.publish(r, events.Event{
+			Type:       models.EventContentDeleted,
+			Collection: coll,
+			ContentId:  id,
+			Class:      before.Class,
+			Before:     before,
+		})
+	}
+
 	responsePayload := models.JsonResponse{
 		Error:   false,
 		Message: "Successfully deleted content",
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.Write(w, r, http.StatusOK, responsePayload)
 	slog.Info("Response sent for HandleDeleteContent", "status", http.StatusOK)
 }
 
@@ -220,24 +309,31 @@ func (s *ContentService) HandleDeleteClass(w http.ResponseWriter, r *http.Reques
 	class := chi.URLParam(r, "class")
 	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
 	slog.Info("Deleting class...")
-	_, err := repo.DeleteClass(coll, class)
+	ids, err := repo.DeleteClass(coll, class)
 	if err != nil {
 		slog.Error("Failed to delete class", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Class deleted successfully", "class", class)
 
+	s.publish(r, events.Event{
+		Type:       models.EventClassDeleted,
+		Collection: coll,
+		Class:      class,
+		Ids:        ids,
+	})
+
 	responsePayload := models.JsonResponse{
 		Error:   false,
 		Message: "Successfully deleted class",
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.Write(w, r, http.StatusOK, responsePayload)
 	slog.Info("Response sent for HandleDeleteClass", "status", http.StatusOK)
 }
 
@@ -246,23 +342,108 @@ func (s *ContentService) HandleDeleteCollection(w http.ResponseWriter, r *http.R
 	coll := chi.URLParam(r, "collection")
 	slog.Debug("Collection parameter extracted", "collection", coll)
 
-	repo := repositories.ContentRepository{DB: s.DB}
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
 	slog.Debug("ContentRepository initialized")
 
 	slog.Info("Deleting collection...")
-	_, err := repo.DeleteCollection(coll)
+	ids, err := repo.DeleteCollection(coll)
 	if err != nil {
 		slog.Error("Failed to delete collection", "error", err)
-		utils.ErrorJSON(w, err)
+		utils.Error(w, r, err)
 		return
 	}
 	slog.Info("Collection deleted successfully", "collection", coll)
 
+	s.publish(r, events.Event{
+		Type:       models.EventCollectionDeleted,
+		Collection: coll,
+		Ids:        ids,
+	})
+
 	responsePayload := models.JsonResponse{
 		Error:   false,
 		Message: "Successfully deleted collection",
 	}
 
-	utils.WriteJSON(w, http.StatusOK, responsePayload)
+	utils.Write(w, r, http.StatusOK, responsePayload)
 	slog.Info("Response sent for HandleDeleteCollection", "status", http.StatusOK)
 }
+
+func (s *ContentService) publish(r *http.Request, e events.Event) {
+	s.Events.PublishFrom(r, e)
+}
+
+// listingCacheOptions makes a listing public only when every item in it is.
+// Its Last-Modified also covers deletions, which leave no UpdatedAt behind,
+// so it is left out when they cannot be known.
+func (s *ContentService) listingCacheOptions(repo *repositories.ContentRepository, coll string, contents []models.Content) utils.CacheOptions {
+	opts := utils.CacheOptions{Weak: true, Public: true, MaxAge: s.CacheMaxAge}
+	for _, c := range contents {
+		opts.Public = opts.Public && c.IsPublic
+		if c.UpdatedAt.After(opts.LastModified) {
+			opts.LastModified = c.UpdatedAt
+		}
+	}
+
+	changed, err := repo.LastChanged(coll)
+	if err != nil {
+		slog.Debug("Listing sent without Last-Modified", "collection", coll, "error", err)
+		opts.LastModified = time.Time{}
+	} else if changed.After(opts.LastModified) {
+		opts.LastModified = changed
+	}
+	return opts
+}
+
+// listingOptions reads the fields.<name> filters, sort_by and order of a
+// listing. It reports whether any were given, since listings without them
+// are served whole from the cache.
+func listingOptions(r *http.Request) (repositories.ListOptions, bool, error) {
+	var opts repositories.ListOptions
+	filtered := false
+	for param, values := range r.URL.Query() {
+		name, ok := strings.CutPrefix(param, "fields.")
+		if !ok {
+			continue
+		}
+		if len(values) > 1 {
+			return opts, false, fmt.Errorf("%s must be given at most once", param)
+		}
+		if opts.Fields == nil {
+			opts.Fields = map[string]any{}
+		}
+		opts.Fields[name] = fieldValue(values[0])
+		filtered = true
+	}
+
+	if sortBy := r.URL.Query().Get("sort_by"); sortBy != "" {
+		opts.SortBy = sortBy
+		filtered = true
+	}
+	switch order := r.URL.Query().Get("order"); order {
+	case "", "asc":
+	case "desc":
+		opts.Descending = true
+		filtered = true
+	default:
+		return opts, false, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+	return opts, filtered, nil
+}
+
+// fieldValue reads the value of a fields.<name> parameter as an integer, or
+// as any other JSON number, boolean, null or quoted string. Anything else is
+// taken as a string as it is.
+func fieldValue(text string) any {
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n
+	}
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err == nil {
+		switch value.(type) {
+		case nil, bool, float64, string:
+			return value
+		}
+	}
+	return text
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListingOptions(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/contents/courses", nil)
+	_, filtered, err := listingOptions(r)
+	assert.NoError(t, err)
+	assert.False(t, filtered)
+
+	r = httptest.NewRequest(http.MethodGet, `/contents/courses?fields.difficulty=easy&fields.duration=30&fields.code="30"&fields.free=true&fields.rating=4.5&sort_by=fields.duration&order=desc`, nil)
+	opts, filtered, err := listingOptions(r)
+	assert.NoError(t, err)
+	assert.True(t, filtered)
+	assert.Equal(t, map[string]any{
+		"difficulty": "easy",
+		"duration":   int64(30),
+		"code":       "30",
+		"free":       true,
+		"rating":     4.5,
+	}, opts.Fields)
+	assert.Equal(t, "fields.duration", opts.SortBy)
+	assert.True(t, opts.Descending)
+
+	for _, query := range []string{"order=newest", "fields.duration=1&fields.duration=2"} {
+		r = httptest.NewRequest(http.MethodGet, "/contents/courses?"+query, nil)
+		_, _, err := listingOptions(r)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/cache"
+	"github.com/YanSystems/cms/pkg/feeds"
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultFeedPageSize = 20
+
+var errArchiveNotFound = errors.New("feed archive page not found")
+
+// FeedService serves the public content of a collection, or of one class,
+// as Atom and RSS feeds. The current feed holds the most recently updated
+// items. Older items are reached through archive pages of PageSize items
+// each, numbered from the oldest by creation time, as RFC 5005 describes.
+// An archive page only changes when items before it are deleted or made
+// private.
+type FeedService struct {
+	DB          *mongo.Database
+	SystemDB    *mongo.Database
+	Cache       *cache.Cache
+	CacheMaxAge time.Duration
+	// PageSize is the number of items in the current feed and in each
+	// archive page. Changing it renumbers the archives.
+	PageSize int64
+	// BaseURL is the absolute URL the service is reached at, used for the
+	// links in feeds. When it is empty they are built from the request.
+	BaseURL string
+}
+
+func (s *FeedService) HandleGetAtomFeed(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetAtomFeed called")
+	s.serveFeed(w, r, feeds.Atom, feeds.AtomContentType)
+	slog.Info("Response sent for HandleGetAtomFeed")
+}
+
+func (s *FeedService) HandleGetRSSFeed(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetRSSFeed called")
+	s.serveFeed(w, r, feeds.RSS, feeds.RSSContentType)
+	slog.Info("Response sent for HandleGetRSSFeed")
+}
+
+func (s *FeedService) serveFeed(w http.ResponseWriter, r *http.Request, render func(feeds.Feed) ([]byte, error), contentType string) {
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	page := 0
+	if value := r.URL.Query().Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			err := errors.New("invalid page, expected a positive integer")
+			slog.Error("Invalid page", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+		page = n
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	slog.Info("Building feed...", "collection", coll, "class", class, "page", page)
+	feed, err := s.buildFeed(&repo, coll, class, page, utils.BaseURL(r, s.BaseURL)+r.URL.Path)
+	if errors.Is(err, errArchiveNotFound) {
+		slog.Error("Failed to build feed", "error", err)
+		utils.Error(w, r, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to build feed", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	out, err := render(feed)
+	if err != nil {
+		slog.Error("Failed to render feed", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	opts := utils.CacheOptions{Public: true, MaxAge: s.CacheMaxAge, LastModified: feed.Updated}
+	if changed, err := repo.LastChanged(coll); err != nil {
+		// Deletions leave no UpdatedAt behind, so without change tracking
+		// only the ETag can tell that the feed changed.
+		slog.Debug("Feed sent without Last-Modified", "collection", coll, "error", err)
+		opts.LastModified = time.Time{}
+	} else if changed.After(opts.LastModified) {
+		opts.LastModified = changed
+	}
+
+	utils.WriteCachedBytes(w, r, contentType, out, opts)
+}
+
+func (s *FeedService) buildFeed(repo *repositories.ContentRepository, coll string, class string, page int, current string) (feeds.Feed, error) {
+	size := s.PageSize
+	if size <= 0 {
+		size = defaultFeedPageSize
+	}
+	public := true
+	opts := repositories.ListOptions{Class: class, IsPublic: &public, Limit: size}
+
+	feed := feeds.Feed{Title: coll, Self: current, Current: current}
+	if class != "" {
+		feed.Title = coll + " / " + class
+	}
+	archiveURL := func(n int64) string {
+		return fmt.Sprintf("%s?page=%d", current, n)
+	}
+
+	if page == 0 {
+		opts.SortBy, opts.Descending = "updated_at", true
+		newest, total, err := repo.ListContent(coll, opts)
+		if err != nil {
+			return feeds.Feed{}, err
+		}
+
+		archived := total / size
+		feed.Entries = newest
+		if archived > 0 {
+			feed.PrevArchive = archiveURL(archived)
+			if total%size != 0 {
+				// The items created since the last full archive page are in no
+				// archive, so they must be in the current feed even if others
+				// were updated after them.
+				tail, _, err := repo.ListContent(coll, repositories.ListOptions{
+					Class: class, IsPublic: &public, SortBy: "created_at", Offset: archived * size, Limit: size,
+				})
+				if err != nil {
+					return feeds.Feed{}, err
+				}
+				feed.Entries = mergeFeedEntries(newest, tail)
+			}
+		}
+	} else {
+		opts.SortBy, opts.Offset = "created_at", int64(page-1)*size
+		entries, total, err := repo.ListContent(coll, opts)
+		if err != nil {
+			return feeds.Feed{}, err
+		}
+
+		archived := total / size
+		if int64(page) > archived {
+			return feeds.Feed{}, errArchiveNotFound
+		}
+		slices.Reverse(entries)
+		feed.Entries = entries
+		feed.Self = archiveURL(int64(page))
+		feed.Archive = true
+		if page > 1 {
+			feed.PrevArchive = archiveURL(int64(page - 1))
+		}
+		if int64(page) < archived {
+			feed.NextArchive = archiveURL(int64(page + 1))
+		}
+	}
+
+	for _, c := range feed.Entries {
+		if c.UpdatedAt.After(feed.Updated) {
+			feed.Updated = c.UpdatedAt
+		}
+	}
+	return feed, nil
+}
+
+// mergeFeedEntries adds the items of tail missing from newest, most recently
+// updated first.
+func mergeFeedEntries(newest []models.Content, tail []models.Content) []models.Content {
+	entries := slices.Clone(newest)
+	for _, c := range tail {
+		if !slices.ContainsFunc(entries, func(e models.Content) bool { return e.Id == c.Id }) {
+			entries = append(entries, c)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].UpdatedAt.After(entries[j].UpdatedAt) })
+	return entries
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeFeedEntries(t *testing.T) {
+	at := func(minutes int) time.Time {
+		return time.Date(2024, 6, 10, 12, minutes, 0, 0, time.UTC)
+	}
+	newest := []models.Content{{Id: "a", UpdatedAt: at(30)}, {Id: "b", UpdatedAt: at(20)}}
+	tail := []models.Content{{Id: "b", UpdatedAt: at(20)}, {Id: "c", UpdatedAt: at(25)}}
+
+	ids := []string{}
+	for _, c := range mergeFeedEntries(newest, tail) {
+		ids = append(ids, c.Id)
+	}
+	assert.Equal(t, []string{"a", "c", "b"}, ids)
+	assert.Len(t, newest, 2)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/patch"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxPatchBytes = 1048576 // one megabyte
+	// maxPatchAttempts is how many times a patch is applied when the item
+	// keeps changing underneath it before the client gets a 409.
+	maxPatchAttempts = 3
+)
+
+// acceptPatch lists the patch formats HandlePatchContent takes, for the
+// Accept-Patch header.
+var acceptPatch = patch.MergePatchType + ", " + patch.JSONPatchType
+
+var errInvalidPatchResult = errors.New("patched content is not valid")
+
+// HandlePatchContent applies a JSON Merge Patch or a JSON Patch, chosen by
+// the Content-Type, to the JSON form of an item and saves the result if it
+// is valid content. The patch can change any field but id and created_at,
+// and can clear fields that PUT cannot.
+func (s *ContentService) HandlePatchContent(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandlePatchContent called")
+
+	var apply func(doc []byte, p []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case patch.MergePatchType:
+		apply = patch.Merge
+	case patch.JSONPatchType:
+		apply = patch.Apply
+	default:
+		err := fmt.Errorf("%w %q, expected one of %s", utils.ErrUnsupportedMediaType, r.Header.Get("Content-Type"), acceptPatch)
+		slog.Error("Unsupported patch format", "error", err)
+		w.Header().Set("Accept-Patch", acceptPatch)
+		utils.Error(w, r, err, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		slog.Error("Failed to read request body", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Debug("Patch read successfully", "format", mediaType, "bytes", len(body))
+
+	coll := chi.URLParam(r, "collection")
+	id := chi.URLParam(r, "id")
+	slog.Debug("Collection and ID parameters extracted", "collection", coll, "id", id)
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	var before *models.ReadContent
+	var after models.Content
+	for attempt := 1; ; attempt++ {
+		before, err = repo.GetContent(coll, id)
+		if err != nil {
+			slog.Error("Failed to get content", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+
+		after, err = applyContentPatch(before, body, apply)
+		if err != nil {
+			break
+		}
+
+		slog.Info("Patching content of id "+id, "attempt", attempt)
+		err = repo.PatchContent(coll, &after, before.UpdatedAt)
+		if !errors.Is(err, repositories.ErrContentChanged) || attempt == maxPatchAttempts {
+			break
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to patch content", "error", err)
+		utils.Error(w, r, err, patchErrorStatus(err))
+		return
+	}
+	slog.Info("Content patched successfully", "id", id)
+
+	s.publish(r, events.Event{
+		Type:       models.EventContentUpdated,
+		Collection: coll,
+		ContentId:  id,
+		Class:      after.Class,
+		Before:     (*models.Content)(before),
+		After:      &after,
+	})
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully patched content",
+		Data:    (*models.ReadContent)(&after),
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandlePatchContent", "status", http.StatusOK)
+}
+
+// applyContentPatch patches the JSON form of before and decodes the result,
+// which may not change the id or creation time or add unknown fields.
+func applyContentPatch(before *models.ReadContent, body []byte, apply func([]byte, []byte) ([]byte, error)) (models.Content, error) {
+	doc, err := json.Marshal(before)
+	if err != nil {
+		return models.Content{}, err
+	}
+
+	patched, err := apply(doc, body)
+	if err != nil {
+		return models.Content{}, err
+	}
+
+	var after models.Content
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&after); err != nil {
+		return models.Content{}, fmt.Errorf("%w: %s", errInvalidPatchResult, err.Error())
+	}
+	if after.Id != before.Id {
+		return models.Content{}, fmt.Errorf("%w: id cannot be changed", errInvalidPatchResult)
+	}
+	if !after.CreatedAt.Equal(before.CreatedAt) {
+		return models.Content{}, fmt.Errorf("%w: created_at cannot be changed", errInvalidPatchResult)
+	}
+	after.CreatedAt = before.CreatedAt
+	return after, nil
+}
+
+func patchErrorStatus(err error) int {
+	var validationErr *repositories.ValidationError
+	switch {
+	case errors.Is(err, patch.ErrTestFailed), errors.Is(err, repositories.ErrContentChanged):
+		return http.StatusConflict
+	case errors.Is(err, patch.ErrCannotApply), errors.Is(err, errInvalidPatchResult), errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
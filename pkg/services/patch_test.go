@@ -0,0 +1,80 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/patch"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyContentPatch(t *testing.T) {
+	created := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	before := &models.ReadContent{
+		Id:          "6f1c1a52-4fd4-4a7e-9a35-1f2b0f2c3d4e",
+		Class:       "lessons",
+		Title:       "Intro",
+		Description: "First lesson",
+		Views:       3,
+		CreatorId:   "0b7e6c8a-2f42-4f9e-8d55-5a3e1b2c4d6f",
+		UpdatedAt:   created,
+		CreatedAt:   created,
+	}
+
+	t.Run("Merge Patch Clears Fields", func(t *testing.T) {
+		after, err := applyContentPatch(before, []byte(`{"description":null,"is_public":true}`), patch.Merge)
+		assert.NoError(t, err)
+		assert.Empty(t, after.Description)
+		assert.True(t, after.IsPublic)
+		assert.Equal(t, "Intro", after.Title)
+		assert.Equal(t, created, after.CreatedAt)
+	})
+
+	t.Run("JSON Patch", func(t *testing.T) {
+		after, err := applyContentPatch(before, []byte(`[{"op":"test","path":"/views","value":3},{"op":"replace","path":"/views","value":4}]`), patch.Apply)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, after.Views)
+
+		_, err = applyContentPatch(before, []byte(`[{"op":"test","path":"/title","value":"Outro"}]`), patch.Apply)
+		assert.Equal(t, http.StatusConflict, patchErrorStatus(err))
+	})
+
+	t.Run("Invalid Results", func(t *testing.T) {
+		for _, p := range []string{
+			`{"id":"00000000-0000-4000-8000-000000000000"}`,
+			`{"created_at":"2020-01-01T00:00:00Z"}`,
+			`{"colour":"red"}`,
+			`{"views":"many"}`,
+			`[1]`,
+		} {
+			_, err := applyContentPatch(before, []byte(p), patch.Merge)
+			assert.ErrorIs(t, err, errInvalidPatchResult, p)
+			assert.Equal(t, http.StatusUnprocessableEntity, patchErrorStatus(err), p)
+		}
+	})
+
+	t.Run("Error Statuses", func(t *testing.T) {
+		_, err := applyContentPatch(before, []byte(`[{"op":"remove","path":"/missing"}]`), patch.Apply)
+		assert.Equal(t, http.StatusUnprocessableEntity, patchErrorStatus(err))
+		_, err = applyContentPatch(before, []byte(`{"op":"remove"}`), patch.Apply)
+		assert.Equal(t, http.StatusBadRequest, patchErrorStatus(err))
+		assert.Equal(t, http.StatusConflict, patchErrorStatus(repositories.ErrContentChanged))
+		assert.Equal(t, http.StatusUnprocessableEntity, patchErrorStatus(&repositories.ValidationError{Field: "Class"}))
+	})
+}
+
+func TestHandlePatchContentUnsupportedType(t *testing.T) {
+	service := ContentService{}
+	r := httptest.NewRequest(http.MethodPatch, "/contents/courses/id/a", strings.NewReader(`{"title":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	service.HandlePatchContent(rr, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	assert.Equal(t, "application/merge-patch+json, application/json-patch+json", rr.Header().Get("Accept-Patch"))
+}
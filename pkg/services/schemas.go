@@ -0,0 +1,245 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/auth"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/policy"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/repositories/schemas"
+	"github.com/YanSystems/cms/pkg/schema"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SchemaService manages the schemas that the custom fields of the items of
+// a class must match.
+type SchemaService struct {
+	DB       *mongo.Database
+	SystemDB *mongo.Database
+	// Policy rejects schemas for collections that could never have items.
+	// Without it, any name is accepted.
+	Policy *policy.Policy
+}
+
+// HandleGetSchema returns the latest version of the schema of a class, or
+// the one asked for with ?version=.
+func (s *SchemaService) HandleGetSchema(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetSchema called")
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	version, err := parseSchemaVersion(r)
+	if err != nil {
+		slog.Error("Invalid schema version", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	found, err := s.schema(coll, class, version)
+	if err != nil {
+		slog.Error("Failed to get schema", "error", err)
+		utils.Error(w, r, err, schemaStatus(err))
+		return
+	}
+	slog.Info("Schema retrieved successfully", "collection", coll, "class", class, "version", found.Version)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved schema",
+		Data:    found,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleGetSchema", "status", http.StatusOK)
+}
+
+// HandleRegisterSchema adds a version of the schema of a class. Items are
+// checked against it from then on, but the items already stored are not, so
+// they should be checked with the report.
+func (s *SchemaService) HandleRegisterSchema(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleRegisterSchema called")
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	if err := s.Policy.Check(coll, auth.OpRead); err != nil {
+		slog.Error("Collection policy check failed", "collection", coll, "error", err)
+		utils.Error(w, r, err, policy.Status(err))
+		return
+	}
+
+	var doc map[string]any
+	if err := utils.Read(w, r, &doc); err != nil {
+		slog.Error("Failed to read JSON request", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	if _, err := schema.Compile(doc); err != nil {
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Schema compiled successfully")
+
+	repo := schemas.SchemaRepository{DB: s.SystemDB}
+	slog.Debug("SchemaRepository initialized")
+
+	created, err := repo.Create(&models.ContentSchema{
+		Collection: coll,
+		Class:      class,
+		Schema:     doc,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("Failed to register schema", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Schema registered successfully", "collection", coll, "class", class, "version", created.Version)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: fmt.Sprintf("Successfully registered schema version %d", created.Version),
+		Data:    created,
+	}
+
+	utils.Write(w, r, http.StatusCreated, responsePayload)
+	slog.Info("Response sent for HandleRegisterSchema", "status", http.StatusCreated)
+}
+
+// HandleListSchemaVersions lists every version of the schema of a class,
+// oldest first.
+func (s *SchemaService) HandleListSchemaVersions(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListSchemaVersions called")
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	repo := schemas.SchemaRepository{DB: s.SystemDB}
+	slog.Debug("SchemaRepository initialized")
+
+	versions, err := repo.Versions(coll, class)
+	if err != nil {
+		slog.Error("Failed to list schema versions", "error", err)
+		utils.Error(w, r, err, schemaStatus(err))
+		return
+	}
+	slog.Info("Schema versions listed successfully", "count", len(versions))
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully listed schema versions",
+		Data:    versions,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleListSchemaVersions", "status", http.StatusOK)
+}
+
+// HandleDeleteSchema removes every version of the schema of a class. The
+// custom fields of its items are kept but no longer checked.
+func (s *SchemaService) HandleDeleteSchema(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleDeleteSchema called")
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	repo := schemas.SchemaRepository{DB: s.SystemDB}
+	slog.Debug("SchemaRepository initialized")
+
+	if err := repo.Delete(coll, class); err != nil {
+		slog.Error("Failed to delete schema", "error", err)
+		utils.Error(w, r, err, schemaStatus(err))
+		return
+	}
+	slog.Info("Schema deleted successfully", "collection", coll, "class", class)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully deleted schema",
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleDeleteSchema", "status", http.StatusOK)
+}
+
+// HandleGetSchemaReport checks every item of a class against the latest
+// version of its schema, or the one asked for with ?version=, and reports
+// those that do not match.
+func (s *SchemaService) HandleGetSchemaReport(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetSchemaReport called")
+	coll := chi.URLParam(r, "collection")
+	class := chi.URLParam(r, "class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	version, err := parseSchemaVersion(r)
+	if err != nil {
+		slog.Error("Invalid schema version", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	found, err := s.schema(coll, class, version)
+	if err != nil {
+		slog.Error("Failed to get schema", "error", err)
+		utils.Error(w, r, err, schemaStatus(err))
+		return
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB}
+	slog.Debug("ContentRepository initialized")
+
+	report, err := repo.CheckSchema(r.Context(), coll, class, found)
+	if err != nil {
+		slog.Error("Failed to check schema", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully checked schema",
+		Data:    report,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleGetSchemaReport", "status", http.StatusOK)
+}
+
+// schema returns one version of the schema of a class, or the latest when
+// version is 0.
+func (s *SchemaService) schema(coll string, class string, version int) (*models.ContentSchema, error) {
+	repo := schemas.SchemaRepository{DB: s.SystemDB}
+	if version == 0 {
+		return repo.Latest(coll, class)
+	}
+	return repo.Get(coll, class, version)
+}
+
+func parseSchemaVersion(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("version")
+	if value == "" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version, expected a positive integer")
+	}
+	return version, nil
+}
+
+func schemaStatus(err error) int {
+	if errors.Is(err, schemas.ErrSchemaNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
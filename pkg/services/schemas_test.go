@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/policy"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleRegisterSchemaInvalid(t *testing.T) {
+	service := SchemaService{Policy: policy.New(policy.Config{}, nil)}
+	router := chi.NewRouter()
+	router.Put("/admin/schemas/{collection}/{class}", service.HandleRegisterSchema)
+
+	tests := []struct {
+		name string
+		coll string
+		body string
+	}{
+		{"Reserved Collection", "system.users", `{"type": "object"}`},
+		{"Not An Object", "lessons", `["type"]`},
+		{"Root Not Object", "lessons", `{"type": "string"}`},
+		{"Unsupported Keyword", "lessons", `{"properties": {"next": {"$ref": "#"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/admin/schemas/"+tt.coll+"/lesson", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, r)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var response models.JsonResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.True(t, response.Error)
+		})
+	}
+}
+
+func TestHandleGetSchemaInvalidVersion(t *testing.T) {
+	service := SchemaService{}
+	router := chi.NewRouter()
+	router.Get("/contents/{collection}/class/{class}/schema", service.HandleGetSchema)
+	router.Get("/admin/schemas/{collection}/{class}/report", service.HandleGetSchemaReport)
+
+	for _, path := range []string{"/contents/lessons/class/lesson/schema", "/admin/schemas/lessons/lesson/report"} {
+		t.Run(path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, path+"?version=0", nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, r)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var response models.JsonResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "invalid version, expected a positive integer", response.Message)
+		})
+	}
+}
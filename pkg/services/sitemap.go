@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/sitemap"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type SitemapService struct {
+	Sitemap     *sitemap.Generator
+	CacheMaxAge time.Duration
+	// BaseURL is the absolute URL the service is reached at, used for the
+	// links in the sitemap index. When it is empty they are built from the
+	// request.
+	BaseURL string
+}
+
+func (s *SitemapService) HandleGetSitemapIndex(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetSitemapIndex called")
+
+	slog.Info("Generating sitemap index...")
+	out, err := s.Sitemap.Index(r.Context(), utils.BaseURL(r, s.BaseURL))
+	if err != nil {
+		slog.Error("Failed to generate sitemap index", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteCachedBytes(w, r, sitemap.ContentType, out, utils.CacheOptions{Public: true, MaxAge: s.CacheMaxAge})
+	slog.Info("Response sent for HandleGetSitemapIndex")
+}
+
+func (s *SitemapService) HandleGetSitemap(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetSitemap called")
+	coll := chi.URLParam(r, "collection")
+	chunk := chi.URLParam(r, "chunk")
+	slog.Debug("Collection and chunk parameters extracted", "collection", coll, "chunk", chunk)
+
+	n, err := strconv.Atoi(chunk)
+	if err != nil {
+		n = 0
+	}
+
+	slog.Info("Generating sitemap...")
+	out, err := s.Sitemap.Chunk(r.Context(), coll, n)
+	if errors.Is(err, sitemap.ErrNotFound) {
+		slog.Error("Failed to generate sitemap", "error", err)
+		utils.Error(w, r, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to generate sitemap", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteCachedBytes(w, r, sitemap.ContentType, out, utils.CacheOptions{Public: true, MaxAge: s.CacheMaxAge})
+	slog.Info("Response sent for HandleGetSitemap")
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type StreamService struct {
+	Broker *events.Broker
+}
+
+func (s *StreamService) HandleStreamEvents(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleStreamEvents called")
+	coll := chi.URLParam(r, "collection")
+	class := r.URL.Query().Get("class")
+	slog.Debug("Collection and Class parameters extracted", "collection", coll, "class", class)
+
+	if s.Broker == nil {
+		err := errors.New("event streaming is not available")
+		slog.Error("Event broker not configured", "error", err)
+		utils.Error(w, r, err, http.StatusServiceUnavailable)
+		return
+	}
+
+	// EventSource only sends Last-Event-ID on reconnects, so clients can also
+	// resume explicitly through the query string.
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	sub, backlog, resumed := s.Broker.Subscribe(coll, class, lastEventID)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	slog.Info("Event stream opened", "collection", coll, "class", class, "lastEventID", lastEventID)
+
+	if !resumed {
+		// The client asked to resume from an event we no longer have, so it
+		// has to reload the collection to be sure it hasn't missed anything.
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, se := range backlog {
+		if err := writeStreamEvent(w, &se); err != nil {
+			return
+		}
+	}
+	rc.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			slog.Info("Event stream closed by client", "collection", coll)
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			rc.Flush()
+		case se, ok := <-sub.C:
+			if !ok {
+				slog.Info("Event stream closed by broker", "collection", coll)
+				return
+			}
+			if err := writeStreamEvent(w, &se); err != nil {
+				slog.Error("Failed to write stream event", "error", err)
+				return
+			}
+			rc.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, se *events.StreamEvent) error {
+	data, err := json.Marshal(se)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", se.Id, se.Type, data)
+	return err
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleStreamEvents(t *testing.T) {
+	broker := events.NewBroker(10)
+	service := StreamService{Broker: broker}
+
+	router := chi.NewRouter()
+	router.Get("/contents/{collection}/events", service.HandleStreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	broker.Handle(events.Event{Type: models.EventContentCreated, Collection: "courses", ContentId: "missed"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/contents/courses/events", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() []string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return lines
+			}
+			line = strings.TrimRight(line, "\n")
+			if line == "" {
+				return lines
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	t.Run("Unknown Last Event ID Resets", func(t *testing.T) {
+		assert.Equal(t, "event: reset", readEvent()[0])
+	})
+
+	t.Run("Streams New Events", func(t *testing.T) {
+		broker.Handle(events.Event{Type: models.EventContentDeleted, Collection: "courses", ContentId: "a"})
+
+		lines := readEvent()
+		assert.Equal(t, "id: 2", lines[0])
+		assert.Equal(t, "event: content.deleted", lines[1])
+		assert.Contains(t, lines[2], `"content_id":"a"`)
+	})
+
+	t.Run("Unavailable Without Broker", func(t *testing.T) {
+		service := StreamService{}
+		rr := httptest.NewRecorder()
+		service.HandleStreamEvents(rr, httptest.NewRequest("GET", "/contents/courses/events", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	syncTokenVersion     = "v1"
+	snapshotTokenVersion = "s1"
+	defaultSyncLimit     = 500
+	maxSyncLimit         = 1000
+)
+
+func (s *ContentService) HandleGetChanges(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetChanges called")
+	coll := chi.URLParam(r, "collection")
+	since := r.URL.Query().Get("since")
+	slog.Debug("Collection and since parameters extracted", "collection", coll, "since", since)
+
+	limit := int64(defaultSyncLimit)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			err := errors.New("invalid limit, expected a positive integer")
+			slog.Error("Invalid limit", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+		limit = min(n, maxSyncLimit)
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	var seq int64
+	var after string
+	var err error
+	if since == "" {
+		seq, err = repo.CurrentSequence(coll)
+		if err != nil {
+			slog.Error("Failed to read change sequence", "error", err)
+			utils.Error(w, r, err, http.StatusServiceUnavailable)
+			return
+		}
+	} else {
+		seq, after, err = decodeSyncToken(coll, since)
+		if err != nil {
+			slog.Error("Invalid sync token", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+	}
+
+	var response models.SyncResponse
+	if since == "" || after != "" {
+		// Without a token the client gets a snapshot of the whole collection,
+		// a page at a time. Every page carries the sequence read before the
+		// first, so that nothing written meanwhile is lost once it is done.
+		contents, hasMore, err := repo.GetSnapshot(coll, after, limit)
+		if err != nil {
+			slog.Error("Failed to get snapshot", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+
+		response.Changes = make([]models.SyncChange, len(contents))
+		for i := range contents {
+			response.Changes[i] = models.SyncChange{Id: contents[i].Id, Content: &contents[i]}
+		}
+		response.NextToken = encodeSyncToken(coll, seq)
+		if hasMore {
+			response.NextToken = encodeSnapshotToken(coll, seq, contents[len(contents)-1].Id)
+		}
+		response.HasMore = hasMore
+	} else {
+		changes, next, hasMore, err := repo.GetChanges(coll, seq, limit)
+		if err != nil {
+			slog.Error("Failed to get changes", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+
+		response.Changes = changes
+		response.NextToken = encodeSyncToken(coll, next)
+		response.HasMore = hasMore
+	}
+	slog.Info("Changes retrieved successfully", "collection", coll, "count", len(response.Changes))
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved changes",
+		Data:    response,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleGetChanges", "status", http.StatusOK)
+}
+
+func encodeSyncToken(coll string, seq int64) string {
+	raw := fmt.Sprintf("%s:%s:%d", syncTokenVersion, coll, seq)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// encodeSnapshotToken continues a snapshot after the item with id after. The
+// id is encoded on its own so that it may contain colons.
+func encodeSnapshotToken(coll string, seq int64, after string) string {
+	raw := fmt.Sprintf("%s:%s:%d:%s", snapshotTokenVersion, coll, seq, base64.RawURLEncoding.EncodeToString([]byte(after)))
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeSyncToken returns the sequence a token continues from and, for a
+// snapshot token, the id of the last item of the snapshot so far.
+func decodeSyncToken(coll string, token string) (int64, string, error) {
+	invalid := errors.New("invalid sync token")
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, "", invalid
+	}
+
+	version, rest, found := strings.Cut(string(raw), ":")
+	if !found || (version != syncTokenVersion && version != snapshotTokenVersion) {
+		return 0, "", invalid
+	}
+
+	var after string
+	if version == snapshotTokenVersion {
+		i := strings.LastIndex(rest, ":")
+		if i < 0 {
+			return 0, "", invalid
+		}
+		id, err := base64.RawURLEncoding.DecodeString(rest[i+1:])
+		if err != nil || len(id) == 0 {
+			return 0, "", invalid
+		}
+		after, rest = string(id), rest[:i]
+	}
+
+	i := strings.LastIndex(rest, ":")
+	if i < 0 {
+		return 0, "", invalid
+	}
+	if rest[:i] != coll {
+		return 0, "", errors.New("sync token belongs to a different collection")
+	}
+
+	seq, err := strconv.ParseInt(rest[i+1:], 10, 64)
+	if err != nil || seq < 0 {
+		return 0, "", invalid
+	}
+	return seq, after, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncToken(t *testing.T) {
+	t.Run("Round Trip", func(t *testing.T) {
+		seq, after, err := decodeSyncToken("lessons:2024", encodeSyncToken("lessons:2024", 42))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), seq)
+		assert.Empty(t, after)
+	})
+
+	t.Run("Snapshot Round Trip", func(t *testing.T) {
+		seq, after, err := decodeSyncToken("lessons:2024", encodeSnapshotToken("lessons:2024", 42, "intro:1"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), seq)
+		assert.Equal(t, "intro:1", after)
+
+		_, _, err = decodeSyncToken("quizzes", encodeSnapshotToken("lessons", 42, "intro"))
+		assert.EqualError(t, err, "sync token belongs to a different collection")
+	})
+
+	t.Run("Different Collection", func(t *testing.T) {
+		_, _, err := decodeSyncToken("quizzes", encodeSyncToken("lessons", 42))
+		assert.EqualError(t, err, "sync token belongs to a different collection")
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, token := range []string{"not base64!", "djI6bGVzc29uczox", "djE6bGVzc29ucw", "djE6bGVzc29uczp4", "czE6bGVzc29uczoxOg", "czE6bGVzc29uczoxOiEh"} {
+			_, _, err := decodeSyncToken("lessons", token)
+			assert.EqualError(t, err, "invalid sync token", token)
+		}
+	})
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	repositories "github.com/YanSystems/cms/pkg/repositories/content"
+	"github.com/YanSystems/cms/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	maxImportBytes     = 32 << 20 // 32 megabytes, both as sent and decompressed
+	maxImportLineBytes = 1 << 20  // one megabyte, the same as a single create
+	maxImportItems     = 100_000
+	maxImportErrors    = 1000
+)
+
+var errTooManyImportItems = fmt.Errorf("import must not have more than %d items", maxImportItems)
+
+const (
+	ImportPreserveIds   = "preserve"
+	ImportRegenerateIds = "regenerate"
+
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+)
+
+type importOptions struct {
+	Ids        string
+	OnConflict string
+	DryRun     bool
+}
+
+type importItem struct {
+	Line    int
+	Content models.Content
+}
+
+func (s *ContentService) HandleExportCollection(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleExportCollection called")
+	coll := chi.URLParam(r, "collection")
+	class := r.URL.Query().Get("class")
+	slog.Debug("Collection and class parameters extracted", "collection", coll, "class", class)
+
+	compress := false
+	if value := r.URL.Query().Get("gzip"); value != "" {
+		var err error
+		if compress, err = strconv.ParseBool(value); err != nil {
+			err := errors.New("invalid gzip, expected true or false")
+			slog.Error("Invalid gzip parameter", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+	}
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	filename := fmt.Sprintf("%s-%s.ndjson", coll, time.Now().UTC().Format("20060102T150405Z"))
+	var out io.Writer = w
+	if compress {
+		filename += ".gz"
+		w.Header().Set("Content-Type", "application/gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		out = zw
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	encoder := json.NewEncoder(out)
+	err := repo.EachContent(r.Context(), coll, class, func(content *models.Content) error {
+		return encoder.Encode(content)
+	})
+	if err != nil {
+		// The status line has already been sent, so all we can do is log.
+		slog.Error("Failed to export collection", "collection", coll, "error", err)
+		return
+	}
+
+	slog.Info("Response sent for HandleExportCollection", "status", http.StatusOK, "gzip", compress)
+}
+
+func (s *ContentService) HandleImportCollection(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleImportCollection called")
+	coll := chi.URLParam(r, "collection")
+	slog.Debug("Collection parameter extracted", "collection", coll)
+
+	opts, err := parseImportOptions(r)
+	if err != nil {
+		slog.Error("Invalid import options", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Debug("Import options parsed", "options", opts)
+
+	body, err := importReader(w, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		slog.Error("Failed to open import body", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	report := models.ImportReport{DryRun: opts.DryRun, Errors: []models.ImportError{}}
+	items, err := parseImport(body, opts, &report)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err := fmt.Errorf("import must not be larger than %d bytes", maxImportBytes)
+			slog.Error("Import body too large", "error", err)
+			utils.Error(w, r, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, errTooManyImportItems) {
+			slog.Error("Import has too many items", "error", err)
+			utils.Error(w, r, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+		slog.Error("Failed to read import body", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Import parsed", "collection", coll, "lines", report.Lines, "valid", len(items), "invalid", report.Failed)
+
+	repo := repositories.ContentRepository{DB: s.DB, SystemDB: s.SystemDB, Cache: s.Cache}
+	slog.Debug("ContentRepository initialized")
+
+	ids := make([]string, len(items))
+	for i := range items {
+		ids[i] = items[i].Content.Id
+	}
+	existing, err := repo.ExistingIds(coll, ids)
+	if err != nil {
+		slog.Error("Failed to look up existing content", "error", err)
+		utils.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if opts.OnConflict == ConflictFail {
+		conflicts := 0
+		for i := range items {
+			if existing[items[i].Content.Id] {
+				conflicts++
+				failImport(&report, &items[i], repositories.ErrContentExists)
+			}
+		}
+		if conflicts > 0 {
+			slog.Error("Import aborted on conflicts", "collection", coll, "conflicts", conflicts)
+			utils.Write(w, r, http.StatusConflict, models.JsonResponse{
+				Error:   true,
+				Message: fmt.Sprintf("import aborted, %d items already exist", conflicts),
+				Data:    report,
+			})
+			return
+		}
+	}
+
+	// Writes check custom fields against the schemas of their classes
+	// themselves, so only a dry run checks them here
+	validateFields := repo.FieldValidator(coll)
+	for i := range items {
+		item := &items[i]
+		if !existing[item.Content.Id] {
+			if !opts.DryRun {
+				if _, err := repo.CreateContent(coll, &item.Content); err != nil {
+					failImport(&report, item, err)
+					continue
+				}
+				s.publish(r, events.Event{
+					Type:       models.EventContentCreated,
+					Collection: coll,
+					ContentId:  item.Content.Id,
+					Class:      item.Content.Class,
+					After:      &item.Content,
+				})
+			} else if err := validateFields(&item.Content); err != nil {
+				failImport(&report, item, err)
+				continue
+			}
+			report.Created++
+			continue
+		}
+
+		if opts.OnConflict == ConflictSkip {
+			report.Skipped++
+			continue
+		}
+
+		if !opts.DryRun {
+			before, err := repo.GetContent(coll, item.Content.Id)
+			if err != nil {
+				failImport(&report, item, err)
+				continue
+			}
+			if err := repo.ReplaceContent(coll, &item.Content); err != nil {
+				failImport(&report, item, err)
+				continue
+			}
+			s.publish(r, events.Event{
+				Type:       models.EventContentUpdated,
+				Collection: coll,
+				ContentId:  item.Content.Id,
+				Class:      item.Content.Class,
+				Before:     (*models.Content)(before),
+				After:      &item.Content,
+			})
+		} else if err := validateFields(&item.Content); err != nil {
+			failImport(&report, item, err)
+			continue
+		}
+		report.Replaced++
+	}
+	slog.Info("Import completed", "collection", coll, "dry_run", opts.DryRun, "created", report.Created,
+		"replaced", report.Replaced, "skipped", report.Skipped, "failed", report.Failed)
+
+	message := "Successfully imported content"
+	if opts.DryRun {
+		message = "Successfully checked import"
+	}
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: message,
+		Data:    report,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleImportCollection", "status", http.StatusOK)
+}
+
+func parseImportOptions(r *http.Request) (*importOptions, error) {
+	query := r.URL.Query()
+	opts := importOptions{Ids: ImportPreserveIds, OnConflict: ConflictFail}
+
+	if value := query.Get("ids"); value != "" {
+		if value != ImportPreserveIds && value != ImportRegenerateIds {
+			return nil, fmt.Errorf("invalid ids %q, expected preserve or regenerate", value)
+		}
+		opts.Ids = value
+	}
+
+	if value := query.Get("on_conflict"); value != "" {
+		if value != ConflictSkip && value != ConflictOverwrite && value != ConflictFail {
+			return nil, fmt.Errorf("invalid on_conflict %q, expected skip, overwrite or fail", value)
+		}
+		opts.OnConflict = value
+	}
+
+	if value := query.Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("invalid dry_run, expected true or false")
+		}
+		opts.DryRun = dryRun
+	}
+
+	return &opts, nil
+}
+
+// importReader returns body, decompressed when it starts with the gzip magic
+// number, so an export can be sent back as it was downloaded. The
+// decompressed body is held to maxImportBytes as well, so that a small gzip
+// body cannot expand without bound.
+func importReader(w http.ResponseWriter, body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	magic, _ := buffered.Peek(2)
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %s", err.Error())
+		}
+		return http.MaxBytesReader(w, zr, maxImportBytes), nil
+	}
+	return buffered, nil
+}
+
+// parseImport reads one item per line and returns those that are valid.
+// Lines that are not are counted as failed in report with the reason. Only a
+// body that cannot be read at all, or that has more than maxImportItems
+// items, is an error.
+func parseImport(body io.Reader, opts *importOptions, report *models.ImportReport) ([]importItem, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineBytes)
+
+	items := []importItem{}
+	seen := map[string]int{}
+	now := time.Now().UTC()
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		report.Lines++
+		if report.Lines > maxImportItems {
+			return nil, errTooManyImportItems
+		}
+
+		item := importItem{Line: line}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&item.Content); err != nil {
+			failImport(report, &item, fmt.Errorf("invalid JSON: %s", err.Error()))
+			continue
+		}
+
+		c := &item.Content
+		if opts.Ids == ImportRegenerateIds || c.Id == "" {
+			c.Id = uuid.New().String()
+		}
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = now
+		}
+		if c.UpdatedAt.IsZero() {
+			c.UpdatedAt = c.CreatedAt
+		}
+
+		if c.Class == "" || c.Title == "" || c.Description == "" || c.Body == "" || c.Views < 0 || c.CreatorId == "" {
+			failImport(report, &item, errors.New("missing fields in request payload"))
+			continue
+		}
+		if err := repositories.ValidateContent(c); err != nil {
+			failImport(report, &item, err)
+			continue
+		}
+		if first, ok := seen[c.Id]; ok {
+			failImport(report, &item, fmt.Errorf("duplicate id, first used on line %d", first))
+			continue
+		}
+		seen[c.Id] = line
+
+		items = append(items, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("line %d is longer than %d bytes", line+1, maxImportLineBytes)
+		}
+		return nil, err
+	}
+	return items, nil
+}
+
+func failImport(report *models.ImportReport, item *importItem, err error) {
+	slog.Debug("Import line failed", "line", item.Line, "error", err)
+	report.Failed++
+	if len(report.Errors) == maxImportErrors {
+		report.Truncated = true
+		return
+	}
+	report.Errors = append(report.Errors, models.ImportError{
+		Line:    item.Line,
+		Id:      item.Content.Id,
+		Message: err.Error(),
+	})
+}
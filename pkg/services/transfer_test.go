@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportOptions(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		opts, err := parseImportOptions(httptest.NewRequest("POST", "/contents/courses/import", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, importOptions{Ids: ImportPreserveIds, OnConflict: ConflictFail}, *opts)
+	})
+
+	t.Run("All Options", func(t *testing.T) {
+		opts, err := parseImportOptions(httptest.NewRequest("POST", "/contents/courses/import?ids=regenerate&on_conflict=skip&dry_run=true", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, importOptions{Ids: ImportRegenerateIds, OnConflict: ConflictSkip, DryRun: true}, *opts)
+	})
+
+	t.Run("Invalid Options", func(t *testing.T) {
+		for _, query := range []string{"ids=keep", "on_conflict=merge", "dry_run=maybe"} {
+			_, err := parseImportOptions(httptest.NewRequest("POST", "/contents/courses/import?"+query, nil))
+			assert.Error(t, err, query)
+		}
+	})
+}
+
+func TestParseImport(t *testing.T) {
+	id := uuid.New().String()
+	creator := uuid.New().String()
+	valid := `{"id":"` + id + `","class":"lessons","title":"Intro","description":"D","body":"B","creator_id":"` + creator + `","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-02T00:00:00Z"}`
+	noId := `{"class":"lessons","title":"Quiz","description":"D","body":"B","creator_id":"` + creator + `"}`
+
+	body := strings.Join([]string{
+		valid,
+		"",
+		noId,
+		`{"class":"lessons"`,
+		`{"class":"lessons","title":"Intro","description":"D","body":"B","creator_id":"not-a-uuid"}`,
+		`{"class":"lessons","title":"Intro","description":"D","body":"B","creator_id":"` + creator + `","colour":"red"}`,
+		`{"class":"lessons","title":"Intro"}`,
+		valid,
+	}, "\n")
+
+	t.Run("Preserve Ids", func(t *testing.T) {
+		var report models.ImportReport
+		items, err := parseImport(strings.NewReader(body), &importOptions{Ids: ImportPreserveIds}, &report)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 7, report.Lines)
+		assert.Len(t, items, 2)
+		assert.Equal(t, id, items[0].Content.Id)
+		assert.Equal(t, 1, items[0].Line)
+		assert.Equal(t, 2024, items[0].Content.CreatedAt.Year())
+		assert.Equal(t, 3, items[1].Line)
+		assert.NoError(t, uuid.Validate(items[1].Content.Id))
+		assert.False(t, items[1].Content.CreatedAt.IsZero())
+
+		assert.Equal(t, 5, report.Failed)
+		lines := []int{}
+		for _, e := range report.Errors {
+			lines = append(lines, e.Line)
+		}
+		assert.Equal(t, []int{4, 5, 6, 7, 8}, lines)
+		assert.Contains(t, report.Errors[2].Message, "unknown field")
+		assert.Equal(t, "duplicate id, first used on line 1", report.Errors[4].Message)
+	})
+
+	t.Run("Regenerate Ids", func(t *testing.T) {
+		var report models.ImportReport
+		items, err := parseImport(strings.NewReader(valid+"\n"+valid), &importOptions{Ids: ImportRegenerateIds}, &report)
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.NotEqual(t, id, items[0].Content.Id)
+		assert.NotEqual(t, items[0].Content.Id, items[1].Content.Id)
+		assert.Zero(t, report.Failed)
+	})
+
+	t.Run("Line Too Long", func(t *testing.T) {
+		var report models.ImportReport
+		_, err := parseImport(strings.NewReader(strings.Repeat("x", maxImportLineBytes+1)), &importOptions{}, &report)
+		assert.Error(t, err)
+	})
+
+	t.Run("Errors Truncated", func(t *testing.T) {
+		var report models.ImportReport
+		_, err := parseImport(strings.NewReader(strings.Repeat("x\n", maxImportErrors+1)), &importOptions{}, &report)
+		assert.NoError(t, err)
+		assert.Equal(t, maxImportErrors+1, report.Failed)
+		assert.Len(t, report.Errors, maxImportErrors)
+		assert.True(t, report.Truncated)
+	})
+
+	t.Run("Too Many Items", func(t *testing.T) {
+		var report models.ImportReport
+		_, err := parseImport(strings.NewReader(strings.Repeat("x\n", maxImportItems+1)), &importOptions{}, &report)
+		assert.ErrorIs(t, err, errTooManyImportItems)
+	})
+}
+
+func TestImportReader(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("{}\n"))
+	zw.Close()
+
+	for name, body := range map[string]io.Reader{
+		"Plain": strings.NewReader("{}\n"),
+		"Gzip":  &compressed,
+	} {
+		t.Run(name, func(t *testing.T) {
+			reader, err := importReader(httptest.NewRecorder(), body)
+			assert.NoError(t, err)
+			data, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, "{}\n", string(data))
+		})
+	}
+
+	t.Run("Decompressed Too Large", func(t *testing.T) {
+		var bomb bytes.Buffer
+		zw := gzip.NewWriter(&bomb)
+		zw.Write(make([]byte, maxImportBytes+1))
+		zw.Close()
+
+		reader, err := importReader(httptest.NewRecorder(), &bomb)
+		assert.NoError(t, err)
+		_, err = io.Copy(io.Discard, reader)
+		var tooLarge *http.MaxBytesError
+		assert.ErrorAs(t, err, &tooLarge)
+	})
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/repositories/webhooks"
+	"github.com/YanSystems/cms/pkg/utils"
+	dispatch "github.com/YanSystems/cms/pkg/webhooks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const deliveryListLimit = 100
+
+type WebhookService struct {
+	DB         *mongo.Database
+	Dispatcher *dispatch.Dispatcher
+}
+
+func (s *WebhookService) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleCreateWebhook called")
+
+	var req models.CreateWebhook
+	err := utils.Read(w, r, &req)
+	if err != nil {
+		slog.Error("Failed to read JSON request", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	if req.Secret == "" {
+		err := errors.New("missing fields in request payload")
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Request payload validated successfully")
+
+	now := time.Now().UTC()
+	webhook := models.Webhook{
+		Id:         uuid.New().String(),
+		URL:        req.URL,
+		Secret:     req.Secret,
+		Events:     req.Events,
+		Collection: req.Collection,
+		Class:      req.Class,
+		Active:     true,
+		UpdatedAt:  now,
+		CreatedAt:  now,
+	}
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	id, err := repo.CreateWebhook(&webhook)
+	if err != nil {
+		slog.Error("Failed to create webhook", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Webhook created successfully", "id", id)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully created webhook",
+		Data:    webhook,
+	}
+
+	utils.Write(w, r, http.StatusCreated, responsePayload)
+	slog.Info("Response sent for HandleCreateWebhook", "status", http.StatusCreated)
+}
+
+func (s *WebhookService) HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListWebhooks called")
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	list, err := repo.ListWebhooks()
+	if err != nil {
+		slog.Error("Failed to list webhooks", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved webhooks",
+		Data:    list,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleListWebhooks", "status", http.StatusOK)
+}
+
+func (s *WebhookService) HandleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleGetWebhook called")
+	id := chi.URLParam(r, "id")
+	slog.Debug("ID parameter extracted", "id", id)
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	webhook, err := repo.GetWebhook(id)
+	if err != nil {
+		slog.Error("Failed to get webhook", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved webhook",
+		Data:    webhook,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleGetWebhook", "status", http.StatusOK)
+}
+
+func (s *WebhookService) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleUpdateWebhook called")
+	var req models.UpdateWebhook
+	err := utils.Read(w, r, &req)
+	if err != nil {
+		slog.Error("Failed to read JSON request", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	slog.Debug("ID parameter extracted", "id", id)
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			slog.Error("Validation error", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(*req.Events); err != nil {
+			slog.Error("Validation error", "error", err)
+			utils.Error(w, r, err)
+			return
+		}
+	}
+	if req.Secret != nil && *req.Secret == "" {
+		err := errors.New("secret cannot be empty")
+		slog.Error("Validation error", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	_, err = repo.UpdateWebhook(id, &req)
+	if err != nil {
+		slog.Error("Failed to update webhook", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Webhook updated successfully", "id", id)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully updated webhook",
+		Data:    id,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleUpdateWebhook", "status", http.StatusOK)
+}
+
+func (s *WebhookService) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleDeleteWebhook called")
+	id := chi.URLParam(r, "id")
+	slog.Debug("ID parameter extracted", "id", id)
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	_, err := repo.DeleteWebhook(id)
+	if err != nil {
+		slog.Error("Failed to delete webhook", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Webhook deleted successfully", "id", id)
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully deleted webhook",
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleDeleteWebhook", "status", http.StatusOK)
+}
+
+func (s *WebhookService) HandleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleListDeliveries called")
+	id := chi.URLParam(r, "id")
+	slog.Debug("ID parameter extracted", "id", id)
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	deliveries, err := repo.ListDeliveries(id, deliveryListLimit)
+	if err != nil {
+		slog.Error("Failed to list deliveries", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully retrieved deliveries",
+		Data:    deliveries,
+	}
+
+	utils.Write(w, r, http.StatusOK, responsePayload)
+	slog.Info("Response sent for HandleListDeliveries", "status", http.StatusOK)
+}
+
+func (s *WebhookService) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("HandleRedeliver called")
+	id := chi.URLParam(r, "id")
+	deliveryId := chi.URLParam(r, "delivery")
+	slog.Debug("ID parameters extracted", "id", id, "delivery", deliveryId)
+
+	repo := webhooks.WebhookRepository{DB: s.DB}
+	slog.Debug("WebhookRepository initialized")
+
+	original, err := repo.GetDelivery(id, deliveryId)
+	if err != nil {
+		slog.Error("Failed to get delivery", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery := models.WebhookDelivery{
+		Id:            uuid.New().String(),
+		WebhookId:     original.WebhookId,
+		EventId:       original.EventId,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		RedeliveryOf:  original.Id,
+		NextAttemptAt: &now,
+		UpdatedAt:     now,
+		CreatedAt:     now,
+	}
+
+	_, err = repo.CreateDelivery(&delivery)
+	if err != nil {
+		slog.Error("Failed to queue redelivery", "error", err)
+		utils.Error(w, r, err)
+		return
+	}
+	slog.Info("Redelivery queued", "webhook", id, "delivery", delivery.Id, "original", original.Id)
+
+	if s.Dispatcher != nil {
+		s.Dispatcher.Wake()
+	}
+
+	responsePayload := models.JsonResponse{
+		Error:   false,
+		Message: "Successfully queued redelivery",
+		Data:    delivery.Id,
+	}
+
+	utils.Write(w, r, http.StatusAccepted, responsePayload)
+	slog.Info("Response sent for HandleRedeliver", "status", http.StatusAccepted)
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("webhook url must be an absolute http or https url")
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return errors.New("webhook must subscribe to at least one event")
+	}
+	for _, event := range events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sitemap
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MaxChunkSize is the most URLs the sitemap protocol allows in one file.
+const MaxChunkSize = 50000
+
+type Config struct {
+	// URLs maps each collection in the sitemap to the template of the public
+	// URL of its items, in which {collection}, {class} and {id} are
+	// replaced. Collections without a template are left out.
+	URLs map[string]string
+	// ChunkSize is the most URLs in one sitemap. Larger collections are
+	// split into several.
+	ChunkSize int
+}
+
+var DefaultConfig = Config{
+	ChunkSize: MaxChunkSize,
+}
+
+// ConfigFromEnv reads YAN_CMS_SITEMAP_URLS and YAN_CMS_SITEMAP_CHUNK_SIZE.
+// The first is a comma-separated list of <collection>=<url template>.
+func ConfigFromEnv() (Config, error) {
+	slog.Debug("Loading sitemap environment variables...")
+	cfg := DefaultConfig
+
+	if value := os.Getenv("YAN_CMS_SITEMAP_URLS"); value != "" {
+		cfg.URLs = map[string]string{}
+		for _, rule := range strings.Split(value, ",") {
+			coll, template, ok := strings.Cut(strings.TrimSpace(rule), "=")
+			if !ok || coll == "" || validateTemplate(template) != nil {
+				err := fmt.Errorf("invalid YAN_CMS_SITEMAP_URLS rule %q, expected <collection>=<absolute url with {id}>", rule)
+				slog.Error("Invalid sitemap configuration", "error", err)
+				return Config{}, err
+			}
+			cfg.URLs[coll] = template
+		}
+	}
+
+	if value := os.Getenv("YAN_CMS_SITEMAP_CHUNK_SIZE"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > MaxChunkSize {
+			err := fmt.Errorf("invalid YAN_CMS_SITEMAP_CHUNK_SIZE %q, expected an integer from 1 to %d", value, MaxChunkSize)
+			slog.Error("Invalid sitemap configuration", "error", err)
+			return Config{}, err
+		}
+		cfg.ChunkSize = n
+	}
+
+	slog.Info("Sitemap configured", "urls", cfg.URLs, "chunk_size", cfg.ChunkSize)
+	return cfg, nil
+}
+
+func validateTemplate(template string) error {
+	if !strings.Contains(template, "{id}") {
+		return fmt.Errorf("template %q has no {id}", template)
+	}
+	u, err := url.Parse(expand(template, "collection", "class", "id"))
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("template %q is not an absolute http or https url", template)
+	}
+	return nil
+}
+
+func expand(template string, coll string, class string, id string) string {
+	return strings.NewReplacer(
+		"{collection}", url.PathEscape(coll),
+		"{class}", url.PathEscape(class),
+		"{id}", url.PathEscape(id),
+	).Replace(template)
+}
@@ -0,0 +1,407 @@
+// Package sitemap lists the public content of configured collections in
+// sitemaps for search engines, under a sitemap index that splits large
+// collections into chunks.
+//
+// A collection is read in full when it is first requested. After that only
+// the items named by content events since the last request are read again,
+// and only the chunks they fall in are rendered again.
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+const (
+	ContentType = "application/xml; charset=utf-8"
+	namespace   = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+	// maxChanges is how many changed items are read on their own before the
+	// whole collection is read again instead.
+	maxChanges = 1000
+)
+
+var ErrNotFound = errors.New("sitemap not found")
+
+// Source is where sitemaps are read from, usually the content repository.
+type Source interface {
+	EachContent(ctx context.Context, coll string, class string, fn func(*models.Content) error) error
+	GetContents(coll string, ids []string) ([]models.Content, error)
+}
+
+type item struct {
+	id      string
+	class   string
+	created time.Time
+	updated time.Time
+}
+
+func (i item) before(other item) bool {
+	if i.created.Equal(other.created) {
+		return i.id < other.id
+	}
+	return i.created.Before(other.created)
+}
+
+type chunk struct {
+	lastmod time.Time
+	// xml is nil until the chunk is rendered, and again once it changes.
+	xml []byte
+}
+
+// collection holds the public items of a collection in order of creation,
+// so that new items only ever change the last chunk.
+type collection struct {
+	items  []item
+	chunks []chunk
+}
+
+type pending struct {
+	ids    map[string]struct{}
+	reload bool
+}
+
+type Generator struct {
+	config Config
+	source Source
+
+	// mu guards collections and is held while they are brought up to date.
+	mu          sync.Mutex
+	collections map[string]*collection
+
+	// changesMu guards changes, so that recording an event never waits for
+	// a sitemap to be generated.
+	changesMu sync.Mutex
+	changes   map[string]*pending
+}
+
+// New returns a generator for the collections in cfg.URLs, or nil if there
+// are none. A nil generator serves an empty sitemap index.
+func New(cfg Config, source Source) *Generator {
+	if len(cfg.URLs) == 0 {
+		return nil
+	}
+	if cfg.ChunkSize <= 0 || cfg.ChunkSize > MaxChunkSize {
+		cfg.ChunkSize = MaxChunkSize
+	}
+	return &Generator{
+		config:      cfg,
+		source:      source,
+		collections: map[string]*collection{},
+		changes:     map[string]*pending{},
+	}
+}
+
+// Handle records a content event published on this instance.
+func (g *Generator) Handle(e events.Event) {
+	g.changed(e.Type, e.Collection, e.ContentId, e.Ids)
+}
+
+// HandleStreamEvent records a change stream event, which also covers writes
+// made through other replicas.
+func (g *Generator) HandleStreamEvent(se events.StreamEvent) {
+	g.changed(se.Type, se.Collection, se.ContentId, se.Ids)
+}
+
+func (g *Generator) changed(typ string, coll string, id string, ids []string) {
+	if g == nil {
+		return
+	}
+	if _, ok := g.config.URLs[coll]; !ok {
+		return
+	}
+
+	g.changesMu.Lock()
+	defer g.changesMu.Unlock()
+
+	p, ok := g.changes[coll]
+	if !ok {
+		p = &pending{ids: map[string]struct{}{}}
+		g.changes[coll] = p
+	}
+	if p.reload {
+		return
+	}
+	if typ == models.EventCollectionDeleted || (id == "" && len(ids) == 0) {
+		p.reload = true
+		p.ids = nil
+		return
+	}
+
+	if id != "" {
+		p.ids[id] = struct{}{}
+	}
+	for _, id := range ids {
+		p.ids[id] = struct{}{}
+	}
+	// Past this point reading the collection again is cheaper than reading
+	// the changed items.
+	if len(p.ids) > maxChanges {
+		p.reload = true
+		p.ids = nil
+	}
+}
+
+func (g *Generator) takeChanges(coll string) *pending {
+	g.changesMu.Lock()
+	defer g.changesMu.Unlock()
+	p := g.changes[coll]
+	delete(g.changes, coll)
+	return p
+}
+
+// Index renders the sitemap index, which links to every chunk at
+// <base>/sitemaps/<collection>/<n>.xml.
+func (g *Generator) Index(ctx context.Context, base string) ([]byte, error) {
+	doc := sitemapIndex{Namespace: namespace, Sitemaps: []sitemapRef{}}
+	if g == nil {
+		return marshal(doc)
+	}
+
+	colls := make([]string, 0, len(g.config.URLs))
+	for coll := range g.config.URLs {
+		colls = append(colls, coll)
+	}
+	sort.Strings(colls)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, coll := range colls {
+		c, err := g.refresh(ctx, coll)
+		if err != nil {
+			return nil, err
+		}
+		for n, ch := range c.chunks {
+			doc.Sitemaps = append(doc.Sitemaps, sitemapRef{
+				Loc:     fmt.Sprintf("%s/sitemaps/%s/%d.xml", base, url.PathEscape(coll), n+1),
+				LastMod: ch.lastmod.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+
+	return marshal(doc)
+}
+
+// Chunk renders the nth sitemap of a collection, counting from 1.
+func (g *Generator) Chunk(ctx context.Context, coll string, n int) ([]byte, error) {
+	if g == nil {
+		return nil, ErrNotFound
+	}
+	template, ok := g.config.URLs[coll]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, err := g.refresh(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(c.chunks) {
+		return nil, ErrNotFound
+	}
+
+	ch := &c.chunks[n-1]
+	if ch.xml == nil {
+		start := (n - 1) * g.config.ChunkSize
+		end := min(start+g.config.ChunkSize, len(c.items))
+
+		doc := urlset{Namespace: namespace, URLs: make([]urlEntry, 0, end-start)}
+		for _, it := range c.items[start:end] {
+			doc.URLs = append(doc.URLs, urlEntry{
+				Loc:     expand(template, coll, it.class, it.id),
+				LastMod: it.updated.UTC().Format(time.RFC3339),
+			})
+		}
+		out, err := marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		ch.xml = out
+		slog.Debug("Sitemap rendered", "collection", coll, "chunk", n, "urls", end-start)
+	}
+	return ch.xml, nil
+}
+
+// refresh brings a collection up to date with the changes recorded since it
+// was last read. The caller must hold g.mu.
+func (g *Generator) refresh(ctx context.Context, coll string) (*collection, error) {
+	p := g.takeChanges(coll)
+	c, ok := g.collections[coll]
+	if !ok || (p != nil && p.reload) {
+		return g.load(ctx, coll)
+	}
+	if p == nil || len(p.ids) == 0 {
+		return c, nil
+	}
+
+	// stale is the first item whose position may have moved, and changed
+	// holds the chunks of items updated in place.
+	stale := len(c.items)
+	changed := map[int]bool{}
+
+	ids := make([]string, 0, len(p.ids))
+	for id := range p.ids {
+		ids = append(ids, id)
+	}
+	contents, err := g.source.GetContents(coll, ids)
+	if err != nil {
+		// Read everything again next time, rather than lose the changes.
+		g.changed("", coll, "", nil)
+		return nil, err
+	}
+	found := make(map[string]*models.Content, len(contents))
+	for i := range contents {
+		found[contents[i].Id] = &contents[i]
+	}
+	index := make(map[string]int, len(c.items))
+	for i := range c.items {
+		index[c.items[i].id] = i
+	}
+
+	// Items that stay where they are are updated in place. The others are
+	// removed, and put back where they now belong below.
+	removed := map[int]bool{}
+	var added []item
+	for _, id := range ids {
+		content := found[id]
+		i, ok := index[id]
+		if content == nil || !content.IsPublic {
+			if ok {
+				removed[i] = true
+			}
+			continue
+		}
+
+		it := item{id: content.Id, class: content.Class, created: content.CreatedAt, updated: content.UpdatedAt}
+		if ok && c.items[i].created.Equal(it.created) {
+			c.items[i] = it
+			changed[i/g.config.ChunkSize] = true
+			continue
+		}
+		if ok {
+			removed[i] = true
+		}
+		added = append(added, it)
+	}
+
+	if len(removed) > 0 || len(added) > 0 {
+		sort.Slice(added, func(i, j int) bool { return added[i].before(added[j]) })
+		items := make([]item, 0, len(c.items)-len(removed)+len(added))
+		for i, it := range c.items {
+			if removed[i] {
+				stale = min(stale, len(items))
+				continue
+			}
+			for len(added) > 0 && added[0].before(it) {
+				stale = min(stale, len(items))
+				items = append(items, added[0])
+				added = added[1:]
+			}
+			items = append(items, it)
+		}
+		if len(added) > 0 {
+			stale = min(stale, len(items))
+			items = append(items, added...)
+		}
+		c.items = items
+	}
+
+	chunks := (len(c.items) + g.config.ChunkSize - 1) / g.config.ChunkSize
+	if chunks < len(c.chunks) {
+		c.chunks = c.chunks[:chunks]
+	}
+	for n := range chunks {
+		if n >= len(c.chunks) {
+			c.chunks = append(c.chunks, chunk{})
+		} else if !changed[n] && (n+1)*g.config.ChunkSize <= stale {
+			continue
+		}
+		c.chunks[n] = chunk{lastmod: g.lastmod(c, n)}
+	}
+
+	slog.Info("Sitemap updated", "collection", coll, "changes", len(p.ids), "urls", len(c.items), "chunks", chunks)
+	return c, nil
+}
+
+func (g *Generator) load(ctx context.Context, coll string) (*collection, error) {
+	slog.Debug("Reading collection for sitemap", "collection", coll)
+	c := &collection{}
+	err := g.source.EachContent(ctx, coll, "", func(content *models.Content) error {
+		if content.IsPublic {
+			c.items = append(c.items, item{id: content.Id, class: content.Class, created: content.CreatedAt, updated: content.UpdatedAt})
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to read collection for sitemap", "collection", coll, "error", err)
+		g.changed("", coll, "", nil)
+		return nil, err
+	}
+	// EachContent orders by creation time too, but ties are broken here the
+	// same way as in refresh.
+	sort.SliceStable(c.items, func(i, j int) bool { return c.items[i].before(c.items[j]) })
+
+	chunks := (len(c.items) + g.config.ChunkSize - 1) / g.config.ChunkSize
+	c.chunks = make([]chunk, chunks)
+	for n := range c.chunks {
+		c.chunks[n].lastmod = g.lastmod(c, n)
+	}
+	g.collections[coll] = c
+
+	slog.Info("Sitemap generated", "collection", coll, "urls", len(c.items), "chunks", chunks)
+	return c, nil
+}
+
+func (g *Generator) lastmod(c *collection, n int) time.Time {
+	var lastmod time.Time
+	for _, it := range c.items[n*g.config.ChunkSize : min((n+1)*g.config.ChunkSize, len(c.items))] {
+		if it.updated.After(lastmod) {
+			lastmod = it.updated
+		}
+	}
+	return lastmod
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type urlset struct {
+	XMLName   xml.Name   `xml:"urlset"`
+	Namespace string     `xml:"xmlns,attr"`
+	URLs      []urlEntry `xml:"url"`
+}
+
+type sitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemapIndex struct {
+	XMLName   xml.Name     `xml:"sitemapindex"`
+	Namespace string       `xml:"xmlns,attr"`
+	Sitemaps  []sitemapRef `xml:"sitemap"`
+}
+
+func marshal(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
@@ -0,0 +1,190 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSource struct {
+	contents map[string]*models.Content
+	scans    int
+	gets     int
+}
+
+func (s *fakeSource) EachContent(ctx context.Context, coll string, class string, fn func(*models.Content) error) error {
+	s.scans++
+	for _, c := range s.contents {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeSource) GetContents(coll string, ids []string) ([]models.Content, error) {
+	s.gets++
+	contents := []models.Content{}
+	for _, id := range ids {
+		if c, ok := s.contents[id]; ok {
+			contents = append(contents, *c)
+		}
+	}
+	return contents, nil
+}
+
+var start = time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func (s *fakeSource) put(id string, minute int, public bool) {
+	s.contents[id] = &models.Content{
+		Id:        id,
+		Class:     "go basics",
+		IsPublic:  public,
+		CreatedAt: start.Add(time.Duration(minute) * time.Minute),
+		UpdatedAt: start.Add(time.Duration(minute) * time.Minute),
+	}
+}
+
+func urls(t *testing.T, g *Generator, n int) []string {
+	out, err := g.Chunk(context.Background(), "courses", n)
+	assert.NoError(t, err)
+	result := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "<loc>") {
+			result = append(result, strings.TrimSuffix(strings.TrimPrefix(line, "<loc>https://learn.example/"), "</loc>"))
+		}
+	}
+	return result
+}
+
+func TestGenerator(t *testing.T) {
+	source := &fakeSource{contents: map[string]*models.Content{}}
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		source.put(id, i, id != "c")
+	}
+	g := New(Config{URLs: map[string]string{"courses": "https://learn.example/{class}/{id}"}, ChunkSize: 2}, source)
+	ctx := context.Background()
+
+	t.Run("Index", func(t *testing.T) {
+		out, err := g.Index(ctx, "https://cms.example")
+		assert.NoError(t, err)
+		doc := string(out)
+		assert.Contains(t, doc, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		assert.Contains(t, doc, "<loc>https://cms.example/sitemaps/courses/1.xml</loc>\n    <lastmod>2024-06-10T12:01:00Z</lastmod>")
+		assert.Contains(t, doc, "<loc>https://cms.example/sitemaps/courses/2.xml</loc>\n    <lastmod>2024-06-10T12:04:00Z</lastmod>")
+		assert.NotContains(t, doc, "3.xml")
+	})
+
+	t.Run("Chunks", func(t *testing.T) {
+		assert.Equal(t, []string{"go%20basics/a", "go%20basics/b"}, urls(t, g, 1))
+		assert.Equal(t, []string{"go%20basics/d", "go%20basics/e"}, urls(t, g, 2))
+
+		out, _ := g.Chunk(ctx, "courses", 1)
+		assert.Contains(t, string(out), "<lastmod>2024-06-10T12:00:00Z</lastmod>")
+
+		_, err := g.Chunk(ctx, "courses", 3)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = g.Chunk(ctx, "drafts", 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Updates Only Affected Chunks", func(t *testing.T) {
+		first, _ := g.Chunk(ctx, "courses", 1)
+		source.contents["e"].UpdatedAt = start.Add(time.Hour)
+		g.Handle(events.Event{Type: models.EventContentUpdated, Collection: "courses", ContentId: "e"})
+
+		out, _ := g.Chunk(ctx, "courses", 2)
+		assert.Contains(t, string(out), "<lastmod>2024-06-10T13:00:00Z</lastmod>")
+		again, _ := g.Chunk(ctx, "courses", 1)
+		assert.Same(t, &first[0], &again[0])
+		assert.Equal(t, 1, source.scans)
+		assert.Equal(t, 1, source.gets)
+	})
+
+	t.Run("Creating And Publishing", func(t *testing.T) {
+		source.put("f", 10, true)
+		source.contents["c"].IsPublic = true
+		g.HandleStreamEvent(events.StreamEvent{Type: models.EventContentCreated, Collection: "courses", ContentId: "f"})
+		g.HandleStreamEvent(events.StreamEvent{Type: models.EventContentUpdated, Collection: "courses", ContentId: "c"})
+
+		assert.Equal(t, []string{"go%20basics/a", "go%20basics/b"}, urls(t, g, 1))
+		assert.Equal(t, []string{"go%20basics/c", "go%20basics/d"}, urls(t, g, 2))
+		assert.Equal(t, []string{"go%20basics/e", "go%20basics/f"}, urls(t, g, 3))
+		assert.Equal(t, 2, source.gets)
+	})
+
+	t.Run("Deleting", func(t *testing.T) {
+		delete(source.contents, "a")
+		delete(source.contents, "b")
+		g.Handle(events.Event{Type: models.EventClassDeleted, Collection: "courses", Ids: []string{"a", "b"}})
+
+		assert.Equal(t, []string{"go%20basics/c", "go%20basics/d"}, urls(t, g, 1))
+		_, err := g.Chunk(ctx, "courses", 3)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 1, source.scans)
+	})
+
+	t.Run("Many Changes Reload", func(t *testing.T) {
+		for i := range maxChanges + 1 {
+			g.Handle(events.Event{Type: models.EventContentUpdated, Collection: "courses", ContentId: fmt.Sprint("x", i)})
+		}
+		assert.Equal(t, []string{"go%20basics/c", "go%20basics/d"}, urls(t, g, 1))
+		assert.Equal(t, 2, source.scans)
+		assert.Equal(t, 3, source.gets)
+	})
+
+	t.Run("Collection Deleted Reloads", func(t *testing.T) {
+		g.HandleStreamEvent(events.StreamEvent{Type: models.EventCollectionDeleted, Collection: "courses"})
+		urls(t, g, 1)
+		assert.Equal(t, 3, source.scans)
+	})
+
+	t.Run("Other Collections Are Ignored", func(t *testing.T) {
+		g.Handle(events.Event{Type: models.EventContentCreated, Collection: "drafts", ContentId: "x"})
+		assert.Empty(t, g.changes)
+	})
+}
+
+func TestNilGenerator(t *testing.T) {
+	g := New(Config{}, nil)
+	assert.Nil(t, g)
+
+	out, err := g.Index(context.Background(), "https://cms.example")
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "<sitemapindex")
+	_, err = g.Chunk(context.Background(), "courses", 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	g.Handle(events.Event{Collection: "courses"})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("YAN_CMS_SITEMAP_URLS", "courses=https://learn.example/courses/{id}?ref=sitemap, blog=https://abyan.dev/{class}/{id}")
+	t.Setenv("YAN_CMS_SITEMAP_CHUNK_SIZE", "1000")
+	cfg, err := ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"courses": "https://learn.example/courses/{id}?ref=sitemap",
+		"blog":    "https://abyan.dev/{class}/{id}",
+	}, cfg.URLs)
+	assert.Equal(t, 1000, cfg.ChunkSize)
+
+	for _, rules := range []string{"courses", "courses=/courses/{id}", "courses=https://learn.example/courses", "=https://learn.example/{id}"} {
+		t.Setenv("YAN_CMS_SITEMAP_URLS", rules)
+		_, err := ConfigFromEnv()
+		assert.Error(t, err, rules)
+	}
+
+	t.Setenv("YAN_CMS_SITEMAP_URLS", "")
+	for _, size := range []string{"0", fmt.Sprint(MaxChunkSize + 1), "many"} {
+		t.Setenv("YAN_CMS_SITEMAP_CHUNK_SIZE", size)
+		_, err := ConfigFromEnv()
+		assert.Error(t, err, size)
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type CacheOptions struct {
+	// Weak marks the ETag as weak, for responses such as listings whose
+	// bytes are not guaranteed to be the same for the same contents.
+	Weak bool
+	// LastModified is sent as Last-Modified unless it is zero.
+	LastModified time.Time
+	// Public allows shared caches such as CDNs to store the response.
+	// Otherwise only the client may, and it must revalidate every time.
+	Public bool
+	MaxAge time.Duration
+}
+
+// WriteCached writes data as a 200 response like Write, with an ETag
+// computed from the body and the validators and Cache-Control of opts. When
+// the request's If-None-Match or If-Modified-Since shows the client already
+// has this representation, it writes a 304 with no body instead.
+func WriteCached(w http.ResponseWriter, r *http.Request, data any, opts CacheOptions) error {
+	slog.Debug("WriteCached called", "weak", opts.Weak, "public", opts.Public)
+
+	out, encoder, err := encode(r, data)
+	if errors.Is(err, errNotAcceptable) {
+		return notAcceptable(w)
+	}
+	if err != nil {
+		slog.Error("Failed to encode response", "error", err)
+		return err
+	}
+
+	w.Header().Add("Vary", "Accept")
+	return WriteCachedBytes(w, r, encoder.ContentType(), out, opts)
+}
+
+// WriteCachedBytes is WriteCached for a body that is already encoded, such
+// as a feed, whose format is not chosen by content negotiation.
+func WriteCachedBytes(w http.ResponseWriter, r *http.Request, contentType string, out []byte, opts CacheOptions) error {
+	sum := sha256.Sum256(out)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if opts.Weak {
+		etag = "W/" + etag
+	}
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	if !opts.LastModified.IsZero() {
+		header.Set("Last-Modified", opts.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if opts.Public {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(opts.MaxAge.Seconds())))
+	} else {
+		header.Set("Cache-Control", "private, no-cache")
+	}
+
+	if NotModified(r, etag, opts.LastModified) {
+		slog.Debug("Representation not modified", "etag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	header.Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(out); err != nil {
+		slog.Error("Failed to write response", "error", err)
+		return err
+	}
+
+	slog.Debug("Response written successfully", "content_type", contentType)
+	return nil
+}
+
+// NotModified reports whether the conditional headers of a GET request match
+// the current validators. If-None-Match uses weak comparison and takes
+// precedence over If-Modified-Since, as RFC 9110 requires.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a resolution of one second.
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCached(t *testing.T) {
+	modified := time.Date(2024, 6, 10, 12, 0, 0, 500, time.UTC)
+	data := map[string]string{"title": "Lesson"}
+
+	write := func(opts CacheOptions, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		assert.NoError(t, WriteCached(rr, r, data, opts))
+		return rr
+	}
+
+	first := write(CacheOptions{LastModified: modified, Public: true, MaxAge: time.Minute}, nil)
+	etag := first.Header().Get("ETag")
+
+	t.Run("Full Response", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.JSONEq(t, `{"title":"Lesson"}`, first.Body.String())
+		assert.True(t, strings.HasPrefix(etag, `"`))
+		assert.Equal(t, "Mon, 10 Jun 2024 12:00:00 GMT", first.Header().Get("Last-Modified"))
+		assert.Equal(t, "public, max-age=60", first.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Private", func(t *testing.T) {
+		rr := write(CacheOptions{}, nil)
+		assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+		assert.Empty(t, rr.Header().Get("Last-Modified"))
+		assert.Equal(t, etag, rr.Header().Get("ETag"))
+	})
+
+	t.Run("Weak", func(t *testing.T) {
+		rr := write(CacheOptions{Weak: true}, nil)
+		assert.Equal(t, "W/"+etag, rr.Header().Get("ETag"))
+	})
+
+	t.Run("If-None-Match", func(t *testing.T) {
+		rr := write(CacheOptions{}, map[string]string{"If-None-Match": `"other", ` + etag})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		assert.Equal(t, etag, rr.Header().Get("ETag"))
+
+		rr = write(CacheOptions{Weak: true}, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+
+		rr = write(CacheOptions{}, map[string]string{"If-None-Match": `"other"`})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		opts := CacheOptions{LastModified: modified}
+		rr := write(opts, map[string]string{"If-Modified-Since": "Mon, 10 Jun 2024 12:00:00 GMT"})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+
+		rr = write(opts, map[string]string{"If-Modified-Since": "Mon, 10 Jun 2024 11:59:59 GMT"})
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = write(opts, map[string]string{"If-Modified-Since": "yesterday"})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("If-None-Match Takes Precedence", func(t *testing.T) {
+		rr := write(CacheOptions{LastModified: modified}, map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": "Mon, 10 Jun 2024 12:00:00 GMT",
+		})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -2,10 +2,13 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -29,3 +32,30 @@ func ConnectToDB() (*mongo.Client, error) {
 
 	return client, nil
 }
+
+// indexOptionsConflict is the code MongoDB returns when an index with the
+// same keys already exists with other options.
+const indexOptionsConflict = 85
+
+// EnsureTTLIndex creates an index that expires documents ttl after the time
+// stored in field. When the index already exists with another expiry, as it
+// does after the configured window changes, the expiry is updated in place.
+func EnsureTTLIndex(coll *mongo.Collection, field string, ttl time.Duration) error {
+	keys := bson.D{{Key: field, Value: 1}}
+	seconds := int32(ttl.Seconds())
+
+	_, err := coll.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetExpireAfterSeconds(seconds),
+	})
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || !cmdErr.HasErrorCode(indexOptionsConflict) {
+		return err
+	}
+
+	slog.Info("Updating expiry of TTL index", "collection", coll.Name(), "field", field, "seconds", seconds)
+	return coll.Database().RunCommand(context.TODO(), bson.D{
+		{Key: "collMod", Value: coll.Name()},
+		{Key: "index", Value: bson.D{{Key: "keyPattern", Value: keys}, {Key: "expireAfterSeconds", Value: seconds}}},
+	}).Err()
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	t.Run("Host And Port", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		assert.Equal(t, "10.0.0.1", ClientIP(req))
+	})
+
+	t.Run("Bare Address", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.7"
+		assert.Equal(t, "203.0.113.7", ClientIP(req))
+	})
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/YanSystems/cms/pkg/codec"
+	"github.com/YanSystems/cms/pkg/models"
+)
+
+// ErrUnsupportedMediaType is returned by Read for bodies it has no decoder
+// for. Error responds to it with 415.
+var ErrUnsupportedMediaType = errors.New("unsupported content type")
+
+// Read decodes the request body into data with the decoder for its
+// Content-Type.
+func Read(w http.ResponseWriter, r *http.Request, data any) error {
+	return ReadLimit(w, r, data, 1048576) // one megabyte
+}
+
+// ReadLimit is Read for bodies of up to maxBytes.
+func ReadLimit(w http.ResponseWriter, r *http.Request, data any, maxBytes int64) error {
+	slog.Debug("Read called", "content_type", r.Header.Get("Content-Type"), "max_bytes", maxBytes)
+
+	decoder, ok := codec.DecoderFor(r.Header.Get("Content-Type"))
+	if !ok {
+		err := fmt.Errorf("%w %q, expected one of %s", ErrUnsupportedMediaType, r.Header.Get("Content-Type"), strings.Join(codec.DecoderTypes(), ", "))
+		slog.Error("Unsupported content type", "error", err)
+		return err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Error("Failed to read request body", "error", err)
+		return err
+	}
+
+	if err := decoder.Decode(body, data); err != nil {
+		slog.Error("Failed to decode request body", "error", err)
+		return err
+	}
+	slog.Debug("Request body decoded successfully", "data", data)
+	return nil
+}
+
+// Write writes data like WriteJSON, in the most preferred format of the
+// request's Accept header. GET requests that accept no format data can be
+// written in get a 406. Other requests get JSON instead, since they have
+// already taken effect.
+func Write(w http.ResponseWriter, r *http.Request, status int, data any, headers ...http.Header) error {
+	slog.Debug("Write called", "status", status, "accept", r.Header.Get("Accept"))
+
+	out, encoder, err := encode(r, data)
+	if errors.Is(err, errNotAcceptable) && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		out, err = codec.JSON().Encode(data)
+		encoder = codec.JSON()
+	}
+	if errors.Is(err, errNotAcceptable) {
+		return notAcceptable(w)
+	}
+	if err != nil {
+		slog.Error("Failed to encode response", "error", err)
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	if _, err := w.Write(out); err != nil {
+		slog.Error("Failed to write response", "error", err)
+		return err
+	}
+
+	slog.Debug("Response written successfully", "content_type", encoder.ContentType())
+	return nil
+}
+
+// Error writes an error response like ErrorJSON, in a format the request
+// accepts or otherwise in JSON. Errors from Read for unsupported bodies get a
+// 415 unless another status is given.
+func Error(w http.ResponseWriter, r *http.Request, err error, status ...int) error {
+	slog.Debug("Error called", "error", err)
+	statusCode := http.StatusBadRequest
+	if len(status) > 0 {
+		statusCode = status[0]
+	} else if errors.Is(err, ErrUnsupportedMediaType) {
+		statusCode = http.StatusUnsupportedMediaType
+		w.Header().Set("Accept", strings.Join(codec.DecoderTypes(), ", "))
+	}
+
+	payload := models.JsonResponse{Error: true, Message: err.Error()}
+	out, encoder, encodeErr := encode(r, payload)
+	if encodeErr != nil {
+		return WriteJSON(w, statusCode, payload)
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(statusCode)
+	_, writeErr := w.Write(out)
+	return writeErr
+}
+
+var errNotAcceptable = errors.New("not acceptable")
+
+// encode tries the acceptable encoders in order of preference, skipping
+// those that cannot represent data.
+func encode(r *http.Request, data any) ([]byte, *codec.Encoder, error) {
+	for _, encoder := range codec.Negotiate(r.Header.Get("Accept")) {
+		out, err := encoder.Encode(data)
+		if errors.Is(err, codec.ErrNotRepresentable) {
+			continue
+		}
+		return out, encoder, err
+	}
+	return nil, nil, errNotAcceptable
+}
+
+func notAcceptable(w http.ResponseWriter) error {
+	err := fmt.Errorf("none of the accepted media types can be produced, available types are %s", strings.Join(codec.EncoderTypes(), ", "))
+	slog.Error("Not acceptable", "error", err)
+	ErrorJSON(w, err, http.StatusNotAcceptable)
+	return err
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	read := func(contentType string, body string) (models.Content, error) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		var c models.Content
+		err := Read(httptest.NewRecorder(), r, &c)
+		return c, err
+	}
+
+	c, err := read("", `{"title":"Intro"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Intro", c.Title)
+
+	c, err = read("application/yaml", "title: Intro\nviews: 3\n")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Content{Title: "Intro", Views: 3}, c)
+
+	c, err = read("application/msgpack", "\x81\xa5title\xa5Intro")
+	assert.NoError(t, err)
+	assert.Equal(t, "Intro", c.Title)
+
+	_, err = read("application/json", `{"title":"Intro"}{}`)
+	assert.EqualError(t, err, "body must have only a single JSON value")
+
+	_, err = read("text/plain", "Intro")
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+
+	rr := httptest.NewRecorder()
+	Error(rr, httptest.NewRequest(http.MethodPost, "/", nil), err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	assert.Equal(t, "application/json, application/yaml, application/msgpack", rr.Header().Get("Accept"))
+}
+
+func TestWrite(t *testing.T) {
+	listing := models.JsonResponse{Message: "Successfully retrieved class", Data: []map[string]string{{"id": "a", "title": "Intro"}}}
+	item := models.JsonResponse{Message: "Successfully retrieved content", Data: map[string]string{"id": "a"}}
+
+	write := func(method string, accept string, data any) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		r.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		Write(rr, r, http.StatusOK, data)
+		return rr
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		rr := write(http.MethodGet, "", item)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+		assert.JSONEq(t, `{"error":false,"message":"Successfully retrieved content","data":{"id":"a"}}`, rr.Body.String())
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		rr := write(http.MethodGet, "application/yaml", item)
+		assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "error: false\nmessage: Successfully retrieved content\ndata:\n  id: a\n", rr.Body.String())
+	})
+
+	t.Run("CSV Listing", func(t *testing.T) {
+		rr := write(http.MethodGet, "text/csv", listing)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "id,title\na,Intro\n", rr.Body.String())
+	})
+
+	t.Run("CSV Falls Back To The Next Type", func(t *testing.T) {
+		rr := write(http.MethodGet, "text/csv, application/xml;q=0.5", item)
+		assert.Equal(t, "application/xml", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("Not Acceptable", func(t *testing.T) {
+		rr := write(http.MethodGet, "text/csv", item)
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		rr = write(http.MethodPost, "image/png", item)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "text/csv, application/yaml;q=0.1")
+		rr := httptest.NewRecorder()
+		Error(rr, r, errors.New("content not found"), http.StatusNotFound)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+
+		r.Header.Set("Accept", "image/png")
+		rr = httptest.NewRecorder()
+		Error(rr, r, errors.New("content not found"))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	})
+}
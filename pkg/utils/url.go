@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BaseURL returns configured without a trailing slash, or the scheme and
+// host the request was made to when it is empty, for building absolute links
+// to the service.
+func BaseURL(r *http.Request, configured string) string {
+	if configured != "" {
+		return strings.TrimSuffix(configured, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "/feeds/courses.atom", nil)
+	r.Host = "cms.example"
+
+	assert.Equal(t, "http://cms.example", BaseURL(r, ""))
+	assert.Equal(t, "https://abyan.dev/cms", BaseURL(r, "https://abyan.dev/cms/"))
+}
@@ -0,0 +1,249 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/YanSystems/cms/pkg/repositories/webhooks"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Dispatcher struct {
+	DB           *mongo.Database
+	Client       *http.Client
+	MaxAttempts  int
+	DisableAfter int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	Concurrency  int
+
+	wake chan struct{}
+}
+
+func NewDispatcher(db *mongo.Database) *Dispatcher {
+	return &Dispatcher{
+		DB:           db,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:  8,
+		DisableAfter: 20,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+		PollInterval: 10 * time.Second,
+		Concurrency:  4,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Handle records the deliveries for an event before the request that
+// produced it returns, so that none are lost if the server stops, and wakes
+// the dispatcher to send them. Sending happens in Run and never holds up the
+// request.
+func (d *Dispatcher) Handle(e events.Event) {
+	d.fanOut(e)
+	d.Wake()
+}
+
+// Queue records the deliveries for an event like Handle, without waking the
+// dispatcher. It suits short lived processes that do not run the dispatcher,
+// such as cmsctl; a running server sends the deliveries on its next poll.
+func (d *Dispatcher) Queue(e events.Event) {
+	d.fanOut(e)
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.Info("Webhook dispatcher started")
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Webhook dispatcher stopped")
+			return
+		case <-d.wake:
+			d.deliverDue(ctx)
+		case <-ticker.C:
+			d.deliverDue(ctx)
+		}
+	}
+}
+
+// Wake makes the dispatcher look for due deliveries straight away instead of
+// waiting for the next poll, such as after a manual redelivery.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) fanOut(e events.Event) {
+	repo := webhooks.WebhookRepository{DB: d.DB}
+	subscribers, err := repo.FindSubscribers(e.Type, e.Collection, e.Class)
+	if err != nil {
+		slog.Error("Failed to find webhook subscribers", "event", e.Id, "error", err)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(NewPayload(e))
+	if err != nil {
+		slog.Error("Failed to marshal webhook payload", "event", e.Id, "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, webhook := range subscribers {
+		delivery := models.WebhookDelivery{
+			Id:            uuid.New().String(),
+			WebhookId:     webhook.Id,
+			EventId:       e.Id,
+			Event:         e.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+			UpdatedAt:     now,
+			CreatedAt:     now,
+		}
+		if _, err := repo.CreateDelivery(&delivery); err != nil {
+			slog.Error("Failed to queue webhook delivery", "webhook", webhook.Id, "event", e.Id, "error", err)
+		}
+	}
+	slog.Info("Webhook deliveries queued", "event", e.Id, "type", e.Type, "subscribers", len(subscribers))
+}
+
+func NewPayload(e events.Event) models.WebhookPayload {
+	content := e.After
+	if content == nil {
+		content = e.Before
+	}
+	return models.WebhookPayload{
+		Id:            e.Id,
+		Event:         e.Type,
+		Collection:    e.Collection,
+		ContentId:     e.ContentId,
+		Class:         e.Class,
+		PreviousClass: e.PreviousClass,
+		Ids:           e.Ids,
+		Content:       content,
+		Timestamp:     e.Time,
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	repo := webhooks.WebhookRepository{DB: d.DB}
+	lease := d.Client.Timeout + time.Minute
+	slots := make(chan struct{}, d.Concurrency)
+	var wg sync.WaitGroup
+
+	for ctx.Err() == nil {
+		delivery, err := repo.ClaimDelivery(time.Now().UTC(), lease)
+		if err != nil || delivery == nil {
+			break
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			d.Deliver(delivery)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// Deliver makes one attempt at a delivery and records the outcome, scheduling
+// a retry with exponential backoff if it failed.
+func (d *Dispatcher) Deliver(delivery *models.WebhookDelivery) {
+	repo := webhooks.WebhookRepository{DB: d.DB}
+
+	webhook, err := repo.GetWebhook(delivery.WebhookId)
+	if err != nil {
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = nil
+		repo.UpdateDelivery(delivery)
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	status, err := d.send(webhook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		slog.Info("Webhook delivered", "webhook", webhook.Id, "delivery", delivery.Id, "status", status)
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		repo.UpdateDelivery(delivery)
+		repo.RecordSuccess(webhook.Id)
+		return
+	}
+
+	slog.Warn("Webhook delivery failed", "webhook", webhook.Id, "delivery", delivery.Id, "attempt", delivery.Attempts, "error", err)
+	delivery.LastError = err.Error()
+
+	disabled, _ := repo.RecordFailure(webhook.Id, d.DisableAfter)
+	if disabled || !webhook.Active || delivery.Attempts >= d.MaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+	} else {
+		next := now.Add(Backoff(delivery.Attempts, d.BaseBackoff, d.MaxBackoff))
+		delivery.NextAttemptAt = &next
+	}
+	repo.UpdateDelivery(delivery)
+}
+
+func (d *Dispatcher) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Yan-CMS-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.Id)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, time.Now(), body))
+
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func Backoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	return backoff
+}
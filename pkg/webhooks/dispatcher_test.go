@@ -0,0 +1,69 @@
+package webhooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YanSystems/cms/pkg/events"
+	"github.com/YanSystems/cms/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	base := 30 * time.Second
+	assert.Equal(t, 30*time.Second, Backoff(1, base, time.Hour))
+	assert.Equal(t, time.Minute, Backoff(2, base, time.Hour))
+	assert.Equal(t, 4*time.Minute, Backoff(4, base, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(20, base, time.Hour))
+}
+
+func TestNewPayload(t *testing.T) {
+	before := &models.Content{Id: "a", Title: "old"}
+	after := &models.Content{Id: "a", Title: "new"}
+
+	payload := NewPayload(events.Event{Id: "1", Type: models.EventContentUpdated, Collection: "lessons", ContentId: "a", Before: before, After: after})
+	assert.Equal(t, "new", payload.Content.Title)
+
+	payload = NewPayload(events.Event{Id: "2", Type: models.EventContentDeleted, Collection: "lessons", ContentId: "a", Before: before})
+	assert.Equal(t, "old", payload.Content.Title)
+
+	payload = NewPayload(events.Event{Id: "3", Type: models.EventClassDeleted, Collection: "lessons", Ids: []string{"a", "b"}})
+	assert.Nil(t, payload.Content)
+	assert.Equal(t, []string{"a", "b"}, payload.Ids)
+}
+
+func TestSend(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	d := NewDispatcher(nil)
+	delivery := &models.WebhookDelivery{Id: "delivery-1", Event: models.EventContentCreated, Payload: `{"id":"1"}`}
+
+	t.Run("Signed Request", func(t *testing.T) {
+		status, err := d.send(&models.Webhook{URL: endpoint.URL + "/ok", Secret: "secret"}, delivery)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, models.EventContentCreated, received.Header.Get(EventHeader))
+		assert.Equal(t, "delivery-1", received.Header.Get(DeliveryHeader))
+		assert.True(t, Verify("secret", received.Header.Get(SignatureHeader), body, time.Minute, time.Now()))
+	})
+
+	t.Run("Error Status", func(t *testing.T) {
+		status, err := d.send(&models.Webhook{URL: endpoint.URL + "/fail", Secret: "secret"}, delivery)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, status)
+	})
+}
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Yan-Signature"
+	EventHeader     = "X-Yan-Event"
+	DeliveryHeader  = "X-Yan-Delivery"
+)
+
+// Sign produces the signature header value for a payload. Receivers should
+// recompute the HMAC over "<t>.<body>" and compare it with v1, rejecting
+// timestamps that are too old to guard against replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, computeMAC(secret, t, body))
+}
+
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) bool {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return false
+	}
+	if tolerance > 0 && now.Sub(time.Unix(unix, 0)).Abs() > tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(v1), []byte(computeMAC(secret, t, body)))
+}
+
+func computeMAC(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
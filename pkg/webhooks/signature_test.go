@@ -0,0 +1,21 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"event":"content.created"}`)
+	now := time.Unix(1700000000, 0)
+	header := Sign("secret", now, body)
+
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, header)
+	assert.True(t, Verify("secret", header, body, 5*time.Minute, now))
+	assert.False(t, Verify("other-secret", header, body, 5*time.Minute, now))
+	assert.False(t, Verify("secret", header, []byte(`{}`), 5*time.Minute, now))
+	assert.False(t, Verify("secret", header, body, 5*time.Minute, now.Add(time.Hour)))
+	assert.False(t, Verify("secret", "garbage", body, 0, now))
+}